@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	_ "net/http/pprof" // Импортируем pprof
 	"os"
 	"pdf-service-go/internal/api"
 	"pdf-service-go/internal/domain/pdf"
+	"pdf-service-go/internal/pkg/admission"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/encryption"
+	"pdf-service-go/internal/pkg/errortracker"
 	"pdf-service-go/internal/pkg/logger"
 	"pdf-service-go/internal/pkg/statistics"
 	"runtime"
@@ -27,6 +34,34 @@ func main() {
 	}
 	defer logger.Log.Sync()
 
+	// Инициализируем хранилище артефактов (локальный диск или S3)
+	artifactStore, err := artifacts.New(artifacts.ConfigFromEnv())
+	if err != nil {
+		logger.Fatal("Failed to initialize artifact store", logger.Field("error", err))
+	}
+
+	// Шифрование архива (тела запросов и PDF) ключами из keyring
+	keyring, err := encryption.KeyringFromEnv()
+	if err != nil {
+		logger.Fatal("Failed to load archive encryption keyring", logger.Field("error", err))
+	}
+	if keyring.Enabled() {
+		encryption.SetDefault(keyring)
+		encryptedStore, err := artifacts.NewEncryptedStore(artifactStore, keyring)
+		if err != nil {
+			logger.Fatal("Failed to enable artifact encryption", logger.Field("error", err))
+		}
+		artifactStore = encryptedStore
+		logger.Info("Archive encryption enabled", logger.Field("active_key", keyring.ActiveKeyID()))
+	}
+	artifacts.SetDefault(artifactStore)
+	logger.Info("Artifact store initialized", logger.Field("backend", artifactStore.Backend()))
+
+	// Подписанные ссылки на скачивание артефактов
+	if err := artifacts.InitDefaultSigner(); err != nil {
+		logger.Fatal("Failed to configure artifact download links", logger.Field("error", err))
+	}
+
 	// Инициализируем статистику с ретраями в фоне, чтобы не падать при кратковременной недоступности БД
 	statsConfig := statistics.Config{
 		Host:     os.Getenv("POSTGRES_HOST"),
@@ -55,22 +90,43 @@ func main() {
 				continue
 			}
 			logger.Info("Statistics initialized with PostgreSQL")
+			// Перешифровываем архив после ротации ключей (только если шифрование включено)
+			statistics.StartArchiveReencryption(context.Background())
 			return
 		}
 	}()
 
+	// Подписываемся на смены состояния всех Circuit Breaker: лог, трекер ошибок и вебхуки
+	breakers := circuitbreaker.Default()
+	breakers.OnStateChange(circuitbreaker.LogListener(logger.Log))
+	breakers.OnStateChange(trackBreakerOpen)
+	for _, listener := range circuitbreaker.WebhookListenersFromEnv(logger.Log) {
+		breakers.OnStateChange(listener)
+	}
+
 	// Создаем PDF сервис
 	gotenbergURL := os.Getenv("GOTENBERG_API_URL")
 	if gotenbergURL == "" {
 		// Пробуем альтернативное имя переменной для обратной совместимости
 		gotenbergURL = os.Getenv("GOTENBERG_URL")
-		if gotenbergURL == "" {
-			logger.Fatal("GOTENBERG_API_URL or GOTENBERG_URL environment variable is not set")
+		// Список бэкендов GOTENBERG_BACKENDS заменяет одиночный URL
+		if gotenbergURL == "" && os.Getenv("GOTENBERG_BACKENDS") == "" {
+			logger.Fatal("GOTENBERG_API_URL, GOTENBERG_URL or GOTENBERG_BACKENDS environment variable is not set")
 		}
 	}
 
-	pdfService := pdf.NewService(gotenbergURL)
-	logger.Info("PDF service created", logger.Field("gotenberg_url", gotenbergURL))
+	// Ограничиваем одновременные генерации и ставим остальные запросы в очередь
+	admissionConfig := admission.ConfigFromEnv()
+	admissionController := admission.New(admissionConfig)
+	admission.SetDefault(admissionController)
+	pdfService := pdf.NewAdmissionService(pdf.NewService(gotenbergURL), admissionController)
+	logger.Info("PDF service created",
+		logger.Field("gotenberg_url", gotenbergURL),
+		logger.Field("max_in_flight", admissionConfig.MaxInFlight),
+		logger.Field("admission_queue_size", admissionConfig.QueueSize),
+		logger.Field("admission_queue_timeout", admissionConfig.QueueTimeout.String()),
+		logger.Field("priority_classes", admissionController.Classes()),
+	)
 
 	// Создаем обработчики
 	handlers := api.NewHandlers(pdfService)
@@ -104,3 +160,20 @@ func main() {
 		logger.Fatal("Failed to start server", logger.Field("error", err))
 	}
 }
+
+// trackBreakerOpen записывает открытие Circuit Breaker в трекер ошибок с причиной и последней ошибкой
+func trackBreakerOpen(t circuitbreaker.Transition) {
+	if t.To != circuitbreaker.StateOpen {
+		return
+	}
+	errortracker.TrackError(context.Background(),
+		fmt.Errorf("circuit breaker %s opened: %s", t.Name, t.Reason),
+		errortracker.WithComponent("circuit_breaker"),
+		errortracker.WithErrorType("circuit_open"),
+		errortracker.WithSeverity("high"),
+		errortracker.WithRequestDetails("circuit_breaker", t.Name),
+		errortracker.WithRequestDetails("reason", t.Reason),
+		errortracker.WithRequestDetails("detail", t.Detail),
+		errortracker.WithRequestDetails("last_error", t.LastError),
+	)
+}
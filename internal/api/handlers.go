@@ -5,7 +5,12 @@ import (
 	"net/http"
 	"pdf-service-go/internal/api/handlers"
 	"pdf-service-go/internal/domain/pdf"
+	"pdf-service-go/internal/pkg/admission"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/auth"
+	"pdf-service-go/internal/pkg/circuitbreaker"
 	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/ratelimit"
 	"pdf-service-go/internal/pkg/statistics"
 	"time"
 
@@ -19,15 +24,29 @@ type Handlers struct {
 	Statistics      *handlers.StatisticsHandler
 	Errors          *handlers.ErrorHandler
 	RequestAnalysis *handlers.RequestAnalysisHandler
+	Artifacts       *handlers.ArtifactHandler
+	Auth            *handlers.AuthHandler
+	Breakers        *handlers.BreakerHandler
+	Authenticator   *auth.Authenticator
+	RateLimiter     *ratelimit.Limiter
+	Admission       *admission.Controller
 }
 
 // NewHandlers создает новые обработчики
 func NewHandlers(service pdf.Service) *Handlers {
+	links := artifacts.DefaultSigner()
+	authenticator := auth.NewAuthenticatorFromConfig(auth.ConfigFromEnv(), statistics.NewAPIKeyStore())
 	return &Handlers{
 		PDF:             handlers.NewPDFHandler(service),
 		Statistics:      handlers.NewStatisticsHandler(),
 		Errors:          handlers.NewErrorHandler(),
-		RequestAnalysis: handlers.NewRequestAnalysisHandler(statistics.GetPostgresDB()),
+		RequestAnalysis: handlers.NewRequestAnalysisHandler(statistics.GetPostgresDB(), links),
+		Artifacts:       handlers.NewArtifactHandler(artifacts.Default(), links),
+		Auth:            handlers.NewAuthHandler(authenticator),
+		Breakers:        handlers.NewBreakerHandler(circuitbreaker.Default()),
+		Authenticator:   authenticator,
+		RateLimiter:     ratelimit.New(ratelimit.ConfigFromEnv(), statistics.NewQuotaStore()),
+		Admission:       admission.Default(),
 	}
 }
 
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// artifactPresignTTL время жизни прямой ссылки на объект во внешнем хранилище
+const artifactPresignTTL = 5 * time.Minute
+
+// ArtifactHandler отдаёт архивные артефакты из хранилища по подписанным ссылкам
+type ArtifactHandler struct {
+	store  artifacts.ArtifactStore
+	signer *artifacts.LinkSigner
+}
+
+func NewArtifactHandler(store artifacts.ArtifactStore, signer *artifacts.LinkSigner) *ArtifactHandler {
+	return &ArtifactHandler{store: store, signer: signer}
+}
+
+// Download проверяет подпись ссылки и отдаёт артефакт. Если хранилище умеет выдавать прямые ссылки — перенаправляет на них
+func (h *ArtifactHandler) Download(c *gin.Context) {
+	start := time.Now()
+	rawKey := strings.TrimPrefix(c.Param("key"), "/")
+	fields := []zap.Field{
+		zap.String("key", rawKey),
+		zap.String("client_ip", c.ClientIP()),
+		zap.String("user_agent", c.Request.UserAgent()),
+	}
+
+	key, err := h.signer.Verify(rawKey, c.Request.URL.Query(), artifacts.ScopeArchiveRead)
+	if err != nil {
+		logger.Warn("Artifact download denied", append(fields, zap.Error(err))...)
+		switch {
+		case errors.Is(err, artifacts.ErrLinkExpired):
+			c.JSON(http.StatusGone, gin.H{"error": "download link expired"})
+		case errors.Is(err, artifacts.ErrInvalidKey):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid artifact key"})
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid download link"})
+		}
+		return
+	}
+	fields = append(fields, zap.String("scope", c.Query("scope")), zap.String("backend", h.store.Backend()))
+
+	ctx := c.Request.Context()
+	if url, err := h.store.Presign(ctx, key, artifactPresignTTL); err == nil {
+		logger.Info("Artifact download redirected", fields...)
+		c.Redirect(http.StatusFound, url)
+		return
+	} else if !errors.Is(err, artifacts.ErrPresignNotSupported) {
+		logger.Warn("Failed to presign artifact, streaming instead", append(fields, zap.Error(err))...)
+	}
+
+	rc, err := h.store.Get(ctx, key)
+	if err != nil {
+		switch {
+		case errors.Is(err, artifacts.ErrNotFound):
+			logger.Warn("Artifact download failed: not found", fields...)
+			c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+		case errors.Is(err, artifacts.ErrInvalidKey):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid artifact key"})
+		default:
+			logger.Error("Failed to read artifact", append(fields, zap.Error(err))...)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read artifact"})
+		}
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	written, err := io.Copy(c.Writer, rc)
+	fields = append(fields, zap.Int64("bytes", written), zap.Duration("duration", time.Since(start)))
+	if err != nil {
+		logger.Warn("Artifact download interrupted", append(fields, zap.Error(err))...)
+		return
+	}
+	logger.Info("Artifact downloaded", fields...)
+}
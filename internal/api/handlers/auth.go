@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pdf-service-go/internal/pkg/auth"
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/statistics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// sessionCookieTTL время жизни cookie веб-интерфейса
+const sessionCookieTTL = 12 * time.Hour
+
+// AuthHandler обслуживает управление API ключами и сессии веб-интерфейса
+type AuthHandler struct {
+	authenticator *auth.Authenticator
+}
+
+func NewAuthHandler(authenticator *auth.Authenticator) *AuthHandler {
+	return &AuthHandler{authenticator: authenticator}
+}
+
+type createAPIKeyRequest struct {
+	Name       string            `json:"name" binding:"required"`
+	Scopes     []string          `json:"scopes" binding:"required"`
+	Attributes map[string]string `json:"attributes"`
+	// ExpiresIn срок действия ключа в формате time.ParseDuration (например, "720h"); пусто — бессрочный
+	ExpiresIn string `json:"expires_in"`
+}
+
+// Whoami возвращает текущего клиента
+func (h *AuthHandler) Whoami(c *gin.Context) {
+	principal, ok := auth.PrincipalFromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"authenticated": false, "auth_enabled": h.authenticator.Enabled()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"authenticated": true, "auth_enabled": true, "principal": principal})
+}
+
+// ListKeys возвращает выданные API ключи (без секретов)
+func (h *AuthHandler) ListKeys(c *gin.Context) {
+	keys, err := h.authenticator.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		h.storeError(c, "Failed to list api keys", err)
+		return
+	}
+	if keys == nil {
+		keys = []auth.APIKey{}
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys, "total": len(keys)})
+}
+
+// CreateKey выдаёт новый API ключ. Секрет возвращается только в этом ответе
+func (h *AuthHandler) CreateKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expires_in"})
+			return
+		}
+		ttl = parsed
+	}
+
+	plaintext, key, err := h.authenticator.IssueAPIKey(c.Request.Context(), req.Name, req.Scopes, req.Attributes, ttl)
+	if err != nil {
+		if errors.Is(err, statistics.ErrDBNotReady) {
+			h.storeError(c, "Failed to create api key", err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("API key created",
+		zap.String("key_id", key.ID),
+		zap.String("name", key.Name),
+		zap.Strings("scopes", key.Scopes),
+		zap.String("created_by", principalID(c)))
+	c.JSON(http.StatusCreated, gin.H{"key": key, "api_key": plaintext})
+}
+
+// RevokeKey отзывает API ключ
+func (h *AuthHandler) RevokeKey(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.authenticator.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+			return
+		}
+		h.storeError(c, "Failed to revoke api key", err)
+		return
+	}
+	logger.Info("API key revoked", zap.String("key_id", id), zap.String("revoked_by", principalID(c)))
+	c.JSON(http.StatusOK, gin.H{"status": "revoked", "id": id})
+}
+
+// CreateSession проверяет переданные учётные данные и сохраняет их в HttpOnly cookie для дашбордов
+func (h *AuthHandler) CreateSession(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+	principal, err := h.authenticator.AuthenticateCredential(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			return
+		}
+		h.storeError(c, "Failed to create session", err)
+		return
+	}
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(auth.CookieName, req.Token, int(sessionCookieTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"principal": principal})
+}
+
+// DeleteSession удаляет cookie веб-интерфейса
+func (h *AuthHandler) DeleteSession(c *gin.Context) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(auth.CookieName, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *AuthHandler) storeError(c *gin.Context, msg string, err error) {
+	logger.Error(msg, zap.Error(err))
+	if errors.Is(err, statistics.ErrDBNotReady) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "statistics DB is not ready"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+}
+
+// principalID возвращает идентификатор текущего клиента для аудита
+func principalID(c *gin.Context) string {
+	if p, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+		return p.ID
+	}
+	return "anonymous"
+}
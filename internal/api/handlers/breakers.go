@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"pdf-service-go/internal/pkg/auth"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BreakerHandler административное API Circuit Breaker: просмотр состояния и истории, ручное управление
+type BreakerHandler struct {
+	registry *circuitbreaker.Registry
+}
+
+// NewBreakerHandler создает обработчик для реестра Circuit Breaker
+func NewBreakerHandler(registry *circuitbreaker.Registry) *BreakerHandler {
+	return &BreakerHandler{registry: registry}
+}
+
+type breakerActionRequest struct {
+	// Reason комментарий оператора, попадает в историю переходов
+	Reason string `json:"reason"`
+}
+
+// List возвращает все Circuit Breaker с состоянием и историей переходов
+func (h *BreakerHandler) List(c *gin.Context) {
+	breakers := h.registry.All()
+	statuses := make([]circuitbreaker.Status, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, cb.Status())
+	}
+	c.JSON(http.StatusOK, gin.H{"breakers": statuses, "total": len(statuses)})
+}
+
+// Get возвращает состояние и историю переходов одного Circuit Breaker
+func (h *BreakerHandler) Get(c *gin.Context) {
+	cb, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cb.Status())
+}
+
+// ForceOpen открывает Circuit Breaker до ручного закрытия или сброса
+func (h *BreakerHandler) ForceOpen(c *gin.Context) {
+	h.apply(c, "force-open", (*circuitbreaker.CircuitBreaker).ForceOpen)
+}
+
+// ForceClose закрывает Circuit Breaker и отключает его автоматическое открытие до сброса
+func (h *BreakerHandler) ForceClose(c *gin.Context) {
+	h.apply(c, "force-close", (*circuitbreaker.CircuitBreaker).ForceClose)
+}
+
+// Reset сбрасывает счётчики и возвращает Circuit Breaker в автоматический режим
+func (h *BreakerHandler) Reset(c *gin.Context) {
+	h.apply(c, "reset", (*circuitbreaker.CircuitBreaker).Reset)
+}
+
+func (h *BreakerHandler) apply(c *gin.Context, action string, fn func(*circuitbreaker.CircuitBreaker, string)) {
+	cb, ok := h.lookup(c)
+	if !ok {
+		return
+	}
+	var req breakerActionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	detail := strings.TrimSpace(req.Reason)
+	actor := "anonymous"
+	if principal, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+		actor = principal.Name
+	}
+	if detail == "" {
+		detail = "by " + actor
+	} else {
+		detail += " (by " + actor + ")"
+	}
+
+	fn(cb, detail)
+	logger.Info("Circuit breaker changed via admin API",
+		zap.String("circuit_breaker", cb.Name()),
+		zap.String("action", action),
+		zap.String("actor", actor),
+		zap.String("reason", req.Reason))
+	c.JSON(http.StatusOK, cb.Status())
+}
+
+func (h *BreakerHandler) lookup(c *gin.Context) (*circuitbreaker.CircuitBreaker, bool) {
+	name := c.Param("name")
+	cb, ok := h.registry.Get(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "circuit breaker not found", "name": name})
+		return nil, false
+	}
+	return cb, true
+}
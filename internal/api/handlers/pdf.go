@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"pdf-service-go/internal/domain/pdf"
+	"pdf-service-go/internal/pkg/admission"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/bulkhead"
 	"pdf-service-go/internal/pkg/circuitbreaker"
 	"pdf-service-go/internal/pkg/errortracker"
 	"pdf-service-go/internal/pkg/logger"
@@ -44,18 +47,20 @@ func NewPDFHandler(service pdf.Service) *PDFHandler {
 func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 	startTime := time.Now()
 	var docxErr error
-	var gotenbergErr error
 
 	defer func() {
-		// Отслеживаем только генерацию DOCX и конвертацию через Gotenberg
+		// Отслеживаем только ошибки генерации; отказы из-за открытого Circuit Breaker учитываются как инфраструктурные
 		if docxErr != nil {
 			h.TrackDocxGeneration(time.Since(startTime), true)
 		}
-		if gotenbergErr != nil {
-			h.TrackGotenbergRequest(time.Since(startTime), true)
-		}
 	}()
 
+	// Быстрый отказ до разбора запроса и запуска Python, если конвертеры недоступны
+	if err := h.service.CheckAvailability(); err != nil {
+		h.respondUnavailable(c, err)
+		return
+	}
+
 	var req pdf.DocxRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error("Failed to parse request",
@@ -81,6 +86,19 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 		return
 	}
 
+	// Табличное приложение реестра формируется до генерации: ошибка соответствия колонок не должна нагружать конвертер
+	annexFormats, bundle, err := parseAnnexQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("validation error: %v", err)})
+		return
+	}
+	annexFiles, err := pdf.BuildRegistryAnnex(pdf.DocxAnnexTemplate, &req, annexFormats)
+	if err != nil {
+		logger.Error("Failed to build registry annex", zap.Error(err))
+		c.JSON(h.determineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	// Время начала генерации DOCX
 	docxStartTime := time.Now()
 	// Восстановим контекст и обогатим его путём к сохраненному payload
@@ -98,6 +116,10 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 	pdfContent, err := h.service.GenerateDocx(ctx, &req)
 	docxDuration := time.Since(docxStartTime)
 
+	if h.respondRejected(ctx, c, err) {
+		return
+	}
+
 	if err != nil {
 		status := h.determineErrorStatus(err)
 
@@ -107,9 +129,6 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 			payloadPath = vv
 		}
 		stage := "docx"
-		if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
-			stage = "gotenberg"
-		}
 		errortracker.TrackError(ctx, err,
 			errortracker.WithComponent("pdf"),
 			errortracker.WithHTTPStatus(status),
@@ -119,11 +138,7 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 			errortracker.WithRequestDetails("request_payload_path", payloadPath),
 		)
 
-		if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
-			gotenbergErr = err
-		} else {
-			docxErr = err
-		}
+		docxErr = err
 		logger.Error("Failed to generate PDF", zap.Error(err))
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
@@ -137,7 +152,7 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 	if tp, ok := ctx.Value("timings_file_path").(string); ok && tp != "" {
 		c.Set("timings_file_path", tp)
 	}
-	resultPath, resultSize := savePDFResultToFile(c, pdfContent)
+	resultPath, resultSize := savePDFResultArtifact(c, pdfContent)
 	h.TrackPDFFile(resultSize)
 
 	totalDuration := time.Since(startTime)
@@ -149,30 +164,121 @@ func (h *PDFHandler) GenerateDocx(c *gin.Context) {
 	if resultPath != "" {
 		c.Header("X-Result-File-Path", resultPath)
 	}
+	if annexPaths := saveAnnexArtifacts(c, annexFiles); len(annexPaths) > 0 {
+		c.Header("X-Annex-File-Path", strings.Join(annexPaths, ","))
+	}
 
+	if bundle {
+		respondBundle(c, req.ID, pdfContent, annexFiles)
+		return
+	}
 	c.Data(http.StatusOK, "application/pdf", pdfContent)
 }
 
-// savePDFResultToFile сохраняет PDF на диск и, если есть request_id в контексте, обновляет запись о запросе
-func savePDFResultToFile(c *gin.Context, pdfContent []byte) (string, int64) {
-	baseDir := getArtifactsBaseDir()
-	outDir := filepath.Join(baseDir, "results")
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return "", int64(len(pdfContent))
+// respondRejected отвечает 503 с Retry-After на отказы из-за перегрузки или недоступного конвертера.
+// Такие отказы не являются ошибками генерации: статистика и трекер ошибок генерации не затрагиваются.
+func (h *PDFHandler) respondRejected(ctx context.Context, c *gin.Context, err error) bool {
+	var rejected *admission.RejectedError
+	if errors.As(err, &rejected) {
+		// Перегрузка не является ошибкой генерации: не трогаем статистику и трекер ошибок
+		retryAfter := int(math.Ceil(rejected.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		logger.Warn("Generation rejected by admission control",
+			zap.String("reason", rejected.Reason),
+			zap.String("class", admission.ClassFromContext(ctx)),
+			zap.Int("retry_after_seconds", retryAfter))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":               "service overloaded",
+			"reason":              rejected.Reason,
+			"retry_after_seconds": retryAfter,
+		})
+		return true
+	}
+
+	var saturated *bulkhead.RejectedError
+	if errors.As(err, &saturated) {
+		// Переполнен отсек одного из этапов генерации — это тоже перегрузка, а не ошибка генерации
+		retryAfter := int(math.Ceil(saturated.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		logger.Warn("Generation rejected by bulkhead",
+			zap.String("bulkhead", saturated.Bulkhead),
+			zap.String("reason", saturated.Reason),
+			zap.Int("retry_after_seconds", retryAfter))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":               "service overloaded",
+			"reason":              saturated.Reason,
+			"bulkhead":            saturated.Bulkhead,
+			"retry_after_seconds": retryAfter,
+		})
+		return true
+	}
+
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		h.respondUnavailable(c, err)
+		return true
+	}
+	return false
+}
+
+// respondUnavailable отвечает 503 с Retry-After, когда Circuit Breaker конвертера открыт.
+// Такие отказы учитываются как инфраструктурные, а не как ошибки генерации PDF.
+func (h *PDFHandler) respondUnavailable(c *gin.Context, err error) {
+	var openErr *circuitbreaker.OpenError
+	if !errors.As(err, &openErr) {
+		// Circuit Breaker открылся во время обработки — берём оставшееся время из текущего состояния
+		if !errors.As(h.service.CheckAvailability(), &openErr) {
+			openErr = &circuitbreaker.OpenError{Name: "converter", RetryAfter: time.Second}
+		}
+	}
+	retryAfter := int(math.Ceil(openErr.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
 	}
+
+	ctx := c.Request.Context()
+	if v, exists := c.Get("request_id"); exists {
+		if s, ok := v.(string); ok && s != "" {
+			ctx = context.WithValue(ctx, "request_id", s)
+		}
+	}
+	errortracker.TrackError(ctx, err,
+		errortracker.WithComponent("infrastructure"),
+		errortracker.WithErrorType("dependency_unavailable"),
+		errortracker.WithSeverity("high"),
+		errortracker.WithHTTPStatus(http.StatusServiceUnavailable),
+		errortracker.WithRequestDetails("dependency", openErr.Name),
+		errortracker.WithRequestDetails("retry_after_seconds", retryAfter),
+	)
+	logger.Warn("Converter unavailable, request rejected",
+		zap.String("dependency", openErr.Name),
+		zap.Int("retry_after_seconds", retryAfter))
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error":               "converter temporarily unavailable",
+		"dependency":          openErr.Name,
+		"retry_after_seconds": retryAfter,
+	})
+}
+
+// savePDFResultArtifact сохраняет PDF в хранилище артефактов и, если есть request_id в контексте, обновляет запись о запросе
+func savePDFResultArtifact(c *gin.Context, pdfContent []byte) (string, int64) {
+	size := int64(len(pdfContent))
 	requestIDAny, _ := c.Get("request_id")
 	requestID, _ := requestIDAny.(string)
 	if requestID == "" {
 		requestID = fmt.Sprintf("anon_%d", time.Now().UnixNano())
 	}
-	filename := filepath.Join(outDir, fmt.Sprintf("%s.pdf", requestID))
-	if err := os.WriteFile(filename, pdfContent, 0o644); err != nil {
-		return "", int64(len(pdfContent))
+	key := artifacts.ResultKey(requestID)
+	// Архивирование не должно зависеть от того, дождался ли клиент ответа
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := artifacts.Default().Put(ctx, key, bytes.NewReader(pdfContent), size, "application/pdf"); err != nil {
+		logger.Error("Failed to store PDF result", zap.String("request_id", requestID), zap.Error(err))
+		return "", size
 	}
 
-	// Попробуем обновить запись request_details путями к файлам
+	// Попробуем обновить запись request_details ключами артефактов
 	if db := statistics.GetPostgresDB(); db != nil {
-		size := int64(len(pdfContent))
 		// Попытаемся также записать timings, если Python сообщил путь
 		var timingsPath *string
 		if tpAny, ok := c.Get("timings_file_path"); ok {
@@ -180,23 +286,15 @@ func savePDFResultToFile(c *gin.Context, pdfContent []byte) (string, int64) {
 				timingsPath = &tp
 			}
 		}
-		if err := db.UpdateResultFileInfoWithTimings(requestID, filename, size, timingsPath); err != nil {
+		if err := db.UpdateResultFileInfoWithTimings(requestID, key, size, timingsPath); err != nil {
 			logger.Error("Failed to update result file info", zap.String("request_id", requestID), zap.Error(err))
 		}
 	}
 
-	// Сохраним путь в контекст на будущее
-	c.Set("result_file_path", filename)
+	// Сохраним ключ в контекст на будущее
+	c.Set("result_file_path", key)
 
-	return filename, int64(len(pdfContent))
-}
-
-// getArtifactsBaseDir реиспользуем логику из middleware
-func getArtifactsBaseDir() string {
-	if v := os.Getenv("ARTIFACTS_DIR"); v != "" {
-		return v
-	}
-	return "/app/data/artifacts"
+	return key, size
 }
 
 func (h *PDFHandler) validateRequest(req *pdf.DocxRequest) error {
@@ -235,6 +333,9 @@ func (h *PDFHandler) determineErrorStatus(err error) int {
 	if errors.Is(err, pdf.ErrTemplateNotFound) {
 		return http.StatusNotFound
 	}
+	if errors.Is(err, pdf.ErrInvalidTemplate) {
+		return http.StatusBadRequest
+	}
 	return http.StatusInternalServerError
 }
 
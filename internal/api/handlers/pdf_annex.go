@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"pdf-service-go/internal/pkg/annex"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// bundleZip значение ?bundle= для ответа ZIP архивом с PDF и приложениями
+const bundleZip = "zip"
+
+// parseAnnexQuery разбирает параметры приложения: ?annex=xlsx,csv и ?bundle=zip
+func parseAnnexQuery(c *gin.Context) ([]string, bool, error) {
+	formats, err := annex.ParseFormats(c.Query("annex"))
+	if err != nil {
+		return nil, false, err
+	}
+	bundle := c.Query("bundle")
+	switch {
+	case bundle == "":
+		return formats, false, nil
+	case !strings.EqualFold(bundle, bundleZip):
+		return nil, false, fmt.Errorf("unknown bundle %q", bundle)
+	case len(formats) == 0:
+		return nil, false, fmt.Errorf("bundle requires annex formats")
+	}
+	return formats, true, nil
+}
+
+// saveAnnexArtifacts сохраняет приложения рядом с PDF результатом запроса и возвращает их ключи.
+// Без request_id приложения не архивируются: их не с чем связать.
+func saveAnnexArtifacts(c *gin.Context, files []annex.File) []string {
+	requestIDAny, _ := c.Get("request_id")
+	requestID, _ := requestIDAny.(string)
+	if requestID == "" || len(files) == 0 {
+		return nil
+	}
+	// Архивирование не должно зависеть от того, дождался ли клиент ответа
+	ctx := context.WithoutCancel(c.Request.Context())
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		format := strings.TrimPrefix(filepath.Ext(f.Name), ".")
+		key := artifacts.AnnexKey(requestID, format)
+		if err := artifacts.Default().Put(ctx, key, bytes.NewReader(f.Content), int64(len(f.Content)), f.ContentType); err != nil {
+			logger.Error("Failed to store annex", zap.String("request_id", requestID), zap.String("key", key), zap.Error(err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// respondBundle отвечает ZIP архивом с PDF и приложениями
+func respondBundle(c *gin.Context, id string, pdfContent []byte, files []annex.File) {
+	name := annex.SafeName(id)
+	entries := append([]annex.File{{Name: name + ".pdf", Content: pdfContent}}, files...)
+	data, err := annex.Bundle(entries)
+	if err != nil {
+		logger.Error("Failed to bundle annex", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+	c.Data(http.StatusOK, "application/zip", data)
+}
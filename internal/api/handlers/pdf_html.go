@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pdf-service-go/internal/domain/pdf"
+	"pdf-service-go/internal/pkg/errortracker"
+	"pdf-service-go/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GenerateHTML генерирует PDF из HTML шаблона через Chromium Gotenberg.
+// Результат архивируется и учитывается в статистике так же, как PDF из DOCX.
+func (h *PDFHandler) GenerateHTML(c *gin.Context) {
+	startTime := time.Now()
+
+	// Быстрый отказ до разбора запроса, если Gotenberg недоступен
+	if err := h.service.CheckHTMLAvailability(); err != nil {
+		h.respondUnavailable(c, err)
+		return
+	}
+
+	var req pdf.HTMLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Failed to parse HTML request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request format: %v", err)})
+		return
+	}
+	if err := pdf.ValidateHTMLRequest(&req); err != nil {
+		logger.Error("HTML request validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("validation error: %v", err)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if v, exists := c.Get("request_id"); exists {
+		if s, ok := v.(string); ok && s != "" {
+			ctx = context.WithValue(ctx, "request_id", s)
+		}
+	}
+	pdfContent, err := h.service.GenerateHTML(ctx, &req)
+	if h.respondRejected(ctx, c, err) {
+		return
+	}
+	if err != nil {
+		status := h.determineErrorStatus(err)
+		payloadPath := ""
+		if v, exists := c.Get("request_body_file_path"); exists {
+			payloadPath, _ = v.(string)
+		}
+		errortracker.TrackError(ctx, err,
+			errortracker.WithComponent("pdf"),
+			errortracker.WithHTTPStatus(status),
+			errortracker.WithDuration(time.Since(startTime)),
+			errortracker.WithRequestDetails("stage", "html"),
+			errortracker.WithRequestDetails("template", req.Template),
+			errortracker.WithRequestDetails("request_payload_path", payloadPath),
+		)
+		logger.Error("Failed to generate PDF from HTML", zap.Error(err))
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	resultPath, resultSize := savePDFResultArtifact(c, pdfContent)
+	h.TrackPDFFile(resultSize)
+
+	c.Header("X-Total-Processing-Time", strconv.FormatFloat(time.Since(startTime).Seconds(), 'f', 3, 64))
+	if resultPath != "" {
+		c.Header("X-Result-File-Path", resultPath)
+	}
+	c.Data(http.StatusOK, "application/pdf", pdfContent)
+}
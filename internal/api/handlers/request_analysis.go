@@ -3,10 +3,10 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"pdf-service-go/internal/pkg/artifacts"
 	"pdf-service-go/internal/pkg/logger"
 	"pdf-service-go/internal/pkg/statistics"
 	"strconv"
-	"strings"
 
 	"time"
 
@@ -15,11 +15,36 @@ import (
 )
 
 type RequestAnalysisHandler struct {
-	db *statistics.PostgresDB
+	db    *statistics.PostgresDB
+	links *artifacts.LinkSigner
 }
 
-func NewRequestAnalysisHandler(db *statistics.PostgresDB) *RequestAnalysisHandler {
-	return &RequestAnalysisHandler{db: db}
+func NewRequestAnalysisHandler(db *statistics.PostgresDB, links *artifacts.LinkSigner) *RequestAnalysisHandler {
+	return &RequestAnalysisHandler{db: db, links: links}
+}
+
+// signArtifactLink преобразует ключ артефакта (или старый абсолютный путь) в подписанную ссылку на скачивание
+func (h *RequestAnalysisHandler) signArtifactLink(p *string) *string {
+	if p == nil || *p == "" {
+		return p
+	}
+	key, ok := artifacts.KeyFromPath(*p)
+	if !ok {
+		return p
+	}
+	link, err := h.links.SignKey(key)
+	if err != nil {
+		logger.Warn("Failed to sign artifact link", zap.String("key", key), zap.Error(err))
+		return nil
+	}
+	return &link
+}
+
+// signArtifactLinks заменяет пути артефактов в записи на подписанные ссылки
+func (h *RequestAnalysisHandler) signArtifactLinks(detail *statistics.RequestDetail) {
+	detail.RequestFilePath = h.signArtifactLink(detail.RequestFilePath)
+	detail.ResultFilePath = h.signArtifactLink(detail.ResultFilePath)
+	detail.TimingsFilePath = h.signArtifactLink(detail.TimingsFilePath)
 }
 
 // getDB возвращает актуальный экземпляр PostgresDB из синглтона, если поле ещё не установлено
@@ -58,6 +83,7 @@ func (h *RequestAnalysisHandler) GetRequestDetail(c *gin.Context) {
 		})
 		return
 	}
+	h.signArtifactLinks(detail)
 
 	c.JSON(http.StatusOK, gin.H{
 		"request_detail": detail,
@@ -107,6 +133,10 @@ func (h *RequestAnalysisHandler) GetErrorRequests(c *gin.Context) {
 		return
 	}
 
+	for i := range details {
+		h.signArtifactLinks(&details[i])
+	}
+
 	// Подготавливаем ответ с дополнительной аналитикой
 	response := gin.H{
 		"error_requests": details,
@@ -186,7 +216,7 @@ func (h *RequestAnalysisHandler) GetRecentRequests(c *gin.Context) {
 	}
 
 	// Принудительная фильтрация по допустимым путям (дополнительная защита)
-	allowed := map[string]bool{"/api/v1/docx": true, "/generate-pdf": true}
+	allowed := map[string]bool{"/api/v1/docx": true, "/api/v1/html": true, "/generate-pdf": true}
 	filtered := make([]statistics.RequestDetail, 0, len(details))
 	for _, d := range details {
 		if allowed[d.Path] {
@@ -195,31 +225,9 @@ func (h *RequestAnalysisHandler) GetRecentRequests(c *gin.Context) {
 	}
 	details = filtered
 
-	// Преобразуем абсолютные пути к публичным URL через /files
-	baseDir := getArtifactsBaseDir()
-	makePublic := func(p *string) *string {
-		if p == nil || *p == "" {
-			return p
-		}
-		path := *p
-		// На Windows могут быть обратные слеши — нормализуем
-		path = strings.ReplaceAll(path, "\\", "/")
-		b := strings.ReplaceAll(baseDir, "\\", "/")
-		if strings.HasPrefix(path, b) {
-			rel := strings.TrimPrefix(path, b)
-			if !strings.HasPrefix(rel, "/") {
-				rel = "/" + rel
-			}
-			url := "/files" + rel
-			return &url
-		}
-		return p
-	}
-
+	// Преобразуем ключи артефактов (и старые абсолютные пути) в подписанные ссылки с ограниченным сроком действия
 	for i := range details {
-		details[i].RequestFilePath = makePublic(details[i].RequestFilePath)
-		details[i].ResultFilePath = makePublic(details[i].ResultFilePath)
-		details[i].TimingsFilePath = makePublic(details[i].TimingsFilePath)
+		h.signArtifactLinks(&details[i])
 	}
 
 	// Тайминги в заголовках для диагностики
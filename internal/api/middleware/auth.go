@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"pdf-service-go/internal/pkg/auth"
+	"pdf-service-go/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// principalContextKey ключ клиента в gin.Context
+const principalContextKey = "auth_principal"
+
+// AuthMiddleware проверяет учётные данные и требует хотя бы одну из указанных областей доступа.
+// Если аутентификация выключена (AUTH_ENABLED=false), запросы пропускаются без проверки.
+func AuthMiddleware(authenticator *auth.Authenticator, scopes ...string) gin.HandlerFunc {
+	return authMiddleware(authenticator, func(c *gin.Context) {
+		c.Header("WWW-Authenticate", `Bearer realm="pdf-service"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+	}, scopes)
+}
+
+// AuthPageMiddleware защищает HTML страницы: неаутентифицированный пользователь перенаправляется на /login
+func AuthPageMiddleware(authenticator *auth.Authenticator, scopes ...string) gin.HandlerFunc {
+	return authMiddleware(authenticator, func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/login?next="+url.QueryEscape(c.Request.URL.RequestURI()))
+		c.Abort()
+	}, scopes)
+}
+
+func authMiddleware(authenticator *auth.Authenticator, unauthenticated gin.HandlerFunc, scopes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticator.Enabled() {
+			c.Next()
+			return
+		}
+
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			var err error
+			principal, err = authenticator.Authenticate(c.Request.Context(), c.Request)
+			if err != nil {
+				if !errors.Is(err, auth.ErrNoCredentials) && !errors.Is(err, auth.ErrInvalidCredentials) {
+					logger.Error("Authentication backend failed", zap.Error(err))
+					c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication is temporarily unavailable"})
+					return
+				}
+				logger.Warn("Authentication failed",
+					zap.String("path", c.Request.URL.Path),
+					zap.String("client_ip", c.ClientIP()),
+					zap.Error(err))
+				unauthenticated(c)
+				return
+			}
+			c.Set(principalContextKey, principal)
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+		}
+
+		if len(scopes) > 0 && !hasAnyScope(principal, scopes) {
+			logger.Warn("Access denied: missing scope",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("principal", principal.ID),
+				zap.Strings("required_scopes", scopes))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":           "insufficient scope",
+				"required_scopes": scopes,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal возвращает аутентифицированного клиента запроса
+func GetPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	if v, ok := c.Get(principalContextKey); ok {
+		if p, ok := v.(*auth.Principal); ok && p != nil {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func hasAnyScope(p *auth.Principal, scopes []string) bool {
+	for _, s := range scopes {
+		if p.HasScope(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"pdf-service-go/internal/pkg/admission"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PriorityMiddleware определяет класс приоритета генерации и сохраняет его в контексте запроса.
+// Клиент выбирает класс заголовком X-Priority, но не выше класса из атрибута priority своего API ключа/JWT.
+func PriorityMiddleware(controller *admission.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !controller.Enabled() {
+			c.Next()
+			return
+		}
+
+		allowed := ""
+		if p, ok := GetPrincipal(c); ok {
+			allowed = p.Attributes[admission.AttrPriority]
+		}
+		class := controller.ResolveClass(c.GetHeader(admission.HeaderPriority), allowed)
+		c.Request = c.Request.WithContext(admission.WithClass(c.Request.Context(), class))
+		c.Set("priority_class", class)
+		c.Header("X-Priority-Class", class)
+		c.Next()
+	}
+}
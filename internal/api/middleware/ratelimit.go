@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/ratelimit"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimitMiddleware применяет token bucket и квоты документов к клиенту запроса.
+// Клиент определяется по аутентифицированному API ключу/JWT, иначе по IP адресу.
+// Квота резервируется при приёме запроса и возвращается, если ответ не 2xx или обработчик упал с паникой:
+// отказы валидации, перегрузки и ошибки Gotenberg не расходуют документы клиента.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Enabled() {
+			c.Next()
+			return
+		}
+
+		client := rateLimitClient(c)
+		d := limiter.Allow(c.Request.Context(), client)
+		setRateLimitHeaders(c, d)
+
+		if !d.Allowed {
+			retryAfter := int(math.Ceil(d.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			logger.Warn("Request throttled",
+				zap.String("client", client.ID),
+				zap.String("reason", d.Reason),
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("retry_after_seconds", retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":               "too many requests",
+				"reason":              d.Reason,
+				"retry_after_seconds": retryAfter,
+			})
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed || c.Writer.Status() < http.StatusOK || c.Writer.Status() >= http.StatusMultipleChoices {
+				limiter.Refund(context.WithoutCancel(c.Request.Context()), client, d)
+			}
+		}()
+		c.Next()
+		completed = true
+	}
+}
+
+// rateLimitClient возвращает ключ учёта лимитов для запроса
+func rateLimitClient(c *gin.Context) ratelimit.Client {
+	if p, ok := GetPrincipal(c); ok && p.ID != "" {
+		return ratelimit.Client{ID: p.Method + ":" + p.ID, Type: p.Method, Attributes: p.Attributes}
+	}
+	return ratelimit.Client{ID: "ip:" + c.ClientIP(), Type: "ip"}
+}
+
+// setRateLimitHeaders выставляет заголовки с оставшимися лимитами и квотами
+func setRateLimitHeaders(c *gin.Context, d ratelimit.Decision) {
+	if d.Limits.RPS > 0 {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(maxInt(d.Limits.Burst, 1)))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(d.RateRemaining))
+	}
+	if d.Limits.Daily > 0 && !d.DailyReset.IsZero() {
+		c.Header("X-Quota-Daily-Limit", strconv.FormatInt(d.Limits.Daily, 10))
+		c.Header("X-Quota-Daily-Remaining", strconv.FormatInt(remaining(d.Limits.Daily, d.DailyUsed), 10))
+		c.Header("X-Quota-Daily-Reset", d.DailyReset.Format(time.RFC3339))
+	}
+	if d.Limits.Monthly > 0 && !d.MonthlyReset.IsZero() && (d.MonthlyUsed > 0 || d.Reason == ratelimit.ReasonQuotaMonthly) {
+		c.Header("X-Quota-Monthly-Limit", strconv.FormatInt(d.Limits.Monthly, 10))
+		c.Header("X-Quota-Monthly-Remaining", strconv.FormatInt(remaining(d.Limits.Monthly, d.MonthlyUsed), 10))
+		c.Header("X-Quota-Monthly-Reset", d.MonthlyReset.Format(time.RFC3339))
+	}
+}
+
+func remaining(limit, used int64) int64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
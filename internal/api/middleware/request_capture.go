@@ -8,8 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"pdf-service-go/internal/pkg/artifacts"
 	"pdf-service-go/internal/pkg/logger"
 	"pdf-service-go/internal/pkg/statistics"
 	"regexp"
@@ -80,13 +79,13 @@ func RequestCaptureMiddleware(db *statistics.PostgresDB, config statistics.Reque
 			c.Request = c.Request.WithContext(ctx)
 		}
 
-		// Сохраним тело запроса в файл сразу (до выполнения обработки), чтобы не зависеть от контекста позднее
+		// Сохраним тело запроса в хранилище артефактов сразу (до выполнения обработки), чтобы не зависеть от контекста позднее
 		var requestBodyFilePath string
 		if len(capture.Body) > 0 {
-			if path, err := saveRequestBodyToFile(capture.RequestID, capture.Body); err == nil {
-				requestBodyFilePath = path
+			if key, err := saveRequestBodyArtifact(c.Request.Context(), capture.RequestID, capture.Body); err == nil {
+				requestBodyFilePath = key
 			} else {
-				logger.Warn("Failed to persist request body to file", zap.Error(err))
+				logger.Warn("Failed to persist request body to artifact store", zap.Error(err))
 			}
 		}
 
@@ -127,7 +126,7 @@ func RequestCaptureMiddleware(db *statistics.PostgresDB, config statistics.Reque
 // shouldCaptureRequest проверяет, нужно ли захватывать данный запрос
 func shouldCaptureRequest(path string, excludePaths []string) bool {
 	// Исключаем системные пути
-	systemPaths := []string{"/health", "/metrics", "/favicon.ico"}
+	systemPaths := []string{"/health", "/ready", "/metrics", "/favicon.ico"}
 	for _, systemPath := range systemPaths {
 		if path == systemPath {
 			return false
@@ -146,7 +145,7 @@ func shouldCaptureRequest(path string, excludePaths []string) bool {
 
 // isConversionRequestPath возвращает true, если путь относится к конвертации JSON→PDF
 func isConversionRequestPath(path string) bool {
-	if path == "/api/v1/docx" || path == "/generate-pdf" {
+	if path == "/api/v1/docx" || path == "/api/v1/html" || path == "/generate-pdf" {
 		return true
 	}
 	return false
@@ -354,26 +353,13 @@ func saveRequestDetailSnapshot(db *statistics.PostgresDB, capture *statistics.Re
 	return db.SaveRequestDetail(detail)
 }
 
-// saveRequestBodyToFile сохраняет тело запроса на диск и возвращает путь к файлу
-func saveRequestBodyToFile(requestID string, body []byte) (string, error) {
-	baseDir := getArtifactsBaseDir()
-	reqDir := filepath.Join(baseDir, "requests")
-	if err := os.MkdirAll(reqDir, 0o755); err != nil {
+// saveRequestBodyArtifact сохраняет тело запроса в хранилище артефактов и возвращает ключ
+func saveRequestBodyArtifact(ctx context.Context, requestID string, body []byte) (string, error) {
+	key := artifacts.RequestKey(requestID)
+	if err := artifacts.Default().Put(ctx, key, bytes.NewReader(body), int64(len(body)), "application/json"); err != nil {
 		return "", err
 	}
-	filename := filepath.Join(reqDir, fmt.Sprintf("%s.json", requestID))
-	if err := os.WriteFile(filename, body, 0o644); err != nil {
-		return "", err
-	}
-	return filename, nil
-}
-
-// getArtifactsBaseDir возвращает базовую директорию для артефактов
-func getArtifactsBaseDir() string {
-	if v := os.Getenv("ARTIFACTS_DIR"); v != "" {
-		return v
-	}
-	return "/app/data/artifacts"
+	return key, nil
 }
 
 // categorizeError определяет категорию ошибки
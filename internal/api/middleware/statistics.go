@@ -16,7 +16,7 @@ func StatisticsMiddleware() gin.HandlerFunc {
 		method := c.Request.Method
 
 		// Отслеживаем только запросы на генерацию файлов
-		if (path == "/api/v1/docx" || path == "/generate-pdf") && method == "POST" {
+		if (path == "/api/v1/docx" || path == "/api/v1/html" || path == "/generate-pdf") && method == "POST" {
 			start := time.Now()
 			c.Next()
 			duration := time.Since(start)
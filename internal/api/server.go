@@ -13,6 +13,7 @@ import (
 
 	"pdf-service-go/internal/api/middleware"
 	"pdf-service-go/internal/domain/pdf"
+	"pdf-service-go/internal/pkg/auth"
 	"pdf-service-go/internal/pkg/errortracker"
 	"pdf-service-go/internal/pkg/logger"
 	"pdf-service-go/internal/pkg/statistics"
@@ -31,6 +32,27 @@ type Server struct {
 	service  pdf.Service
 }
 
+// configureTrustedProxies задаёт доверенные прокси из списка CIDR/IP через запятую.
+// Gin по умолчанию доверяет всем адресам, и X-Forwarded-For можно подделать, поэтому без списка
+// и при ошибке разбора прокси не доверяется никому
+func configureTrustedProxies(router *gin.Engine, proxies string) {
+	var cidrs []string
+	for _, p := range strings.Split(proxies, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cidrs = append(cidrs, p)
+		}
+	}
+	if len(cidrs) > 0 {
+		err := router.SetTrustedProxies(cidrs)
+		if err == nil {
+			logger.Info("Trusted proxies configured", zap.Strings("proxies", cidrs))
+			return
+		}
+		logger.Error("Invalid TRUSTED_PROXIES, trusting no proxies", zap.String("value", proxies), zap.Error(err))
+	}
+	_ = router.SetTrustedProxies(nil)
+}
+
 func NewServer(handlers *Handlers, service pdf.Service) *Server {
 	// Отключаем стандартный логгер gin
 	gin.SetMode(gin.ReleaseMode)
@@ -39,6 +61,10 @@ func NewServer(handlers *Handlers, service pdf.Service) *Server {
 	// Создаем новый роутер без стандартного логгера
 	router := gin.New()
 
+	// Доверенные прокси (ingress): без них ClientIP не отличает клиентов за балансировщиком,
+	// и лимиты по IP делят одно ведро на всех анонимных клиентов
+	configureTrustedProxies(router, os.Getenv("TRUSTED_PROXIES"))
+
 	// Включаем gzip-сжатие ответов
 	router.Use(gzip.Gzip(gzip.DefaultCompression))
 
@@ -69,7 +95,7 @@ func NewServer(handlers *Handlers, service pdf.Service) *Server {
 		EnableCapture:     true,
 		CaptureOnlyErrors: false,       // ВРЕМЕННО: захватываем ВСЕ запросы для диагностики
 		MaxBodySize:       1024 * 1024, // 1MB максимум
-		ExcludePaths:      []string{"/health", "/metrics", "/favicon.ico"},
+		ExcludePaths:      []string{"/health", "/ready", "/metrics", "/favicon.ico"},
 		ExcludeHeaders:    []string{"authorization", "cookie", "x-api-key"},
 		RetentionDays:     7,
 		MaskSensitiveData: true,
@@ -83,8 +109,8 @@ func NewServer(handlers *Handlers, service pdf.Service) *Server {
 
 	// Добавляем middleware для логирования с использованием zap
 	router.Use(func(c *gin.Context) {
-		// Пропускаем логирование для health check
-		if c.Request.URL.Path != "/health" {
+		// Пропускаем логирование для health и readiness check
+		if c.Request.URL.Path != "/health" && c.Request.URL.Path != "/ready" {
 			start := time.Now()
 			path := c.Request.URL.Path
 			query := c.Request.URL.RawQuery
@@ -153,24 +179,50 @@ func NewServer(handlers *Handlers, service pdf.Service) *Server {
 }
 
 func (s *Server) SetupRoutes() {
+	authn := s.Handlers.Authenticator
+	if authn.Enabled() {
+		logger.Info("API authentication enabled", zap.Bool("jwt", authn.JWTEnabled()))
+	} else {
+		logger.Warn("API authentication is disabled (AUTH_ENABLED=false); all endpoints are public")
+	}
+	requireScope := func(scopes ...string) gin.HandlerFunc {
+		return middleware.AuthMiddleware(authn, scopes...)
+	}
+	requirePageScope := func(scopes ...string) gin.HandlerFunc {
+		return middleware.AuthPageMiddleware(authn, scopes...)
+	}
+	// Лимиты и квоты применяются к генерации документов после аутентификации, чтобы учитывать клиента по ключу
+	rateLimit := middleware.RateLimitMiddleware(s.Handlers.RateLimiter)
+	// Класс приоритета генерации (interactive/bulk) для очереди допуска
+	priority := middleware.PriorityMiddleware(s.Handlers.Admission)
+
 	// Health check для k8s
 	s.Router.GET("/health", s.handleHealth())
+	s.Router.GET("/ready", s.handleReady())
 
 	// Метрики Prometheus
 	s.Router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Статистика API
-	s.Router.GET("/api/v1/statistics", s.Handlers.Statistics.GetStatistics)
+	s.Router.GET("/api/v1/statistics", requireScope(auth.ScopeArchiveRead, auth.ScopeErrorsRead), s.Handlers.Statistics.GetStatistics)
 
 	// API для детальной информации об ошибках
-	s.Router.GET("/api/v1/errors", s.Handlers.Errors.GetErrors)
-	s.Router.GET("/api/v1/errors/stats", s.Handlers.Errors.GetErrorStats)
-	s.Router.GET("/api/v1/errors/:id", s.Handlers.Errors.GetErrorDetails)
+	errorsAPI := s.Router.Group("/api/v1/errors", requireScope(auth.ScopeErrorsRead))
+	{
+		errorsAPI.GET("", s.Handlers.Errors.GetErrors)
+		errorsAPI.GET("/stats", s.Handlers.Errors.GetErrorStats)
+		errorsAPI.GET("/:id", s.Handlers.Errors.GetErrorDetails)
+	}
 
 	// API для анализа детальных запросов (регистрируется ниже внутри группы v1)
 
+	// Страница входа для веб-интерфейса
+	s.Router.GET("/login", func(c *gin.Context) {
+		c.File("internal/static/login.html")
+	})
+
 	// Единый дашборд
-	s.Router.GET("/dashboard", func(c *gin.Context) {
+	s.Router.GET("/dashboard", requirePageScope(auth.ScopeArchiveRead, auth.ScopeErrorsRead), func(c *gin.Context) {
 		c.File("internal/static/dashboard.html")
 	})
 
@@ -180,22 +232,23 @@ func (s *Server) SetupRoutes() {
 	})
 
 	// Веб-интерфейс для статистики (сохраняем для обратной совместимости)
-	s.Router.GET("/stats", func(c *gin.Context) {
+	s.Router.GET("/stats", requirePageScope(auth.ScopeArchiveRead, auth.ScopeErrorsRead), func(c *gin.Context) {
 		c.File("internal/static/index.html")
 	})
 
 	// Веб-интерфейс для ошибок (сохраняем для обратной совместимости)
-	s.Router.GET("/errors", func(c *gin.Context) {
+	s.Router.GET("/errors", requirePageScope(auth.ScopeErrorsRead), func(c *gin.Context) {
 		c.File("internal/static/errors.html")
 	})
 
 	// Статические файлы
 	s.Router.Static("/static", "internal/static")
-	// Раздача артефактов (запросов и результатов)
-	s.Router.Static("/files", "/app/data/artifacts")
+	// Раздача артефактов (запросов и результатов) по подписанным ссылкам с ограниченным сроком действия.
+	// Подпись сама является разрешением, поэтому ссылки работают в браузере без заголовков авторизации
+	s.Router.GET("/files/*key", s.Handlers.Artifacts.Download)
 
 	// Тестовые эндпоинты для проверки логирования ошибок
-	s.Router.GET("/test-error", func(c *gin.Context) {
+	s.Router.GET("/test-error", requireScope(auth.ScopeArchiveAdmin), func(c *gin.Context) {
 		err := fmt.Errorf("test error for debugging")
 
 		// Тестируем новую систему отслеживания ошибок
@@ -218,7 +271,7 @@ func (s *Server) SetupRoutes() {
 		})
 	})
 
-	s.Router.GET("/test-timeout", func(c *gin.Context) {
+	s.Router.GET("/test-timeout", requireScope(auth.ScopeArchiveAdmin), func(c *gin.Context) {
 		err := fmt.Errorf("context deadline exceeded (Client.Timeout exceeded while awaiting headers)")
 
 		errortracker.TrackError(c.Request.Context(), err,
@@ -238,20 +291,51 @@ func (s *Server) SetupRoutes() {
 	// API endpoints
 	v1 := s.Router.Group("/api/v1")
 	{
-		v1.POST("/docx", func(c *gin.Context) {
+		v1.POST("/docx", requireScope(auth.ScopeGenerate), rateLimit, priority, func(c *gin.Context) {
 			s.Handlers.PDF.GenerateDocx(c)
 		})
-		// Дублируем endpoints архива в группе v1 (для корректного матчинга роутов)
-		v1.GET("/requests/recent", s.Handlers.RequestAnalysis.GetRecentRequests)
-		v1.POST("/requests/cleanup", s.Handlers.RequestAnalysis.CleanupRequests)
-		v1.GET("/requests/error", s.Handlers.RequestAnalysis.GetErrorRequests)
-		v1.GET("/requests/analytics", s.Handlers.RequestAnalysis.GetErrorAnalytics)
-		v1.GET("/requests/:request_id", s.Handlers.RequestAnalysis.GetRequestDetail)
-		v1.GET("/requests/:request_id/body", s.Handlers.RequestAnalysis.GetRequestBody)
+		// PDF из HTML шаблона через Chromium Gotenberg
+		v1.POST("/html", requireScope(auth.ScopeGenerate), rateLimit, priority, s.Handlers.PDF.GenerateHTML)
+
+		// Архив запросов: чтение и администрирование
+		archive := v1.Group("/requests")
+		archive.POST("/cleanup", requireScope(auth.ScopeArchiveAdmin), s.Handlers.RequestAnalysis.CleanupRequests)
+		archiveRead := archive.Group("", requireScope(auth.ScopeArchiveRead))
+		{
+			// Дублируем endpoints архива в группе v1 (для корректного матчинга роутов)
+			archiveRead.GET("/recent", s.Handlers.RequestAnalysis.GetRecentRequests)
+			archiveRead.GET("/error", s.Handlers.RequestAnalysis.GetErrorRequests)
+			archiveRead.GET("/analytics", s.Handlers.RequestAnalysis.GetErrorAnalytics)
+			archiveRead.GET("/:request_id", s.Handlers.RequestAnalysis.GetRequestDetail)
+			archiveRead.GET("/:request_id/body", s.Handlers.RequestAnalysis.GetRequestBody)
+		}
+
+		// Аутентификация: сессия веб-интерфейса, текущий клиент и управление API ключами
+		authAPI := v1.Group("/auth")
+		{
+			authAPI.POST("/session", s.Handlers.Auth.CreateSession)
+			authAPI.DELETE("/session", s.Handlers.Auth.DeleteSession)
+			authAPI.GET("/whoami", requireScope(), s.Handlers.Auth.Whoami)
+
+			keys := authAPI.Group("/keys", requireScope(auth.ScopeKeysAdmin))
+			keys.GET("", s.Handlers.Auth.ListKeys)
+			keys.POST("", s.Handlers.Auth.CreateKey)
+			keys.DELETE("/:id", s.Handlers.Auth.RevokeKey)
+		}
+
+		// Административное API Circuit Breaker
+		breakers := v1.Group("/breakers", requireScope(auth.ScopeBreakersAdmin))
+		{
+			breakers.GET("", s.Handlers.Breakers.List)
+			breakers.GET("/:name", s.Handlers.Breakers.Get)
+			breakers.POST("/:name/force-open", s.Handlers.Breakers.ForceOpen)
+			breakers.POST("/:name/force-close", s.Handlers.Breakers.ForceClose)
+			breakers.POST("/:name/reset", s.Handlers.Breakers.Reset)
+		}
 	}
 
 	// Поддержка старого endpoint'а для обратной совместимости
-	s.Router.POST("/generate-pdf", func(c *gin.Context) {
+	s.Router.POST("/generate-pdf", requireScope(auth.ScopeGenerate), rateLimit, priority, func(c *gin.Context) {
 		s.Handlers.PDF.GenerateDocx(c)
 	})
 
@@ -265,7 +349,8 @@ func (s *Server) SetupRoutes() {
 		logger.Field("errors_api", "/api/v1/errors"),
 		logger.Field("errors_ui", "/errors"),
 		logger.Field("test_endpoints", []string{"/test-error", "/test-timeout"}),
-		logger.Field("api_endpoints", []string{"/api/v1/docx", "/generate-pdf"}),
+		logger.Field("api_endpoints", []string{"/api/v1/docx", "/api/v1/html", "/generate-pdf"}),
+		logger.Field("auth_endpoints", []string{"/login", "/api/v1/auth/session", "/api/v1/auth/whoami", "/api/v1/auth/keys"}),
 	)
 }
 
@@ -356,6 +441,9 @@ func (s *Server) handleHealth() gin.HandlerFunc {
 						"state":  docxState.String(),
 					},
 				},
+				"gotenberg_probe": gin.H{
+					"status": s.service.IsGotenbergReady(),
+				},
 			},
 		}
 
@@ -369,6 +457,32 @@ func (s *Server) handleHealth() gin.HandlerFunc {
 	}
 }
 
+// handleReady отвечает для readiness: под готов, если фоновая проверка Gotenberg успешна и Circuit Breaker закрыты.
+// В отличие от /health, не используется для liveness, чтобы недоступность Gotenberg не перезапускала под.
+func (s *Server) handleReady() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		gotenbergReady := s.service.IsGotenbergReady()
+		isReady := gotenbergReady && s.service.IsCircuitBreakerHealthy() && s.service.IsDocxGeneratorHealthy()
+
+		status := "ready"
+		code := http.StatusOK
+		if !isReady {
+			status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+
+		c.JSON(code, gin.H{
+			"status":    status,
+			"timestamp": time.Now().Format(time.RFC3339),
+			"details": gin.H{
+				"gotenberg_probe":   gotenbergReady,
+				"gotenberg_breaker": s.service.IsCircuitBreakerHealthy(),
+				"docx_generator":    s.service.IsDocxGeneratorHealthy(),
+			},
+		})
+	}
+}
+
 // getRequestTimeout читает REQUEST_TIMEOUT из переменных окружения.
 // Формат значения: duration (например, "180s", "2m"). По умолчанию 180s.
 func getRequestTimeout() time.Duration {
@@ -0,0 +1,47 @@
+package pdf
+
+import (
+	"context"
+
+	"pdf-service-go/internal/pkg/admission"
+)
+
+// admissionService пропускает генерацию через контроллер допуска
+type admissionService struct {
+	Service
+	controller *admission.Controller
+}
+
+// NewAdmissionService оборачивает сервис ограничением одновременных генераций и очередью ожидания
+func NewAdmissionService(service Service, controller *admission.Controller) Service {
+	if !controller.Enabled() {
+		return service
+	}
+	return &admissionService{Service: service, controller: controller}
+}
+
+func (s *admissionService) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byte, error) {
+	// Не занимаем очередь, если конвертеры недоступны
+	if err := s.Service.CheckAvailability(); err != nil {
+		return nil, err
+	}
+	release, err := s.controller.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.GenerateDocx(ctx, req)
+}
+
+func (s *admissionService) GenerateHTML(ctx context.Context, req *HTMLRequest) ([]byte, error) {
+	// Не занимаем очередь, если Gotenberg недоступен
+	if err := s.Service.CheckHTMLAvailability(); err != nil {
+		return nil, err
+	}
+	release, err := s.controller.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.GenerateHTML(ctx, req)
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pdf-service-go/internal/pkg/annex"
+)
+
+// annexMappingsDir директория соответствий колонок приложения: <шаблон>.json
+const annexMappingsDir = "internal/domain/pdf/templates/annex"
+
+// DocxAnnexTemplate имя шаблона DOCX для выбора соответствия колонок приложения
+const DocxAnnexTemplate = "template"
+
+// defaultAnnexMapping соответствие колонок, если для шаблона нет файла
+var defaultAnnexMapping = annex.Mapping{
+	Sheet: "Реестр",
+	Columns: []annex.Column{
+		{Header: "№", Field: annex.FieldRowNumber},
+		{Header: "Наименование", Field: "name"},
+		{Header: "Описание", Field: "description"},
+		{Header: "Инвентарный номер", Field: "invNumber"},
+		{Header: "Тип носителя", Field: "geoInfoCarrierTypes"},
+		{Header: "Дата информации", Field: "informationDate"},
+	},
+}
+
+// annexMapping возвращает соответствие колонок для шаблона
+func annexMapping(dir, template string) (annex.Mapping, error) {
+	if !IsValidTemplateName(template) {
+		return annex.Mapping{}, fmt.Errorf("%w: bad template name %q", ErrInvalidTemplate, template)
+	}
+	m, err := annex.LoadMapping(filepath.Join(dir, template+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultAnnexMapping, nil
+	}
+	return m, err
+}
+
+// BuildRegistryAnnex формирует табличные приложения из элементов реестра в запрошенных форматах.
+// Имена файлов: <id заявки>.<формат>.
+func BuildRegistryAnnex(template string, req *DocxRequest, formats []string) ([]annex.File, error) {
+	if len(formats) == 0 {
+		return nil, nil
+	}
+	mapping, err := annexMapping(annexMappingsDir, template)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := registryRows(req.RegistryItems)
+	if err != nil {
+		return nil, err
+	}
+	table := mapping.Table(rows)
+
+	files := make([]annex.File, 0, len(formats))
+	for _, format := range formats {
+		content, err := annex.Encode(format, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s annex: %w", format, err)
+		}
+		files = append(files, annex.File{
+			Name:        annex.SafeName(req.ID) + "." + format,
+			ContentType: annex.ContentType(format),
+			Content:     content,
+		})
+	}
+	return files, nil
+}
+
+// registryRows переводит элементы реестра в строки по JSON именам полей, как их задаёт соответствие колонок
+func registryRows(items []RegistryItem) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal registry items: %w", err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal registry items: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,107 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-service-go/internal/pkg/annex"
+)
+
+func testRegistryRequest() *DocxRequest {
+	return &DocxRequest{
+		ID: "req/1",
+		RegistryItems: []RegistryItem{
+			{ID: 1, Name: "Карта", InvNumber: "INV-1", InformationDate: "2024-01-02"},
+			{ID: "2", Name: "План", Description: "Описание"},
+		},
+	}
+}
+
+func readAnnexCSV(t *testing.T, data []byte) [][]string {
+	t.Helper()
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read csv: %v", err)
+	}
+	return records
+}
+
+func TestBuildRegistryAnnex_DefaultMapping(t *testing.T) {
+	files, err := BuildRegistryAnnex("no_such_template", testRegistryRequest(), []string{annex.FormatCSV, annex.FormatXLSX})
+	if err != nil {
+		t.Fatalf("BuildRegistryAnnex failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "req_1.csv" || files[1].Name != "req_1.xlsx" {
+		t.Fatalf("Unexpected files: %+v", files)
+	}
+	if files[1].ContentType != annex.ContentType(annex.FormatXLSX) {
+		t.Errorf("Unexpected content type %s", files[1].ContentType)
+	}
+
+	records := readAnnexCSV(t, files[0].Content)
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(records))
+	}
+	var headers []string
+	for _, col := range defaultAnnexMapping.Columns {
+		headers = append(headers, col.Header)
+	}
+	if strings.Join(records[0], "|") != strings.Join(headers, "|") {
+		t.Errorf("Expected default headers, got %v", records[0])
+	}
+	if strings.Join(records[1], "|") != "1|Карта||INV-1||2024-01-02" {
+		t.Errorf("Unexpected first row: %v", records[1])
+	}
+	if strings.Join(records[2], "|") != "2|План|Описание|||" {
+		t.Errorf("Unexpected second row: %v", records[2])
+	}
+
+	if files, err := BuildRegistryAnnex("template", testRegistryRequest(), nil); err != nil || files != nil {
+		t.Errorf("Expected no annex without formats, got %v, %v", files, err)
+	}
+}
+
+func TestBuildRegistryAnnex_BadTemplateName(t *testing.T) {
+	for _, name := range []string{"../secret", "a/b", ""} {
+		_, err := BuildRegistryAnnex(name, testRegistryRequest(), []string{annex.FormatCSV})
+		if !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("Expected ErrInvalidTemplate for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestAnnexMapping_PerTemplate(t *testing.T) {
+	dir := t.TempDir()
+	mapping := `{"sheet":"Носители","columns":[{"header":"Инв. №","field":"invNumber"},{"header":"№","field":"#"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "custom.json"), []byte(mapping), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := annexMapping(dir, "custom")
+	if err != nil {
+		t.Fatalf("annexMapping failed: %v", err)
+	}
+	table := m.Table([]map[string]interface{}{{"invNumber": "INV-7"}})
+	if table.Sheet != "Носители" || strings.Join(table.Headers, "|") != "Инв. №|№" || strings.Join(table.Rows[0], "|") != "INV-7|1" {
+		t.Errorf("Unexpected table from template mapping: %+v", table)
+	}
+
+	// Шаблон без файла соответствия получает колонки по умолчанию
+	if m, err := annexMapping(dir, "other"); err != nil || m.Sheet != defaultAnnexMapping.Sheet {
+		t.Errorf("Expected default mapping, got %+v, %v", m, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"columns":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := annexMapping(dir, "broken"); err == nil {
+		t.Error("Expected error for invalid mapping file")
+	}
+}
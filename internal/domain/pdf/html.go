@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"pdf-service-go/internal/pkg/gotenberg"
+)
+
+// htmlTemplatesDir директория HTML шаблонов: по поддиректории на шаблон
+const htmlTemplatesDir = "internal/domain/pdf/templates/html"
+
+// Файлы HTML шаблона; header.html и footer.html необязательны
+const (
+	htmlIndexFile  = "index.html"
+	htmlHeaderFile = "header.html"
+	htmlFooterFile = "footer.html"
+)
+
+var (
+	// templateNamePattern имя шаблона не может выйти за пределы директории шаблонов
+	templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	// lengthPattern размер в дюймах или с единицами измерения, которые принимает Gotenberg
+	lengthPattern = regexp.MustCompile(`^\d+(\.\d+)?(pt|px|in|mm|cm|pc)?$`)
+)
+
+// PaperSizes размеры бумаги по названию: ширина и высота в книжной ориентации
+var PaperSizes = map[string][2]string{
+	"A3":     {"297mm", "420mm"},
+	"A4":     {"210mm", "297mm"},
+	"A5":     {"148mm", "210mm"},
+	"LETTER": {"8.5in", "11in"},
+	"LEGAL":  {"8.5in", "14in"},
+}
+
+// IsValidTemplateName проверяет имя HTML шаблона
+func IsValidTemplateName(name string) bool {
+	return templateNamePattern.MatchString(name)
+}
+
+// IsValidLength проверяет размер страницы или поля; пустое значение допустимо
+func IsValidLength(value string) bool {
+	return value == "" || lengthPattern.MatchString(value)
+}
+
+// ValidateHTMLRequest проверяет запрос на генерацию PDF из HTML до обращения к шаблону и Gotenberg
+func ValidateHTMLRequest(req *HTMLRequest) error {
+	var errors []string
+
+	if req.ID == "" {
+		errors = append(errors, "id is required")
+	}
+	if req.Template == "" {
+		errors = append(errors, "template is required")
+	} else if !IsValidTemplateName(req.Template) {
+		errors = append(errors, "template name may contain only lowercase letters, digits, '-' and '_'")
+	}
+	if size := req.Options.PaperSize; size != "" {
+		if _, ok := PaperSizes[strings.ToUpper(size)]; !ok {
+			errors = append(errors, fmt.Sprintf("unknown paper size %q", size))
+		}
+	}
+	lengths := map[string]string{
+		"paperWidth":   req.Options.PaperWidth,
+		"paperHeight":  req.Options.PaperHeight,
+		"marginTop":    req.Options.MarginTop,
+		"marginBottom": req.Options.MarginBottom,
+		"marginLeft":   req.Options.MarginLeft,
+		"marginRight":  req.Options.MarginRight,
+	}
+	for _, name := range []string{"paperWidth", "paperHeight", "marginTop", "marginBottom", "marginLeft", "marginRight"} {
+		if !IsValidLength(lengths[name]) {
+			errors = append(errors, fmt.Sprintf("invalid %s %q", name, lengths[name]))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("validation failed: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// renderHTMLDocument заполняет index.html, header.html и footer.html шаблона данными запроса
+func renderHTMLDocument(dir string, req *HTMLRequest) (gotenberg.HTMLDocument, error) {
+	if !IsValidTemplateName(req.Template) {
+		return gotenberg.HTMLDocument{}, fmt.Errorf("%w: bad template name %q", ErrInvalidTemplate, req.Template)
+	}
+	templateDir := filepath.Join(dir, req.Template)
+	if _, err := os.Stat(filepath.Join(templateDir, htmlIndexFile)); err != nil {
+		return gotenberg.HTMLDocument{}, fmt.Errorf("%w: %s", ErrTemplateNotFound, req.Template)
+	}
+
+	doc := gotenberg.HTMLDocument{Options: req.Options.gotenbergOptions()}
+	parts := []struct {
+		file     string
+		target   *[]byte
+		optional bool
+	}{
+		{htmlIndexFile, &doc.Index, false},
+		{htmlHeaderFile, &doc.Header, true},
+		{htmlFooterFile, &doc.Footer, true},
+	}
+	for _, part := range parts {
+		path := filepath.Join(templateDir, part.file)
+		if part.optional {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+		}
+		content, err := renderHTMLFile(path, req.Data)
+		if err != nil {
+			return gotenberg.HTMLDocument{}, err
+		}
+		*part.target = content
+	}
+	return doc, nil
+}
+
+// renderHTMLFile заполняет один файл шаблона; html/template экранирует данные запроса
+func renderHTMLFile(path string, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, filepath.Base(path), err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, filepath.Base(path), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gotenbergOptions переводит параметры страницы запроса в параметры Chromium; по умолчанию A4
+func (o HTMLOptions) gotenbergOptions() gotenberg.HTMLOptions {
+	size, ok := PaperSizes[strings.ToUpper(o.PaperSize)]
+	if !ok {
+		size = PaperSizes["A4"]
+	}
+	opts := gotenberg.HTMLOptions{
+		PaperWidth:      size[0],
+		PaperHeight:     size[1],
+		MarginTop:       o.MarginTop,
+		MarginBottom:    o.MarginBottom,
+		MarginLeft:      o.MarginLeft,
+		MarginRight:     o.MarginRight,
+		Landscape:       o.Landscape,
+		PrintBackground: o.PrintBackground,
+	}
+	if o.PaperWidth != "" {
+		opts.PaperWidth = o.PaperWidth
+	}
+	if o.PaperHeight != "" {
+		opts.PaperHeight = o.PaperHeight
+	}
+	return opts
+}
@@ -0,0 +1,223 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shippedHTMLTemplatesDir HTML шаблоны из поставки относительно директории пакета
+const shippedHTMLTemplatesDir = "templates/html"
+
+// writeHTMLTemplate создаёт во временной директории шаблон name с указанными файлами
+func writeHTMLTemplate(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	return dir
+}
+
+func TestRenderHTMLDocument_NoticeTemplate(t *testing.T) {
+	req := &HTMLRequest{
+		ID:       "req-1",
+		Template: "notice",
+		Data: map[string]interface{}{
+			"title":        "Уведомление",
+			"organization": "ООО «Ромашка»",
+			"paragraphs":   []string{"Первый абзац", "Второй абзац"},
+		},
+		Options: HTMLOptions{PaperSize: "letter", MarginTop: "20mm", Landscape: true},
+	}
+	doc, err := renderHTMLDocument(shippedHTMLTemplatesDir, req)
+	if err != nil {
+		t.Fatalf("renderHTMLDocument failed: %v", err)
+	}
+
+	index := string(doc.Index)
+	for _, want := range []string{"<h1>Уведомление</h1>", "<p>Первый абзац</p>", "<p>Второй абзац</p>"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("Expected index to contain %q", want)
+		}
+	}
+	if strings.Contains(index, "Номер:") {
+		t.Error("Expected empty number row to be omitted")
+	}
+	if !strings.Contains(string(doc.Header), "ООО «Ромашка»") {
+		t.Errorf("Expected organization in header, got %s", doc.Header)
+	}
+	if !strings.Contains(string(doc.Footer), `class="pageNumber"`) {
+		t.Errorf("Expected page number in footer, got %s", doc.Footer)
+	}
+	if doc.Options.PaperWidth != "8.5in" || doc.Options.PaperHeight != "11in" || doc.Options.MarginTop != "20mm" || !doc.Options.Landscape {
+		t.Errorf("Unexpected options: %+v", doc.Options)
+	}
+}
+
+func TestRenderHTMLDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		files      map[string]string
+		data       map[string]interface{}
+		wantErr    error
+		wantIndex  string
+		wantHeader bool
+		wantFooter bool
+	}{
+		{
+			name:     "path traversal",
+			template: "../notice",
+			files:    map[string]string{"index.html": "ok"},
+			wantErr:  ErrInvalidTemplate,
+		},
+		{
+			name:     "uppercase name",
+			template: "Notice",
+			files:    map[string]string{"index.html": "ok"},
+			wantErr:  ErrInvalidTemplate,
+		},
+		{
+			name:     "missing index",
+			template: "empty",
+			files:    map[string]string{"header.html": "header"},
+			wantErr:  ErrTemplateNotFound,
+		},
+		{
+			name:      "index only",
+			template:  "plain",
+			files:     map[string]string{"index.html": "<p>{{.name}}</p>"},
+			data:      map[string]interface{}{"name": "Иван"},
+			wantIndex: "<p>Иван</p>",
+		},
+		{
+			name:       "header without footer",
+			template:   "headed",
+			files:      map[string]string{"index.html": "body", "header.html": "header"},
+			wantIndex:  "body",
+			wantHeader: true,
+		},
+		{
+			name:       "footer without header",
+			template:   "footed",
+			files:      map[string]string{"index.html": "body", "footer.html": "footer"},
+			wantIndex:  "body",
+			wantFooter: true,
+		},
+		{
+			name:      "escapes request data",
+			template:  "escaped",
+			files:     map[string]string{"index.html": `<p>{{.name}}</p><a href="{{.link}}">link</a>`},
+			data:      map[string]interface{}{"name": "<script>alert(1)</script>", "link": "javascript:alert(1)"},
+			wantIndex: `<p>&lt;script&gt;alert(1)&lt;/script&gt;</p><a href="#ZgotmplZ">link</a>`,
+		},
+		{
+			name:     "broken header",
+			template: "broken",
+			files:    map[string]string{"index.html": "body", "header.html": "{{.name"},
+			wantErr:  ErrInvalidTemplate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := strings.TrimPrefix(strings.ToLower(tt.template), "../")
+			dir := writeHTMLTemplate(t, name, tt.files)
+			doc, err := renderHTMLDocument(dir, &HTMLRequest{ID: "req-1", Template: tt.template, Data: tt.data})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderHTMLDocument failed: %v", err)
+			}
+			if string(doc.Index) != tt.wantIndex {
+				t.Errorf("Expected index %q, got %q", tt.wantIndex, doc.Index)
+			}
+			if (doc.Header != nil) != tt.wantHeader || (doc.Footer != nil) != tt.wantFooter {
+				t.Errorf("Unexpected header %q or footer %q", doc.Header, doc.Footer)
+			}
+		})
+	}
+}
+
+func TestRenderHTMLFile_MissingKeyRendersEmpty(t *testing.T) {
+	dir := writeHTMLTemplate(t, "plain", map[string]string{"index.html": "<p>{{.missing}}</p>"})
+	content, err := renderHTMLFile(filepath.Join(dir, "plain", "index.html"), nil)
+	if err != nil {
+		t.Fatalf("renderHTMLFile failed: %v", err)
+	}
+	if string(content) != "<p></p>" {
+		t.Errorf("Expected empty value, got %q", content)
+	}
+}
+
+func TestValidateHTMLRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     HTMLRequest
+		wantErr string
+	}{
+		{
+			name: "valid",
+			req:  HTMLRequest{ID: "1", Template: "notice", Options: HTMLOptions{PaperSize: "a4", MarginTop: "20mm", MarginLeft: "0.5"}},
+		},
+		{
+			name: "custom paper size",
+			req:  HTMLRequest{ID: "1", Template: "notice", Options: HTMLOptions{PaperWidth: "100mm", PaperHeight: "8.5in"}},
+		},
+		{
+			name:    "missing id and template",
+			req:     HTMLRequest{},
+			wantErr: "id is required; template is required",
+		},
+		{
+			name:    "path traversal",
+			req:     HTMLRequest{ID: "1", Template: "../notice"},
+			wantErr: "template name may contain only",
+		},
+		{
+			name:    "uppercase template",
+			req:     HTMLRequest{ID: "1", Template: "Notice"},
+			wantErr: "template name may contain only",
+		},
+		{
+			name:    "unknown paper size",
+			req:     HTMLRequest{ID: "1", Template: "notice", Options: HTMLOptions{PaperSize: "B5"}},
+			wantErr: `unknown paper size "B5"`,
+		},
+		{
+			name:    "invalid paper width",
+			req:     HTMLRequest{ID: "1", Template: "notice", Options: HTMLOptions{PaperWidth: "10em"}},
+			wantErr: `invalid paperWidth "10em"`,
+		},
+		{
+			name:    "invalid margins",
+			req:     HTMLRequest{ID: "1", Template: "notice", Options: HTMLOptions{MarginTop: "-1mm", MarginRight: "1;2"}},
+			wantErr: `invalid marginTop "-1mm"; invalid marginRight "1;2"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHTMLRequest(&tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid request, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
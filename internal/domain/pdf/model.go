@@ -8,6 +8,8 @@ import (
 // Определяем пользовательские ошибки
 var (
 	ErrTemplateNotFound = errors.New("template file not found")
+	// ErrInvalidTemplate HTML шаблон не удалось разобрать или заполнить данными запроса
+	ErrInvalidTemplate = errors.New("invalid html template")
 )
 
 // ... existing code ...
@@ -73,3 +75,29 @@ type DictionaryValue struct {
 	Code  string `json:"code,omitempty"`
 	Value string `json:"value"`
 }
+
+// HTMLRequest запрос на генерацию PDF из HTML шаблона
+type HTMLRequest struct {
+	ID        string `json:"id"`
+	Operation string `json:"operation"`
+	// Template имя шаблона: директория templates/html/<template> с index.html и необязательными header.html и footer.html
+	Template string `json:"template"`
+	// Data контекст шаблона, доступный в html/template как "."
+	Data    map[string]interface{} `json:"data"`
+	Options HTMLOptions            `json:"options"`
+}
+
+// HTMLOptions параметры страницы PDF из HTML
+type HTMLOptions struct {
+	// PaperSize A3, A4, A5, Letter или Legal (по умолчанию A4); PaperWidth и PaperHeight имеют приоритет
+	PaperSize   string `json:"paperSize"`
+	PaperWidth  string `json:"paperWidth"`
+	PaperHeight string `json:"paperHeight"`
+	// Поля страницы в дюймах или с единицами измерения ("20mm")
+	MarginTop       string `json:"marginTop"`
+	MarginBottom    string `json:"marginBottom"`
+	MarginLeft      string `json:"marginLeft"`
+	MarginRight     string `json:"marginRight"`
+	Landscape       bool   `json:"landscape"`
+	PrintBackground bool   `json:"printBackground"`
+}
@@ -11,6 +11,9 @@ type Service interface {
 	// GenerateDocx генерирует PDF документ из шаблона DOCX
 	GenerateDocx(ctx context.Context, req *DocxRequest) ([]byte, error)
 
+	// GenerateHTML генерирует PDF документ из HTML шаблона через Chromium Gotenberg
+	GenerateHTML(ctx context.Context, req *HTMLRequest) ([]byte, error)
+
 	// GetCircuitBreakerState возвращает текущее состояние Circuit Breaker
 	GetCircuitBreakerState() circuitbreaker.State
 
@@ -22,4 +25,13 @@ type Service interface {
 
 	// IsDocxGeneratorHealthy возвращает true, если DOCX генератор в здоровом состоянии
 	IsDocxGeneratorHealthy() bool
+
+	// IsGotenbergReady возвращает true, если фоновая проверка /health считает Gotenberg доступным
+	IsGotenbergReady() bool
+
+	// CheckAvailability возвращает *circuitbreaker.OpenError, если генератор DOCX или Gotenberg сейчас недоступны
+	CheckAvailability() error
+
+	// CheckHTMLAvailability возвращает *circuitbreaker.OpenError, если Gotenberg сейчас недоступен для HTML
+	CheckHTMLAvailability() error
 }
@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"time"
 
+	"pdf-service-go/internal/pkg/bulkhead"
 	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/converter"
 	"pdf-service-go/internal/pkg/docxgen"
 	"pdf-service-go/internal/pkg/gotenberg"
 	"pdf-service-go/internal/pkg/logger"
@@ -22,9 +24,31 @@ import (
 	"go.uber.org/zap"
 )
 
+// gotenbergConverter клиент Gotenberg: один бэкенд с Circuit Breaker или балансировщик нескольких бэкендов
+type gotenbergConverter interface {
+	ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error)
+	ConvertHTMLToPDF(ctx context.Context, doc gotenberg.HTMLDocument) ([]byte, error)
+	State() circuitbreaker.State
+	IsHealthy() bool
+	Check() error
+	Ready() bool
+	StartProbing()
+	SetHandler(handler interface {
+		TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
+	})
+}
+
 type ServiceImpl struct {
-	gotenbergClient *gotenberg.ClientWithCircuitBreaker
-	docxGenerator   *docxgen.Generator
+	// pdfConverter Gotenberg или локальный LibreOffice, возможно с переключением на запасной бэкенд
+	pdfConverter  converter.Converter
+	docxGenerator *docxgen.Generator
+	// htmlConverter Gotenberg для HTML шаблонов: Chromium есть только в Gotenberg
+	htmlConverter gotenbergConverter
+
+	// Отсеки этапов генерации: очередь медленных финальных конвертаций не задерживает черновые
+	renderBulkhead *bulkhead.Bulkhead
+	draftBulkhead  *bulkhead.Bulkhead
+	finalBulkhead  *bulkhead.Bulkhead
 }
 
 type StatsHandler struct {
@@ -42,16 +66,64 @@ func (h *StatsHandler) TrackGotenbergRequest(duration time.Duration, hasError bo
 }
 
 func NewService(gotenbergURL string) Service {
-	client := gotenberg.NewClientWithCircuitBreaker(gotenbergURL)
+	client := newGotenbergConverter(gotenbergURL)
 	handler := &StatsHandler{
 		stats: statistics.GetInstance(),
 	}
 	client.SetHandler(handler)
+	client.StartProbing()
 
+	defaults := bulkhead.Config{MaxConcurrent: 4, QueueSize: 16, QueueTimeout: 30 * time.Second}
 	return &ServiceImpl{
-		gotenbergClient: client,
-		docxGenerator:   docxgen.NewGenerator("scripts/generate_docx.py"),
+		pdfConverter:   newConverter(client),
+		docxGenerator:  docxgen.NewGenerator("scripts/generate_docx.py"),
+		htmlConverter:  client,
+		renderBulkhead: bulkhead.New(bulkhead.ConfigFromEnv(bulkhead.Render, defaults)),
+		draftBulkhead:  bulkhead.New(bulkhead.ConfigFromEnv(bulkhead.DraftConversion, defaults)),
+		finalBulkhead:  bulkhead.New(bulkhead.ConfigFromEnv(bulkhead.FinalConversion, defaults)),
+	}
+}
+
+// newConverter выбирает основной и запасной бэкенды конвертации по CONVERTER_PRIMARY и CONVERTER_FALLBACK
+func newConverter(gotenbergClient gotenbergConverter) converter.Converter {
+	cfg := converter.ConfigFromEnv()
+	backends := map[string]converter.Converter{converter.BackendGotenberg: gotenbergClient}
+	if cfg.Uses(converter.BackendLibreOffice) {
+		backends[converter.BackendLibreOffice] = converter.NewLibreOffice(converter.LibreOfficeConfigFromEnv())
+	}
+	conv, err := converter.New(cfg, backends)
+	if err != nil {
+		logger.Log.Error("Invalid converter configuration, using Gotenberg", zap.Error(err))
+		return gotenbergClient
+	}
+	logger.Log.Info("PDF converter configured",
+		zap.String("primary", cfg.Primary),
+		zap.String("fallback", cfg.Fallback))
+	return conv
+}
+
+// newGotenbergConverter создаёт балансировщик, если в GOTENBERG_BACKENDS задано несколько бэкендов
+func newGotenbergConverter(gotenbergURL string) gotenbergConverter {
+	cfg, err := gotenberg.BalancerConfigFromEnv(gotenbergURL)
+	if err != nil {
+		logger.Log.Error("Invalid GOTENBERG_BACKENDS, using single backend", zap.Error(err))
+		return gotenberg.NewClientWithCircuitBreaker(gotenbergURL)
+	}
+	if len(cfg.Backends) <= 1 {
+		if len(cfg.Backends) == 1 {
+			gotenbergURL = cfg.Backends[0].URL
+		}
+		return gotenberg.NewClientWithCircuitBreaker(gotenbergURL)
+	}
+	balancer, err := gotenberg.NewBalancer(cfg)
+	if err != nil {
+		logger.Log.Error("Failed to create Gotenberg balancer, using single backend", zap.Error(err))
+		return gotenberg.NewClientWithCircuitBreaker(cfg.Backends[0].URL)
 	}
+	logger.Log.Info("Gotenberg load balancing enabled",
+		zap.Int("backends", len(cfg.Backends)),
+		zap.String("strategy", cfg.Strategy))
+	return balancer
 }
 
 type contextKey string
@@ -87,6 +159,12 @@ func (s *ServiceImpl) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byt
 		)
 	}()
 
+	// Не запускаем Python и не создаём файлы, если конвертеры заведомо недоступны
+	if err := s.CheckAvailability(); err != nil {
+		log.Warn("Converters unavailable, failing fast", zap.Error(err))
+		return nil, err
+	}
+
 	// Увеличиваем счетчик общего количества запросов
 	metrics.RequestsTotal.WithLabelValues("started").Inc()
 	log.Info("Starting PDF generation")
@@ -142,7 +220,10 @@ func (s *ServiceImpl) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byt
 
 	// Генерируем черновик DOCX
 	log.Info("Generating draft DOCX for page counting")
-	if err := s.docxGenerator.Generate(ctx, templatePath, draftDataFile.Name(), draftDocxFile.Name()); err != nil {
+	err = s.renderBulkhead.Execute(ctx, func() error {
+		return s.docxGenerator.Generate(ctx, templatePath, draftDataFile.Name(), draftDocxFile.Name())
+	})
+	if err != nil {
 		log.Error("Failed to generate draft DOCX", zap.Error(err))
 		metrics.RequestsTotal.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("failed to generate draft DOCX: %w", err)
@@ -150,7 +231,12 @@ func (s *ServiceImpl) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byt
 
 	// Конвертируем черновик в PDF и подсчитываем страницы
 	log.Info("Converting draft DOCX to PDF for page counting")
-	draftPdfContent, err := s.gotenbergClient.ConvertDocxToPDF(draftDocxFile.Name())
+	var draftPdfContent []byte
+	err = s.draftBulkhead.Execute(ctx, func() error {
+		var err error
+		draftPdfContent, err = s.pdfConverter.ConvertDocxToPDF(ctx, draftDocxFile.Name())
+		return err
+	})
 	if err != nil {
 		log.Error("Failed to convert draft DOCX to PDF", zap.Error(err))
 		metrics.RequestsTotal.WithLabelValues("error").Inc()
@@ -201,7 +287,10 @@ func (s *ServiceImpl) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byt
 	// Генерируем финальный DOCX
 	log.Info("Starting final DOCX generation", zap.Int("pages", req.Pages))
 	docxStart := time.Now()
-	if err := s.docxGenerator.Generate(ctxDocx, templatePath, dataFile.Name(), docxFile.Name()); err != nil {
+	err = s.renderBulkhead.Execute(ctxDocx, func() error {
+		return s.docxGenerator.Generate(ctxDocx, templatePath, dataFile.Name(), docxFile.Name())
+	})
+	if err != nil {
 		log.Error("Failed to generate DOCX", zap.Error(err))
 		tracing.RecordError(ctxDocx, err)
 		tracing.SetStatus(ctxDocx, codes.Error, "docx generation failed")
@@ -223,7 +312,12 @@ func (s *ServiceImpl) GenerateDocx(ctx context.Context, req *DocxRequest) ([]byt
 	log.Info("Starting PDF conversion with Gotenberg")
 	ctxPDF, spanPDF := tracing.StartSpan(ctx, "gotenberg.convert")
 	pdfStart := time.Now()
-	pdfContent, err := s.gotenbergClient.ConvertDocxToPDF(docxFile.Name())
+	var pdfContent []byte
+	err = s.finalBulkhead.Execute(ctxPDF, func() error {
+		var err error
+		pdfContent, err = s.pdfConverter.ConvertDocxToPDF(ctxPDF, docxFile.Name())
+		return err
+	})
 	pdfConversionTime = time.Since(pdfStart)
 	if pdfConversionTime > 60*time.Second {
 		logger.Log.Warn("PDF conversion exceeded threshold", zap.Float64("seconds", pdfConversionTime.Seconds()))
@@ -276,14 +370,14 @@ func countPages(pdfContent []byte) int {
 	return pageCount - 3
 }
 
-// GetCircuitBreakerState возвращает текущее состояние Circuit Breaker для Gotenberg
+// GetCircuitBreakerState возвращает текущее состояние Circuit Breaker основного бэкенда конвертации
 func (s *ServiceImpl) GetCircuitBreakerState() circuitbreaker.State {
-	return s.gotenbergClient.State()
+	return s.pdfConverter.State()
 }
 
-// IsCircuitBreakerHealthy возвращает true, если Circuit Breaker для Gotenberg в здоровом состоянии
+// IsCircuitBreakerHealthy возвращает true, если конвертацию может принять основной или запасной бэкенд
 func (s *ServiceImpl) IsCircuitBreakerHealthy() bool {
-	return s.gotenbergClient.IsHealthy()
+	return s.pdfConverter.IsHealthy()
 }
 
 // GetDocxGeneratorState возвращает текущее состояние Circuit Breaker для генератора DOCX
@@ -295,3 +389,78 @@ func (s *ServiceImpl) GetDocxGeneratorState() circuitbreaker.State {
 func (s *ServiceImpl) IsDocxGeneratorHealthy() bool {
 	return s.docxGenerator.IsHealthy()
 }
+
+// IsGotenbergReady возвращает готовность основного или запасного бэкенда конвертации
+func (s *ServiceImpl) IsGotenbergReady() bool {
+	return s.pdfConverter.Ready()
+}
+
+// CheckAvailability проверяет Circuit Breaker генератора DOCX и бэкендов конвертации без выполнения запросов
+func (s *ServiceImpl) CheckAvailability() error {
+	if err := s.docxGenerator.Check(); err != nil {
+		return err
+	}
+	return s.pdfConverter.Check()
+}
+
+// CheckHTMLAvailability проверяет Circuit Breaker Gotenberg без выполнения запросов
+func (s *ServiceImpl) CheckHTMLAvailability() error {
+	return s.htmlConverter.Check()
+}
+
+// GenerateHTML заполняет HTML шаблон данными запроса и конвертирует его в PDF через Chromium Gotenberg.
+// Конвертация проходит через тот же отсек и конвейер устойчивости, что и финальная конвертация DOCX.
+func (s *ServiceImpl) GenerateHTML(ctx context.Context, req *HTMLRequest) ([]byte, error) {
+	log := logger.Log.With(
+		zap.String("request_id", req.ID),
+		zap.String("operation", req.Operation),
+		zap.String("template", req.Template),
+	)
+
+	start := time.Now()
+	defer func() {
+		metrics.HTTPRequestDuration.WithLabelValues("POST", "/api/v1/html").Observe(time.Since(start).Seconds())
+	}()
+
+	if err := s.CheckHTMLAvailability(); err != nil {
+		log.Warn("Gotenberg unavailable, failing fast", zap.Error(err))
+		return nil, err
+	}
+
+	metrics.RequestsTotal.WithLabelValues("started").Inc()
+	_, spanRender := tracing.StartSpan(ctx, "html.render")
+	doc, err := renderHTMLDocument(htmlTemplatesDir, req)
+	spanRender.End()
+	if err != nil {
+		log.Error("Failed to render HTML template", zap.Error(err))
+		metrics.RequestsTotal.WithLabelValues("error").Inc()
+		return nil, err
+	}
+
+	ctxPDF, spanPDF := tracing.StartSpan(ctx, "gotenberg.convert_html")
+	pdfStart := time.Now()
+	var pdfContent []byte
+	err = s.finalBulkhead.Execute(ctxPDF, func() error {
+		var err error
+		pdfContent, err = s.htmlConverter.ConvertHTMLToPDF(ctxPDF, doc)
+		return err
+	})
+	if err != nil {
+		log.Error("Failed to convert HTML to PDF", zap.Error(err))
+		tracing.RecordError(ctxPDF, err)
+		tracing.SetStatus(ctxPDF, codes.Error, "html conversion failed")
+		spanPDF.End()
+		metrics.RequestsTotal.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("failed to convert HTML to PDF: %w", err)
+	}
+	spanPDF.End()
+
+	log.Info("HTML conversion completed",
+		zap.Float64("pdf_conversion_seconds", time.Since(pdfStart).Seconds()),
+		zap.Float64("pdf_size_mb", float64(len(pdfContent))/1024/1024),
+	)
+	metrics.RequestsTotal.WithLabelValues("completed").Inc()
+	metrics.PDFFileSizeBytes.WithLabelValues("generate-html").Observe(float64(len(pdfContent)))
+
+	return pdfContent, nil
+}
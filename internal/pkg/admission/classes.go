@@ -0,0 +1,95 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Классы приоритета по умолчанию
+const (
+	ClassInteractive = "interactive"
+	ClassBulk        = "bulk"
+)
+
+// AttrPriority атрибут API ключа (или claim attributes JWT) с максимальным классом приоритета клиента
+const AttrPriority = "priority"
+
+// HeaderPriority заголовок, которым клиент выбирает класс приоритета запроса
+const HeaderPriority = "X-Priority"
+
+// Class класс приоритета генераций
+type Class struct {
+	Name string
+	// Weight вес класса при справедливом распределении освободившихся слотов
+	Weight int
+	// MaxInFlight максимум одновременных генераций класса (0 — ограничен только общим лимитом)
+	MaxInFlight int
+}
+
+// DefaultClasses классы по умолчанию: bulk не может занять последний слот, чтобы интерактивные запросы не ждали выгрузок
+func DefaultClasses(maxInFlight int) []Class {
+	bulkCap := maxInFlight - 1
+	if bulkCap < 1 {
+		bulkCap = 1
+	}
+	return []Class{
+		{Name: ClassInteractive, Weight: 3},
+		{Name: ClassBulk, Weight: 1, MaxInFlight: bulkCap},
+	}
+}
+
+// ParseClasses разбирает список классов вида "interactive:3:0,bulk:1:2" (имя:вес:лимит).
+// Порядок задаёт приоритет: первый класс — наивысший.
+func ParseClasses(value string) ([]Class, error) {
+	var classes []Class
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) < 1 || len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid priority class %q", part)
+		}
+		class := Class{Name: strings.ToLower(fields[0]), Weight: 1}
+		if seen[class.Name] {
+			return nil, fmt.Errorf("duplicate priority class %q", class.Name)
+		}
+		if len(fields) > 1 {
+			w, err := strconv.Atoi(fields[1])
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight for priority class %q", class.Name)
+			}
+			class.Weight = w
+		}
+		if len(fields) > 2 {
+			m, err := strconv.Atoi(fields[2])
+			if err != nil || m < 0 {
+				return nil, fmt.Errorf("invalid concurrency cap for priority class %q", class.Name)
+			}
+			class.MaxInFlight = m
+		}
+		seen[class.Name] = true
+		classes = append(classes, class)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("no priority classes defined")
+	}
+	return classes, nil
+}
+
+type classKey struct{}
+
+// WithClass сохраняет класс приоритета запроса в контексте
+func WithClass(ctx context.Context, class string) context.Context {
+	return context.WithValue(ctx, classKey{}, class)
+}
+
+// ClassFromContext возвращает класс приоритета из контекста
+func ClassFromContext(ctx context.Context) string {
+	class, _ := ctx.Value(classKey{}).(string)
+	return class
+}
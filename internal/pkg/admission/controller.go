@@ -0,0 +1,378 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/waitqueue"
+
+	"go.uber.org/zap"
+)
+
+// Причины отказа в допуске
+const (
+	ReasonQueueFull    = "queue_full"
+	ReasonQueueTimeout = "queue_timeout"
+)
+
+// ErrRejected запрос не допущен к генерации (очередь переполнена или истекло ожидание)
+var ErrRejected = errors.New("admission rejected")
+
+// RejectedError описывает отказ в допуске и рекомендуемую задержку повтора
+type RejectedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("generation queue is saturated (%s), retry after %s", e.Reason, e.RetryAfter)
+}
+
+// Is позволяет проверять отказ через errors.Is(err, ErrRejected)
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Config настройки контроллера допуска
+type Config struct {
+	// MaxInFlight максимум одновременных генераций (0 — без ограничения)
+	MaxInFlight int
+	// QueueSize максимум запросов, ожидающих свободного слота (во всех классах)
+	QueueSize int
+	// QueueTimeout максимальное время ожидания в очереди
+	QueueTimeout time.Duration
+	// Classes классы приоритета в порядке убывания приоритета
+	Classes []Class
+	// DefaultClass класс запросов без явного приоритета
+	DefaultClass string
+}
+
+// ConfigFromEnv читает настройки из окружения
+func ConfigFromEnv() Config {
+	cfg := Config{
+		MaxInFlight:  getEnvIntWithDefault("MAX_CONCURRENT_REQUESTS", 4),
+		QueueSize:    getEnvIntWithDefault("ADMISSION_QUEUE_SIZE", 32),
+		QueueTimeout: getEnvDurationWithDefault("ADMISSION_QUEUE_TIMEOUT", 30*time.Second),
+		DefaultClass: strings.ToLower(os.Getenv("ADMISSION_DEFAULT_CLASS")),
+	}
+	if value := os.Getenv("ADMISSION_CLASSES"); value != "" {
+		classes, err := ParseClasses(value)
+		if err != nil {
+			logger.Warn("Invalid ADMISSION_CLASSES, using defaults", zap.Error(err))
+		} else {
+			cfg.Classes = classes
+		}
+	}
+	return cfg
+}
+
+// Controller ограничивает число одновременных генераций и держит ограниченную очередь ожидания.
+// Освободившиеся слоты распределяются между классами приоритета по весам (smooth weighted round robin),
+// внутри класса — в порядке поступления.
+type Controller struct {
+	cfg     Config
+	now     func() time.Time
+	classes map[string]*classState
+	// order классы в порядке убывания приоритета
+	order []*classState
+
+	mu       sync.Mutex
+	inFlight int
+	queued   int
+	// hold время удержания слота, используется для Retry-After
+	hold waitqueue.HoldEstimator
+}
+
+type classState struct {
+	Class
+	rank     int
+	inFlight int
+	waiters  *waitqueue.Queue
+	// current текущий вес в smooth weighted round robin
+	current int
+}
+
+// New создаёт контроллер допуска
+func New(cfg Config) *Controller {
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = 30 * time.Second
+	}
+	if len(cfg.Classes) == 0 {
+		cfg.Classes = DefaultClasses(cfg.MaxInFlight)
+	}
+	c := &Controller{
+		cfg:     cfg,
+		now:     time.Now,
+		classes: make(map[string]*classState, len(cfg.Classes)),
+	}
+	for i, class := range cfg.Classes {
+		if class.Weight < 1 {
+			class.Weight = 1
+		}
+		state := &classState{Class: class, rank: i, waiters: waitqueue.New()}
+		c.classes[class.Name] = state
+		c.order = append(c.order, state)
+		classCapacity.WithLabelValues(class.Name).Set(float64(class.MaxInFlight))
+	}
+	if _, ok := c.classes[cfg.DefaultClass]; !ok {
+		c.cfg.DefaultClass = cfg.Classes[0].Name
+	}
+	capacity.Set(float64(cfg.MaxInFlight))
+	queueCapacity.Set(float64(cfg.QueueSize))
+	return c
+}
+
+// Enabled возвращает true, если число одновременных генераций ограничено
+func (c *Controller) Enabled() bool {
+	return c != nil && c.cfg.MaxInFlight > 0
+}
+
+// Classes возвращает имена классов в порядке убывания приоритета
+func (c *Controller) Classes() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, len(c.order))
+	for i, state := range c.order {
+		names[i] = state.Name
+	}
+	return names
+}
+
+// DefaultClass возвращает класс запросов без явного приоритета
+func (c *Controller) DefaultClass() string {
+	if c == nil {
+		return ""
+	}
+	return c.cfg.DefaultClass
+}
+
+// ResolveClass выбирает класс запроса: requested (например, из заголовка) применяется,
+// только если он не выше allowed (атрибут клиента, иначе класс по умолчанию).
+func (c *Controller) ResolveClass(requested, allowed string) string {
+	if c == nil {
+		return ""
+	}
+	limit, ok := c.classes[strings.ToLower(allowed)]
+	if !ok {
+		limit = c.classes[c.cfg.DefaultClass]
+	}
+	if want, ok := c.classes[strings.ToLower(requested)]; ok && want.rank >= limit.rank {
+		return want.Name
+	}
+	return limit.Name
+}
+
+// Acquire занимает слот генерации для класса из контекста (см. WithClass), при необходимости ожидая в очереди.
+// Возвращает функцию освобождения слота, *RejectedError при отказе или ошибку контекста.
+func (c *Controller) Acquire(ctx context.Context) (func(), error) {
+	if !c.Enabled() {
+		return func() {}, nil
+	}
+	start := c.now()
+
+	c.mu.Lock()
+	class, ok := c.classes[ClassFromContext(ctx)]
+	if !ok {
+		class = c.classes[c.cfg.DefaultClass]
+	}
+	if c.hasSlotLocked(class) && class.waiters.Len() == 0 {
+		c.takeSlotLocked(class)
+		c.updateGaugesLocked()
+		c.mu.Unlock()
+		queueWait.WithLabelValues(class.Name).Observe(0)
+		return c.releaseFunc(class, start), nil
+	}
+	if c.queued >= c.cfg.QueueSize {
+		retryAfter := c.retryAfterLocked()
+		c.mu.Unlock()
+		rejectedTotal.WithLabelValues(ReasonQueueFull, class.Name).Inc()
+		return nil, &RejectedError{Reason: ReasonQueueFull, RetryAfter: retryAfter}
+	}
+	w := class.waiters.Push()
+	c.queued++
+	c.updateGaugesLocked()
+	c.mu.Unlock()
+
+	waitErr := w.Wait(ctx, c.cfg.QueueTimeout)
+	if waitErr == nil {
+		queueWait.WithLabelValues(class.Name).Observe(c.now().Sub(start).Seconds())
+		return c.releaseFunc(class, c.now()), nil
+	}
+
+	c.mu.Lock()
+	if !class.waiters.Remove(w) {
+		// Слот выдан одновременно с таймаутом — возвращаем его следующему
+		c.releaseSlotLocked(class)
+	} else {
+		c.queued--
+		// Освободившееся место в очереди класса могло разблокировать другие классы
+		c.dispatchLocked()
+	}
+	c.updateGaugesLocked()
+	retryAfter := c.retryAfterLocked()
+	c.mu.Unlock()
+
+	queueWait.WithLabelValues(class.Name).Observe(c.now().Sub(start).Seconds())
+	if !errors.Is(waitErr, waitqueue.ErrTimeout) {
+		rejectedTotal.WithLabelValues("canceled", class.Name).Inc()
+		return nil, waitErr
+	}
+	rejectedTotal.WithLabelValues(ReasonQueueTimeout, class.Name).Inc()
+	return nil, &RejectedError{Reason: ReasonQueueTimeout, RetryAfter: retryAfter}
+}
+
+// Stats возвращает текущее число генераций и длину очереди
+func (c *Controller) Stats() (inFlight, queued int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight, c.queued
+}
+
+// ClassStats возвращает число генераций и длину очереди класса
+func (c *Controller) ClassStats(name string) (inFlight, queued int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	class, ok := c.classes[name]
+	if !ok {
+		return 0, 0
+	}
+	return class.inFlight, class.waiters.Len()
+}
+
+func (c *Controller) releaseFunc(class *classState, start time.Time) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hold := c.now().Sub(start)
+			generationDuration.WithLabelValues(class.Name).Observe(hold.Seconds())
+			c.mu.Lock()
+			c.hold.Observe(hold)
+			c.releaseSlotLocked(class)
+			c.updateGaugesLocked()
+			c.mu.Unlock()
+		})
+	}
+}
+
+// hasSlotLocked проверяет общий лимит и лимит класса
+func (c *Controller) hasSlotLocked(class *classState) bool {
+	if c.inFlight >= c.cfg.MaxInFlight {
+		return false
+	}
+	return class.MaxInFlight <= 0 || class.inFlight < class.MaxInFlight
+}
+
+func (c *Controller) takeSlotLocked(class *classState) {
+	c.inFlight++
+	class.inFlight++
+}
+
+// releaseSlotLocked освобождает слот класса и раздаёт свободные слоты ожидающим
+func (c *Controller) releaseSlotLocked(class *classState) {
+	c.inFlight--
+	class.inFlight--
+	c.dispatchLocked()
+}
+
+// dispatchLocked выдаёт свободные слоты ожидающим по весам классов
+func (c *Controller) dispatchLocked() {
+	for c.inFlight < c.cfg.MaxInFlight {
+		class := c.pickClassLocked()
+		if class == nil {
+			return
+		}
+		class.waiters.Grant()
+		c.queued--
+		c.takeSlotLocked(class)
+	}
+}
+
+// pickClassLocked выбирает класс по smooth weighted round robin среди классов с ожидающими и свободным лимитом
+func (c *Controller) pickClassLocked() *classState {
+	var best *classState
+	total := 0
+	for _, class := range c.order {
+		if class.waiters.Len() == 0 || !c.hasSlotLocked(class) {
+			continue
+		}
+		class.current += class.Weight
+		total += class.Weight
+		if best == nil || class.current > best.current {
+			best = class
+		}
+	}
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// retryAfterLocked оценивает время освобождения места в очереди
+func (c *Controller) retryAfterLocked() time.Duration {
+	return c.hold.RetryAfter(c.queued, c.cfg.MaxInFlight)
+}
+
+func (c *Controller) updateGaugesLocked() {
+	inFlightGauge.Set(float64(c.inFlight))
+	queueDepth.Set(float64(c.queued))
+	for _, class := range c.order {
+		classInFlight.WithLabelValues(class.Name).Set(float64(class.inFlight))
+		classQueueDepth.WithLabelValues(class.Name).Set(float64(class.waiters.Len()))
+	}
+}
+
+var (
+	defaultMu         sync.RWMutex
+	defaultController *Controller
+)
+
+// Default возвращает контроллер, заданный через SetDefault (nil — без ограничений)
+func Default() *Controller {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultController
+}
+
+// SetDefault задаёт контроллер по умолчанию
+func SetDefault(c *Controller) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultController = c
+}
+
+// getEnvIntWithDefault возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,280 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestController_AcquireWithinLimit(t *testing.T) {
+	c := New(Config{MaxInFlight: 2, QueueSize: 1, QueueTimeout: time.Second})
+
+	r1, err := c.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r2, err := c.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if inFlight, queued := c.Stats(); inFlight != 2 || queued != 0 {
+		t.Errorf("Expected 2 in flight and empty queue, got %d/%d", inFlight, queued)
+	}
+	r1()
+	r1() // повторное освобождение не должно ломать счётчик
+	r2()
+	if inFlight, _ := c.Stats(); inFlight != 0 {
+		t.Errorf("Expected no generations in flight, got %d", inFlight)
+	}
+}
+
+func TestController_QueueFull(t *testing.T) {
+	c := New(Config{MaxInFlight: 1, QueueSize: 1, QueueTimeout: time.Second})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	queued := make(chan error, 1)
+	go func() {
+		r, err := c.Acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		queued <- err
+	}()
+	waitForQueue(t, c, 1)
+
+	_, err := c.Acquire(context.Background())
+	var rej *RejectedError
+	if !errors.As(err, &rej) || rej.Reason != ReasonQueueFull {
+		t.Fatalf("Expected queue_full rejection, got %v", err)
+	}
+	if !errors.Is(err, ErrRejected) {
+		t.Error("Expected errors.Is(err, ErrRejected)")
+	}
+	if rej.RetryAfter < time.Second {
+		t.Errorf("Expected RetryAfter >= 1s, got %v", rej.RetryAfter)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Errorf("Expected queued request to be admitted, got %v", err)
+	}
+}
+
+func TestController_QueueTimeout(t *testing.T) {
+	c := New(Config{MaxInFlight: 1, QueueSize: 4, QueueTimeout: 20 * time.Millisecond})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	_, err := c.Acquire(context.Background())
+	var rej *RejectedError
+	if !errors.As(err, &rej) || rej.Reason != ReasonQueueTimeout {
+		t.Fatalf("Expected queue_timeout rejection, got %v", err)
+	}
+	if _, queued := c.Stats(); queued != 0 {
+		t.Errorf("Expected timed out request to leave the queue, got %d", queued)
+	}
+}
+
+func TestController_ContextCanceled(t *testing.T) {
+	c := New(Config{MaxInFlight: 1, QueueSize: 4, QueueTimeout: time.Second})
+	release, _ := c.Acquire(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waitForQueue(t, c, 1)
+		cancel()
+	}()
+	if _, err := c.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestController_FIFOAndConcurrencyLimit(t *testing.T) {
+	const limit = 3
+	c := New(Config{MaxInFlight: limit, QueueSize: 100, QueueTimeout: 5 * time.Second})
+
+	var mu sync.Mutex
+	current, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := c.Acquire(context.Background())
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			current++
+			if current > peak {
+				peak = current
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			current--
+			mu.Unlock()
+			release()
+		}()
+	}
+	wg.Wait()
+
+	if peak > limit {
+		t.Errorf("Expected at most %d concurrent generations, got %d", limit, peak)
+	}
+	if inFlight, queued := c.Stats(); inFlight != 0 || queued != 0 {
+		t.Errorf("Expected idle controller, got %d/%d", inFlight, queued)
+	}
+}
+
+func TestController_Disabled(t *testing.T) {
+	var nilController *Controller
+	if _, err := nilController.Acquire(context.Background()); err != nil {
+		t.Errorf("Expected nil controller to admit everything, got %v", err)
+	}
+	c := New(Config{MaxInFlight: 0})
+	for i := 0; i < 10; i++ {
+		if _, err := c.Acquire(context.Background()); err != nil {
+			t.Fatalf("Expected unlimited controller to admit everything, got %v", err)
+		}
+	}
+}
+
+func waitForQueue(t *testing.T, c *Controller, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, queued := c.Stats(); queued >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("Queue did not reach %d waiters", n)
+}
+
+type grant struct {
+	class   string
+	release func()
+}
+
+func TestController_WeightedFairScheduling(t *testing.T) {
+	c := New(Config{
+		MaxInFlight:  1,
+		QueueSize:    16,
+		QueueTimeout: 5 * time.Second,
+		Classes:      []Class{{Name: ClassInteractive, Weight: 3}, {Name: ClassBulk, Weight: 1}},
+	})
+	release, _ := c.Acquire(context.Background())
+
+	grants := make(chan grant, 8)
+	enqueue := func(class string, n int) {
+		for i := 0; i < n; i++ {
+			go func() {
+				r, err := c.Acquire(WithClass(context.Background(), class))
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				grants <- grant{class: class, release: r}
+			}()
+		}
+	}
+	// Выгрузка пришла первой, интерактивные запросы — после неё
+	enqueue(ClassBulk, 4)
+	waitForQueue(t, c, 4)
+	enqueue(ClassInteractive, 4)
+	waitForQueue(t, c, 8)
+
+	var order []string
+	release()
+	for i := 0; i < 8; i++ {
+		g := <-grants
+		order = append(order, g.class)
+		g.release()
+	}
+
+	interactive := 0
+	for _, class := range order[:4] {
+		if class == ClassInteractive {
+			interactive++
+		}
+	}
+	if interactive != 3 {
+		t.Errorf("Expected 3 of the first 4 slots to go to interactive (weight 3:1), got order %v", order)
+	}
+}
+
+func TestController_ClassConcurrencyCap(t *testing.T) {
+	c := New(Config{
+		MaxInFlight:  3,
+		QueueSize:    8,
+		QueueTimeout: 20 * time.Millisecond,
+		Classes:      []Class{{Name: ClassInteractive, Weight: 3}, {Name: ClassBulk, Weight: 1, MaxInFlight: 2}},
+	})
+	bulk := WithClass(context.Background(), ClassBulk)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Acquire(bulk); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Третья выгрузка упирается в лимит класса, хотя общий слот свободен
+	if _, err := c.Acquire(bulk); !errors.Is(err, ErrRejected) {
+		t.Fatalf("Expected bulk request to wait for its class cap, got %v", err)
+	}
+	// Интерактивный запрос получает оставшийся слот сразу
+	release, err := c.Acquire(WithClass(context.Background(), ClassInteractive))
+	if err != nil {
+		t.Fatalf("Expected interactive request to be admitted, got %v", err)
+	}
+	release()
+	if inFlight, _ := c.ClassStats(ClassBulk); inFlight != 2 {
+		t.Errorf("Expected 2 bulk generations in flight, got %d", inFlight)
+	}
+}
+
+func TestController_ResolveClass(t *testing.T) {
+	c := New(Config{MaxInFlight: 4})
+	tests := []struct {
+		requested, allowed, want string
+	}{
+		{"", "", ClassInteractive},
+		{"bulk", "", ClassBulk},
+		{"BULK", "interactive", ClassBulk},
+		{"interactive", "bulk", ClassBulk},
+		{"unknown", "bulk", ClassBulk},
+		{"", "unknown", ClassInteractive},
+	}
+	for _, tt := range tests {
+		if got := c.ResolveClass(tt.requested, tt.allowed); got != tt.want {
+			t.Errorf("ResolveClass(%q, %q) = %q, want %q", tt.requested, tt.allowed, got, tt.want)
+		}
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	classes, err := ParseClasses("interactive:3, bulk:1:2 ,batch")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []Class{{"interactive", 3, 0}, {"bulk", 1, 2}, {"batch", 1, 0}}
+	if len(classes) != len(want) {
+		t.Fatalf("Expected %d classes, got %+v", len(want), classes)
+	}
+	for i := range want {
+		if classes[i] != want[i] {
+			t.Errorf("Class %d: expected %+v, got %+v", i, want[i], classes[i])
+		}
+	}
+
+	for _, bad := range []string{"", "a:0", "a:1:-1", "a,a", "a:1:2:3", ":1"} {
+		if _, err := ParseClasses(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package admission
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// inFlightGauge количество выполняющихся генераций
+	inFlightGauge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "admission_in_flight",
+			Help: "Number of PDF generations currently in flight",
+		},
+	)
+
+	// capacity максимум одновременных генераций
+	capacity = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "admission_in_flight_limit",
+			Help: "Maximum number of concurrent PDF generations",
+		},
+	)
+
+	// queueDepth количество запросов в очереди (метрика для HPA)
+	queueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "admission_queue_depth",
+			Help: "Number of generation requests waiting for a free slot",
+		},
+	)
+
+	// queueCapacity размер очереди ожидания
+	queueCapacity = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "admission_queue_capacity",
+			Help: "Maximum number of generation requests allowed to wait",
+		},
+	)
+
+	// classInFlight выполняющиеся генерации по классам приоритета
+	classInFlight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "admission_class_in_flight",
+			Help: "Number of PDF generations in flight per priority class",
+		},
+		[]string{"class"},
+	)
+
+	// classCapacity лимит одновременных генераций класса (0 — только общий лимит)
+	classCapacity = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "admission_class_in_flight_limit",
+			Help: "Maximum number of concurrent PDF generations per priority class (0 means shared limit only)",
+		},
+		[]string{"class"},
+	)
+
+	// classQueueDepth длина очереди по классам приоритета
+	classQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "admission_class_queue_depth",
+			Help: "Number of generation requests waiting for a free slot per priority class",
+		},
+		[]string{"class"},
+	)
+
+	// queueWait время ожидания слота
+	queueWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "admission_queue_wait_seconds",
+			Help:    "Time generation requests spent waiting for a free slot",
+			Buckets: []float64{0, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		},
+		[]string{"class"},
+	)
+
+	// generationDuration время удержания слота (длительность генерации)
+	generationDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "admission_generation_duration_seconds",
+			Help:    "Time generation requests held an admission slot",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+		},
+		[]string{"class"},
+	)
+
+	// rejectedTotal отклонённые запросы по причинам
+	rejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "admission_rejected_total",
+			Help: "Total number of generation requests rejected by admission control",
+		},
+		[]string{"reason", "class"},
+	)
+)
@@ -0,0 +1,203 @@
+package annex
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Форматы приложения
+const (
+	FormatXLSX = "xlsx"
+	FormatCSV  = "csv"
+)
+
+// Formats поддерживаемые форматы приложения
+var Formats = []string{FormatXLSX, FormatCSV}
+
+// FieldRowNumber поле колонки с порядковым номером строки
+const FieldRowNumber = "#"
+
+// generationTotal сформированные приложения по формату и результату
+var generationTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "annex_generation_total",
+		Help: "Total number of spreadsheet annexes generated",
+	},
+	[]string{"format", "status"},
+)
+
+// Column колонка таблицы: заголовок и поле строки данных
+type Column struct {
+	Header string `json:"header"`
+	// Field имя поля строки (JSON имя поля элемента реестра) или "#" для номера строки
+	Field string `json:"field"`
+}
+
+// Mapping соответствие колонок таблицы полям данных для одного шаблона
+type Mapping struct {
+	// Sheet имя листа XLSX
+	Sheet   string   `json:"sheet"`
+	Columns []Column `json:"columns"`
+}
+
+// LoadMapping читает соответствие колонок из JSON файла
+func LoadMapping(path string) (Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Mapping{}, err
+	}
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Mapping{}, fmt.Errorf("invalid annex mapping %s: %w", path, err)
+	}
+	if len(m.Columns) == 0 {
+		return Mapping{}, fmt.Errorf("invalid annex mapping %s: no columns", path)
+	}
+	return m, nil
+}
+
+// Table таблица приложения
+type Table struct {
+	Sheet   string
+	Headers []string
+	Rows    [][]string
+}
+
+// Table строит таблицу из строк данных по соответствию колонок
+func (m Mapping) Table(rows []map[string]interface{}) Table {
+	t := Table{Sheet: m.Sheet, Headers: make([]string, len(m.Columns)), Rows: make([][]string, 0, len(rows))}
+	for i, col := range m.Columns {
+		t.Headers[i] = col.Header
+	}
+	for n, row := range rows {
+		cells := make([]string, len(m.Columns))
+		for i, col := range m.Columns {
+			if col.Field == FieldRowNumber {
+				cells[i] = strconv.Itoa(n + 1)
+				continue
+			}
+			cells[i] = formatValue(row[col.Field])
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	return t
+}
+
+// formatValue приводит значение JSON к тексту ячейки; целые числа без дробной части
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	}
+}
+
+// ParseFormats разбирает список форматов через запятую; пустая строка — приложение не нужно
+func ParseFormats(value string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		format := strings.ToLower(strings.TrimSpace(part))
+		if format == "" || seen[format] {
+			continue
+		}
+		if format != FormatXLSX && format != FormatCSV {
+			return nil, fmt.Errorf("unknown annex format %q", format)
+		}
+		seen[format] = true
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// ContentType возвращает MIME тип формата
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// File файл приложения или архива
+type File struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// SafeName приводит идентификатор к имени файла: всё, кроме латинских букв, цифр, '-', '_' и '.', заменяется на '_'.
+// Защищает записи ZIP архива и Content-Disposition от путей и кавычек из данных запроса.
+func SafeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.') {
+			return r
+		}
+		return '_'
+	}, name)
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return "document"
+	}
+	return name
+}
+
+// Encode формирует файл таблицы в указанном формате
+func Encode(format string, t Table) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatXLSX:
+		err = WriteXLSX(&buf, t)
+	case FormatCSV:
+		err = WriteCSV(&buf, t)
+	default:
+		err = fmt.Errorf("unknown annex format %q", format)
+	}
+	if err != nil {
+		generationTotal.WithLabelValues(format, "error").Inc()
+		return nil, err
+	}
+	generationTotal.WithLabelValues(format, "success").Inc()
+	return buf.Bytes(), nil
+}
+
+// Bundle упаковывает файлы в ZIP архив
+func Bundle(files []File) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to zip: %w", f.Name, err)
+		}
+		if _, err := w.Write(f.Content); err != nil {
+			return nil, fmt.Errorf("failed to write %s to zip: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
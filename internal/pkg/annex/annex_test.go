@@ -0,0 +1,198 @@
+package annex
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testTable() Table {
+	m := Mapping{
+		Sheet: "Реестр: 2024",
+		Columns: []Column{
+			{Header: "№", Field: FieldRowNumber},
+			{Header: "ID", Field: "id"},
+			{Header: "Наименование", Field: "name"},
+		},
+	}
+	return m.Table([]map[string]interface{}{
+		{"id": float64(101), "name": "Отчёт <A&B>"},
+		{"id": "x-2", "name": "=HYPERLINK(\"http://evil\")"},
+		{"name": nil},
+	})
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestMapping_Table(t *testing.T) {
+	table := testTable()
+	if strings.Join(table.Headers, "|") != "№|ID|Наименование" {
+		t.Errorf("Unexpected headers: %v", table.Headers)
+	}
+	expected := [][]string{
+		{"1", "101", "Отчёт <A&B>"},
+		{"2", "x-2", "=HYPERLINK(\"http://evil\")"},
+		{"3", "", ""},
+	}
+	for i, row := range expected {
+		if strings.Join(table.Rows[i], "|") != strings.Join(row, "|") {
+			t.Errorf("Row %d: expected %v, got %v", i, row, table.Rows[i])
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	data, err := Encode(FormatXLSX, testTable())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	files := readZip(t, data)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		content, ok := files[name]
+		if !ok {
+			t.Fatalf("Missing part %s", name)
+		}
+		// Каждая часть должна быть корректным XML
+		dec := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Part %s is not valid XML: %v", name, err)
+			}
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="Реестр_ 2024"`) {
+		t.Errorf("Expected sanitized sheet name, got %s", files["xl/workbook.xml"])
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">№</t></is></c>`,
+		`<c r="C2" s="2" t="inlineStr"><is><t xml:space="preserve">Отчёт &lt;A&amp;B&gt;</t></is></c>`,
+		`<c r="B4" s="2"/>`,
+		`<autoFilter ref="A1:C4"/>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Expected sheet to contain %s", want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	data, err := Encode(FormatCSV, testTable())
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte(utf8BOM)) {
+		t.Fatal("Expected UTF-8 BOM")
+	}
+	r := csv.NewReader(bytes.NewReader(data[len(utf8BOM):]))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read csv: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %d", len(records))
+	}
+	if records[1][2] != "Отчёт <A&B>" {
+		t.Errorf("Unexpected value: %q", records[1][2])
+	}
+	if records[2][2] != `'=HYPERLINK("http://evil")` {
+		t.Errorf("Expected formula to be neutralized, got %q", records[2][2])
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := ParseFormats(" XLSX, csv,xlsx ,")
+	if err != nil || strings.Join(formats, ",") != "xlsx,csv" {
+		t.Errorf("Expected [xlsx csv], got %v, %v", formats, err)
+	}
+	if formats, err := ParseFormats(""); err != nil || len(formats) != 0 {
+		t.Errorf("Expected no formats, got %v, %v", formats, err)
+	}
+	if _, err := ParseFormats("xlsx,ods"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "template.json")
+	if err := os.WriteFile(path, []byte(`{"sheet":"S","columns":[{"header":"Инв. №","field":"invNumber"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMapping(path)
+	if err != nil || m.Sheet != "S" || len(m.Columns) != 1 || m.Columns[0].Field != "invNumber" {
+		t.Errorf("Unexpected mapping %+v, %v", m, err)
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, []byte(`{"columns":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMapping(empty); err == nil {
+		t.Error("Expected error for mapping without columns")
+	}
+}
+
+func TestBundle(t *testing.T) {
+	data, err := Bundle([]File{
+		{Name: "a.pdf", Content: []byte("%PDF")},
+		{Name: "a.xlsx", Content: []byte("xlsx")},
+	})
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	files := readZip(t, data)
+	if files["a.pdf"] != "%PDF" || files["a.xlsx"] != "xlsx" {
+		t.Errorf("Unexpected bundle contents: %v", files)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for i, want := range cases {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	cases := map[string]string{
+		"req-1_2.v3":       "req-1_2.v3",
+		"../../etc/passwd": "_.._etc_passwd",
+		`a"b`:              "a_b",
+		"req 7/Ж":          "req_7__",
+		"..":               "document",
+	}
+	for in, want := range cases {
+		if got := SafeName(in); got != want {
+			t.Errorf("SafeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package annex
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvDelimiter разделитель, который Excel с русской локалью открывает без мастера импорта
+const csvDelimiter = ';'
+
+// utf8BOM метка порядка байт, по которой Excel распознаёт UTF-8
+const utf8BOM = "\xef\xbb\xbf"
+
+// WriteCSV записывает таблицу в CSV (UTF-8 с BOM, разделитель ';')
+func WriteCSV(w io.Writer, t Table) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = csvDelimiter
+	cw.UseCRLF = true
+	if err := cw.Write(sanitizeCSVRow(t.Headers)); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	for _, row := range t.Rows {
+		if err := cw.Write(sanitizeCSVRow(row)); err != nil {
+			return fmt.Errorf("failed to write csv: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+// sanitizeCSVRow защищает от CSV-инъекций: значение, которое табличный редактор
+// принял бы за формулу, предваряется апострофом
+func sanitizeCSVRow(row []string) []string {
+	out := make([]string, len(row))
+	for i, value := range row {
+		if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		out[i] = value
+	}
+	return out
+}
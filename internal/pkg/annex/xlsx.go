@@ -0,0 +1,186 @@
+package annex
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Минимальная книга SpreadsheetML: один лист со строками inlineStr,
+// жирной строкой заголовков, закреплённой первой строкой и автофильтром.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment wrapText="1" vertical="top"/></xf></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+)
+
+// Стили ячеек из xlsxStyles
+const (
+	styleHeader = 1
+	styleBody   = 2
+)
+
+// Ограничения ширины колонки в символах
+const (
+	minColumnWidth = 8
+	maxColumnWidth = 60
+)
+
+// defaultSheetName имя листа, если в соответствии колонок оно не задано
+const defaultSheetName = "Реестр"
+
+// WriteXLSX записывает таблицу книгой XLSX с одним листом
+func WriteXLSX(w io.Writer, t Table) error {
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapeXML(sheetName(t.Sheet)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return fmt.Errorf("failed to create sheet: %w", err)
+	}
+	bw := bufio.NewWriter(sw)
+	writeSheet(bw, t)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to close xlsx: %w", err)
+	}
+	return nil
+}
+
+// writeSheet записывает xl/worksheets/sheet1.xml; ошибки записи возвращает Flush
+func writeSheet(w *bufio.Writer, t Table) {
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	w.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	if len(t.Headers) > 0 {
+		w.WriteString("<cols>")
+		for i, width := range columnWidths(t) {
+			fmt.Fprintf(w, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		w.WriteString("</cols>")
+	}
+
+	w.WriteString("<sheetData>")
+	writeRow(w, 1, t.Headers, styleHeader)
+	for i, row := range t.Rows {
+		writeRow(w, i+2, row, styleBody)
+	}
+	w.WriteString("</sheetData>")
+
+	if len(t.Headers) > 0 {
+		fmt.Fprintf(w, `<autoFilter ref="A1:%s%d"/>`, columnName(len(t.Headers)-1), len(t.Rows)+1)
+	}
+	w.WriteString("</worksheet>")
+}
+
+func writeRow(w *bufio.Writer, n int, cells []string, style int) {
+	fmt.Fprintf(w, `<row r="%d">`, n)
+	for i, value := range cells {
+		ref := columnName(i) + strconv.Itoa(n)
+		if value == "" {
+			fmt.Fprintf(w, `<c r="%s" s="%d"/>`, ref, style)
+			continue
+		}
+		fmt.Fprintf(w, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escapeXML(value))
+	}
+	w.WriteString("</row>")
+}
+
+// columnWidths ширина колонок по самому длинному значению в пределах minColumnWidth..maxColumnWidth
+func columnWidths(t Table) []int {
+	widths := make([]int, len(t.Headers))
+	measure := func(i int, value string) {
+		if i >= len(widths) {
+			return
+		}
+		if n := utf8.RuneCountInString(value) + 2; n > widths[i] {
+			widths[i] = n
+		}
+	}
+	for i, h := range t.Headers {
+		measure(i, h)
+	}
+	for _, row := range t.Rows {
+		for i, value := range row {
+			measure(i, value)
+		}
+	}
+	for i, width := range widths {
+		if width < minColumnWidth {
+			widths[i] = minColumnWidth
+		} else if width > maxColumnWidth {
+			widths[i] = maxColumnWidth
+		}
+	}
+	return widths
+}
+
+// columnName буквенное имя колонки по индексу с нуля: A..Z, AA..
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sheetName приводит имя листа к ограничениям Excel: до 31 символа без []:*?/\
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return defaultSheetName
+	}
+	if utf8.RuneCountInString(name) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	return name
+}
+
+// escapeXML экранирует текст; недопустимые в XML символы заменяются на U+FFFD
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
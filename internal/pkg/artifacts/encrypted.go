@@ -0,0 +1,153 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"pdf-service-go/internal/pkg/encryption"
+)
+
+// EncryptedStore шифрует артефакты перед записью во внутреннее хранилище и
+// прозрачно расшифровывает их при чтении. Незашифрованные артефакты (записанные
+// до включения шифрования) читаются как есть.
+type EncryptedStore struct {
+	inner   ArtifactStore
+	keyring *encryption.Keyring
+}
+
+// headReader реализуется хранилищами, умеющими читать начало объекта без загрузки целиком
+type headReader interface {
+	ReadHead(ctx context.Context, key string, n int64) ([]byte, string, error)
+}
+
+// NewEncryptedStore оборачивает хранилище шифрованием с ключами из keyring
+func NewEncryptedStore(inner ArtifactStore, keyring *encryption.Keyring) (*EncryptedStore, error) {
+	if !keyring.Enabled() {
+		return nil, encryption.ErrDisabled
+	}
+	return &EncryptedStore{inner: inner, keyring: keyring}, nil
+}
+
+// Backend возвращает имя внутреннего хранилища с пометкой о шифровании
+func (s *EncryptedStore) Backend() string {
+	return s.inner.Backend() + "+encrypted"
+}
+
+// Put шифрует содержимое активным ключом и сохраняет его
+func (s *EncryptedStore) Put(ctx context.Context, key string, r io.Reader, _ int64, contentType string) error {
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read artifact: %w", err)
+	}
+	data, err := s.keyring.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt artifact: %w", err)
+	}
+	return s.inner.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// Get читает и расшифровывает артефакт
+func (s *EncryptedStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := s.readRaw(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.keyring.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt artifact %s: %w", key, err)
+	}
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+// Delete удаляет артефакт из внутреннего хранилища
+func (s *EncryptedStore) Delete(ctx context.Context, key string) error {
+	return s.inner.Delete(ctx, key)
+}
+
+// List возвращает артефакты внутреннего хранилища (размеры — зашифрованных объектов)
+func (s *EncryptedStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return s.inner.List(ctx, prefix)
+}
+
+// Presign не поддерживается: прямая ссылка отдала бы зашифрованные данные,
+// поэтому артефакт отдаётся сервисом с расшифровкой
+func (s *EncryptedStore) Presign(_ context.Context, _ string, _ time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// Reencrypt перешифровывает активным ключом все артефакты с указанным префиксом,
+// зашифрованные старыми ключами или сохранённые без шифрования. Возвращает число перешифрованных объектов.
+// Ключ объекта определяется по заголовку конверта, поэтому объекты на активном ключе целиком не читаются.
+func (s *EncryptedStore) Reencrypt(ctx context.Context, prefix string) (int, error) {
+	objects, err := s.inner.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		count int
+		errs  []error
+	)
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		head, contentType, err := s.readHead(ctx, obj.Key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", obj.Key, err))
+			continue
+		}
+		if !s.keyring.NeedsRotation(head) {
+			continue
+		}
+		data, err := s.readRaw(ctx, obj.Key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", obj.Key, err))
+			continue
+		}
+		plaintext, err := s.keyring.Decrypt(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", obj.Key, err))
+			continue
+		}
+		if err := s.Put(ctx, obj.Key, bytes.NewReader(plaintext), int64(len(plaintext)), contentType); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", obj.Key, err))
+			continue
+		}
+		count++
+	}
+	return count, errors.Join(errs...)
+}
+
+// readHead читает заголовок конверта и Content-Type объекта. Хранилища без headReader
+// (локальное не хранит Content-Type) отдают поток, из которого читается только начало.
+func (s *EncryptedStore) readHead(ctx context.Context, key string) ([]byte, string, error) {
+	if hr, ok := s.inner.(headReader); ok {
+		return hr.ReadHead(ctx, key, encryption.MaxHeaderSize)
+	}
+	rc, err := s.inner.Get(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, encryption.MaxHeaderSize))
+	return data, "", err
+}
+
+func (s *EncryptedStore) readRaw(ctx context.Context, key string) ([]byte, error) {
+	rc, err := s.inner.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,132 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdf-service-go/internal/pkg/encryption"
+)
+
+func newTestKeyring(t *testing.T, active string, ids ...string) *encryption.Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for i, id := range ids {
+		keys[id] = bytes.Repeat([]byte{byte(i + 1)}, 32)
+	}
+	kr, err := encryption.NewKeyring(keys, active)
+	if err != nil {
+		t.Fatalf("Failed to create keyring: %v", err)
+	}
+	return kr
+}
+
+func readAll(t *testing.T, store ArtifactStore, key string) []byte {
+	t.Helper()
+	rc, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get %s failed: %v", key, err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	return data
+}
+
+func TestEncryptedStore_PutGet(t *testing.T) {
+	dir := t.TempDir()
+	inner, _ := NewLocalStore(dir)
+	store, err := NewEncryptedStore(inner, newTestKeyring(t, "k1", "k1"))
+	if err != nil {
+		t.Fatalf("Failed to create encrypted store: %v", err)
+	}
+	ctx := context.Background()
+
+	content := []byte(`{"inn":"7707083893"}`)
+	if err := store.Put(ctx, RequestKey("req_1"), bytes.NewReader(content), int64(len(content)), "application/json"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	raw, _ := os.ReadFile(filepath.Join(dir, "requests", "req_1.json"))
+	if !encryption.IsEncrypted(raw) || bytes.Contains(raw, []byte("7707083893")) {
+		t.Error("Expected artifact to be encrypted on disk")
+	}
+	if got := readAll(t, store, RequestKey("req_1")); !bytes.Equal(got, content) {
+		t.Errorf("Expected %q, got %q", content, got)
+	}
+	if _, err := store.Presign(ctx, RequestKey("req_1"), 0); !errors.Is(err, ErrPresignNotSupported) {
+		t.Errorf("Expected ErrPresignNotSupported, got %v", err)
+	}
+}
+
+func TestEncryptedStore_Reencrypt(t *testing.T) {
+	inner, _ := NewLocalStore(t.TempDir())
+	ctx := context.Background()
+
+	// Артефакт до включения шифрования
+	if err := inner.Put(ctx, RequestKey("legacy"), bytes.NewReader([]byte("legacy")), 6, ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	oldStore, _ := NewEncryptedStore(inner, newTestKeyring(t, "k1", "k1"))
+	if err := oldStore.Put(ctx, ResultKey("old"), bytes.NewReader([]byte("old")), 3, ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	rotated := newTestKeyring(t, "k2", "k1", "k2")
+	store, _ := NewEncryptedStore(inner, rotated)
+	n, err := store.Reencrypt(ctx, "")
+	if err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 reencrypted artifacts, got %d", n)
+	}
+
+	for key, want := range map[string]string{RequestKey("legacy"): "legacy", ResultKey("old"): "old"} {
+		raw := readAll(t, inner, key)
+		if id, _ := encryption.KeyID(raw); id != "k2" {
+			t.Errorf("Expected %s to be encrypted with k2, got %q", key, id)
+		}
+		if got := readAll(t, store, key); string(got) != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	if n, _ := store.Reencrypt(ctx, ""); n != 0 {
+		t.Errorf("Expected nothing to reencrypt on second pass, got %d", n)
+	}
+}
+
+func TestEncryptedStore_ReencryptReadsHeaderOnly(t *testing.T) {
+	inner, fake := newTestS3Store(t)
+	ctx := context.Background()
+
+	oldStore, _ := NewEncryptedStore(inner, newTestKeyring(t, "k1", "k1"))
+	content := bytes.Repeat([]byte("%PDF"), 1024)
+	if err := oldStore.Put(ctx, ResultKey("r1"), bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	store, _ := NewEncryptedStore(inner, newTestKeyring(t, "k2", "k1", "k2"))
+	if n, err := store.Reencrypt(ctx, ""); err != nil || n != 1 {
+		t.Fatalf("Expected 1 reencrypted artifact, got %d, %v", n, err)
+	}
+	if ct := fake.types["pdf-service/"+ResultKey("r1")]; ct != "application/pdf" {
+		t.Errorf("Expected content type to be preserved, got %q", ct)
+	}
+
+	// Объект уже на активном ключе: читается только заголовок конверта
+	fake.fullGets = 0
+	if n, err := store.Reencrypt(ctx, ""); err != nil || n != 0 {
+		t.Fatalf("Expected nothing to reencrypt, got %d, %v", n, err)
+	}
+	if fake.fullGets != 0 {
+		t.Errorf("Expected only ranged reads, got %d full downloads", fake.fullGets)
+	}
+	if got := readAll(t, store, ResultKey("r1")); !bytes.Equal(got, content) {
+		t.Error("Unexpected content after reencryption")
+	}
+}
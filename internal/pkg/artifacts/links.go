@@ -0,0 +1,189 @@
+package artifacts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ScopeArchiveRead область доступа ссылок на скачивание архивных артефактов
+const ScopeArchiveRead = "archive:read"
+
+// DownloadPathPrefix префикс маршрута скачивания артефактов
+const DownloadPathPrefix = "/files/"
+
+var (
+	// ErrLinkExpired возвращается для ссылок с истёкшим сроком действия
+	ErrLinkExpired = errors.New("download link expired")
+	// ErrLinkInvalid возвращается для ссылок без подписи или с неверной подписью
+	ErrLinkInvalid = errors.New("download link signature is invalid")
+)
+
+// LinkSigner выдаёт и проверяет подписанные HMAC-SHA256 ссылки на скачивание артефактов.
+// Подпись покрывает ключ, время истечения и область доступа.
+type LinkSigner struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewLinkSigner создаёт подписывающий объект. ttl — срок действия ссылок по умолчанию
+func NewLinkSigner(secret []byte, ttl time.Duration) (*LinkSigner, error) {
+	if len(secret) < 16 {
+		return nil, fmt.Errorf("download link secret must be at least 16 bytes")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("download link ttl must be positive")
+	}
+	return &LinkSigner{secret: append([]byte(nil), secret...), ttl: ttl, now: time.Now}, nil
+}
+
+// LinkSignerFromEnv создаёт подписывающий объект из ARTIFACTS_LINK_SECRET и ARTIFACTS_LINK_TTL.
+// Если секрет не задан, генерируется случайный — ссылки будут действительны только на этом поде.
+// Второе значение сообщает, был ли секрет сгенерирован.
+func LinkSignerFromEnv() (*LinkSigner, bool, error) {
+	ttl := 15 * time.Minute
+	if value := os.Getenv("ARTIFACTS_LINK_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid ARTIFACTS_LINK_TTL: %w", err)
+		}
+		ttl = parsed
+	}
+
+	secret := []byte(os.Getenv("ARTIFACTS_LINK_SECRET"))
+	generated := false
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, false, fmt.Errorf("failed to generate download link secret: %w", err)
+		}
+		generated = true
+	}
+	signer, err := NewLinkSigner(secret, ttl)
+	return signer, generated, err
+}
+
+// TTL возвращает срок действия ссылок по умолчанию
+func (s *LinkSigner) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign возвращает относительный URL скачивания артефакта с подписью, сроком действия ttl и областью scope
+func (s *LinkSigner) Sign(key, scope string, ttl time.Duration) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	expires := s.now().Add(ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("scope", scope)
+	query.Set("signature", s.signature(cleaned, expires, scope))
+	return DownloadPathPrefix + escapePath(cleaned) + "?" + query.Encode(), nil
+}
+
+// SignKey подписывает ссылку на артефакт со стандартными TTL и областью archive:read
+func (s *LinkSigner) SignKey(key string) (string, error) {
+	return s.Sign(key, ScopeArchiveRead, 0)
+}
+
+// Verify проверяет подпись, срок действия и область ссылки. Возвращает нормализованный ключ
+func (s *LinkSigner) Verify(key string, query url.Values, requiredScope string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	signature := query.Get("signature")
+	scope := query.Get("scope")
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if signature == "" || err != nil {
+		return "", ErrLinkInvalid
+	}
+
+	expected := s.signature(cleaned, expires, scope)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", ErrLinkInvalid
+	}
+	if scope != requiredScope {
+		return "", ErrLinkInvalid
+	}
+	if s.now().Unix() > expires {
+		return "", ErrLinkExpired
+	}
+	return cleaned, nil
+}
+
+func (s *LinkSigner) signature(key string, expires int64, scope string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{key, strconv.FormatInt(expires, 10), scope}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	defaultSigner   *LinkSigner
+	defaultSignerMu sync.Mutex
+)
+
+// DefaultSigner возвращает глобальный подписывающий объект, создавая его из окружения при первом обращении
+func DefaultSigner() *LinkSigner {
+	defaultSignerMu.Lock()
+	defer defaultSignerMu.Unlock()
+	if defaultSigner != nil {
+		return defaultSigner
+	}
+	signer, err := newDefaultSigner()
+	if err != nil {
+		log().Warn("Failed to configure download links, using defaults", zap.Error(err))
+		secret := make([]byte, 32)
+		_, _ = rand.Read(secret)
+		signer = &LinkSigner{secret: secret, ttl: 15 * time.Minute, now: time.Now}
+	}
+	defaultSigner = signer
+	return defaultSigner
+}
+
+// InitDefaultSigner создаёт глобальный подписывающий объект из окружения при старте сервиса.
+// В отличие от DefaultSigner ошибка конфигурации возвращается, а не заменяется настройками по умолчанию.
+func InitDefaultSigner() error {
+	signer, err := newDefaultSigner()
+	if err != nil {
+		return err
+	}
+	SetDefaultSigner(signer)
+	return nil
+}
+
+// newDefaultSigner читает настройки из окружения и предупреждает о секрете, сгенерированном для экземпляра
+func newDefaultSigner() (*LinkSigner, error) {
+	signer, generated, err := LinkSignerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if generated {
+		log().Warn("ARTIFACTS_LINK_SECRET is not set; download links are valid only on this instance")
+	}
+	return signer, nil
+}
+
+// SetDefaultSigner заменяет глобальный подписывающий объект (используется при старте и в тестах)
+func SetDefaultSigner(signer *LinkSigner) {
+	defaultSignerMu.Lock()
+	defer defaultSignerMu.Unlock()
+	defaultSigner = signer
+}
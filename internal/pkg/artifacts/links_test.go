@@ -0,0 +1,101 @@
+package artifacts
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSigner(t *testing.T, now time.Time) *LinkSigner {
+	t.Helper()
+	signer, err := NewLinkSigner([]byte("0123456789abcdef0123456789abcdef"), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	signer.now = func() time.Time { return now }
+	return signer
+}
+
+func parseLink(t *testing.T, link string) (string, url.Values) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Failed to parse link %q: %v", link, err)
+	}
+	if !strings.HasPrefix(u.Path, DownloadPathPrefix) {
+		t.Fatalf("Expected link under %s, got %s", DownloadPathPrefix, u.Path)
+	}
+	return strings.TrimPrefix(u.Path, DownloadPathPrefix), u.Query()
+}
+
+func TestLinkSigner_SignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(t, now)
+
+	link, err := signer.SignKey(ResultKey("req_1"))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	key, query := parseLink(t, link)
+
+	got, err := signer.Verify(key, query, ScopeArchiveRead)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if got != ResultKey("req_1") {
+		t.Errorf("Expected key %q, got %q", ResultKey("req_1"), got)
+	}
+}
+
+func TestLinkSigner_Rejects(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := newTestSigner(t, now)
+	link, _ := signer.Sign(RequestKey("req_1"), ScopeArchiveRead, time.Minute)
+	key, query := parseLink(t, link)
+
+	// Подмена ключа
+	if _, err := signer.Verify(RequestKey("req_2"), query, ScopeArchiveRead); !errors.Is(err, ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for another key, got %v", err)
+	}
+
+	// Продление срока действия
+	forged := url.Values{}
+	for k, v := range query {
+		forged[k] = v
+	}
+	forged.Set("expires", "9999999999")
+	if _, err := signer.Verify(key, forged, ScopeArchiveRead); !errors.Is(err, ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for forged expiry, got %v", err)
+	}
+
+	// Другая область доступа
+	if _, err := signer.Verify(key, query, "archive:admin"); !errors.Is(err, ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for wrong scope, got %v", err)
+	}
+
+	// Без подписи
+	if _, err := signer.Verify(key, url.Values{}, ScopeArchiveRead); !errors.Is(err, ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid without signature, got %v", err)
+	}
+
+	// Истёкшая ссылка
+	signer.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if _, err := signer.Verify(key, query, ScopeArchiveRead); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("Expected ErrLinkExpired, got %v", err)
+	}
+
+	// Другой секрет
+	other, _ := NewLinkSigner([]byte("fedcba9876543210fedcba9876543210"), time.Minute)
+	other.now = func() time.Time { return now }
+	if _, err := other.Verify(key, query, ScopeArchiveRead); !errors.Is(err, ErrLinkInvalid) {
+		t.Errorf("Expected ErrLinkInvalid for another secret, got %v", err)
+	}
+}
+
+func TestNewLinkSigner_ShortSecret(t *testing.T) {
+	if _, err := NewLinkSigner([]byte("short"), time.Minute); err == nil {
+		t.Error("Expected error for short secret")
+	}
+}
@@ -0,0 +1,156 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalStore хранит артефакты в директории на локальном диске
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore создаёт локальное хранилище с корнем в dir
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("artifact store directory is not set")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	return &LocalStore{root: dir}, nil
+}
+
+// Backend возвращает имя реализации
+func (s *LocalStore) Backend() string {
+	return "local"
+}
+
+// Put атомарно записывает артефакт через временный файл
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, _ int64, _ string) error {
+	target, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp artifact file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close artifact file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to chmod artifact file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to move artifact into place: %w", err)
+	}
+	return nil
+}
+
+// Get открывает артефакт на чтение
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete удаляет артефакт с диска
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	target, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List обходит директорию и возвращает артефакты с указанным префиксом
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) {
+				return nil
+			}
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		result = append(result, ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// Presign не поддерживается локальным диском: артефакты отдаются самим сервисом
+func (s *LocalStore) Presign(_ context.Context, _ string, _ time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// resolve переводит ключ в путь на диске. Абсолютные пути внутри корня
+// (старые записи в БД) переводятся в относительные ключи.
+func (s *LocalStore) resolve(key string) (string, error) {
+	if filepath.IsAbs(key) {
+		if rel, err := filepath.Rel(s.root, key); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			key = rel
+		}
+	}
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
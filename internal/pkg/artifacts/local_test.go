@@ -0,0 +1,129 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	content := []byte(`{"id":"1"}`)
+	if err := store.Put(ctx, RequestKey("req_1"), bytes.NewReader(content), int64(len(content)), "application/json"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	rc, err := store.Get(ctx, RequestKey("req_1"))
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Expected %q, got %q", content, got)
+	}
+
+	if err := store.Delete(ctx, RequestKey("req_1")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(ctx, RequestKey("req_1")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	// Повторное удаление не считается ошибкой
+	if err := store.Delete(ctx, RequestKey("req_1")); err != nil {
+		t.Errorf("Expected no error deleting missing artifact, got %v", err)
+	}
+}
+
+func TestLocalStore_List(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{RequestKey("a"), RequestKey("b"), ResultKey("a")} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), -1, ""); err != nil {
+			t.Fatalf("Put %s failed: %v", key, err)
+		}
+	}
+
+	objects, err := store.List(ctx, RequestsPrefix)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("Expected 2 request artifacts, got %d", len(objects))
+	}
+	if objects[0].Key != "requests/a.json" || objects[0].Size != 1 {
+		t.Errorf("Unexpected first object: %+v", objects[0])
+	}
+}
+
+func TestLocalStore_RejectsTraversal(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"", "../escape", "requests/../../escape"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestLocalStore_LegacyAbsolutePath(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	legacy := filepath.Join(dir, "results", "old.pdf")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("%PDF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete(context.Background(), legacy); err != nil {
+		t.Fatalf("Delete by legacy path failed: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("Expected legacy file to be removed, stat err: %v", err)
+	}
+}
+
+func TestKeyFromPath(t *testing.T) {
+	t.Setenv("ARTIFACTS_DIR", "/data/artifacts")
+
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"/data/artifacts/results/r.pdf", "results/r.pdf", true},
+		{"results/r.pdf", "results/r.pdf", true},
+		{"/tmp/docx-1.docx.timings.json", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := KeyFromPath(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("KeyFromPath(%q) = (%q, %v), want (%q, %v)", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
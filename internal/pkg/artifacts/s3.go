@@ -0,0 +1,454 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3EmptyBodyHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	s3MaxPresignTTL  = 7 * 24 * time.Hour
+	s3ErrorBodyLimit = 1024
+)
+
+// S3Config содержит настройки S3-совместимого хранилища (AWS S3, MinIO, Ceph RGW)
+type S3Config struct {
+	// Endpoint адрес сервиса, например "http://minio:9000" или "https://s3.amazonaws.com"
+	Endpoint string
+	// Region регион для подписи запросов
+	Region string
+	// Bucket имя бакета
+	Bucket string
+	// Prefix общий префикс ключей внутри бакета
+	Prefix string
+	// AccessKey и SecretKey учётные данные
+	AccessKey string
+	SecretKey string
+	// UsePathStyle адресация вида endpoint/bucket/key (нужна MinIO); иначе bucket.endpoint/key
+	UsePathStyle bool
+	// HTTPClient клиент для запросов; по умолчанию создаётся с таймаутом 60s
+	HTTPClient *http.Client
+}
+
+// S3Store хранит артефакты в S3-совместимом объектном хранилище.
+// Запросы подписываются AWS Signature V4 без внешних зависимостей.
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store создаёт S3 хранилище
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 artifact store requires endpoint and bucket")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("s3 artifact store requires access and secret keys")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Prefix != "" && !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &S3Store{
+		config:   cfg,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Backend возвращает имя реализации
+func (s *S3Store) Backend() string {
+	return "s3"
+}
+
+// Put загружает объект в бакет
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	// S3 требует Content-Length для обычного PUT, поэтому тело неизвестного размера буферизуем
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read artifact: %w", err)
+		}
+		r = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, s3UnsignedBody)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 put failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3StatusError("put", resp)
+	}
+	return nil
+}
+
+// Get скачивает объект из бакета
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, s3EmptyBodyHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 get failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s3StatusError("get", resp)
+	}
+	return resp.Body, nil
+}
+
+// ReadHead читает первые n байт объекта ranged GET запросом и возвращает их вместе с Content-Type объекта
+func (s *S3Store) ReadHead(ctx context.Context, key string, n int64) ([]byte, string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	s.sign(req, s3EmptyBodyHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("s3 get failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Пустой объект
+		return nil, "", nil
+	case http.StatusNotFound:
+		return nil, "", ErrNotFound
+	default:
+		return nil, "", s3StatusError("get", resp)
+	}
+	// Хранилище без поддержки Range вернёт объект целиком — читаем только начало
+	data, err := io.ReadAll(io.LimitReader(resp.Body, n))
+	if err != nil {
+		return nil, "", fmt.Errorf("s3 get failed: %w", err)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// Delete удаляет объект из бакета
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, s3EmptyBodyHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 delete failed: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3StatusError("delete", resp)
+	}
+}
+
+// listBucketResult соответствует ответу ListObjectsV2
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List возвращает объекты с указанным префиксом, проходя по всем страницам ListObjectsV2
+func (s *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var result []ObjectInfo
+	token := ""
+	for {
+		u := s.bucketURL()
+		params := map[string]string{
+			"list-type": "2",
+			"prefix":    s.config.Prefix + prefix,
+		}
+		if token != "" {
+			params["continuation-token"] = token
+		}
+		u.RawQuery = canonicalQuery(params)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		s.sign(req, s3EmptyBodyHash)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("s3 list failed: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3StatusError("list", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode s3 list response: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			result = append(result, ObjectInfo{
+				Key:          strings.TrimPrefix(obj.Key, s.config.Prefix),
+				Size:         obj.Size,
+				LastModified: obj.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	return result, nil
+}
+
+// Presign возвращает подписанную ссылку на GET объекта, действующую ttl
+func (s *S3Store) Presign(_ context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > s3MaxPresignTTL {
+		return "", fmt.Errorf("presign ttl must be within (0, %s]", s3MaxPresignTTL)
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format(s3TimeFormat)
+	scope := s.scope(now)
+
+	params := map[string]string{
+		"X-Amz-Algorithm":     s3Algorithm,
+		"X-Amz-Credential":    s.config.AccessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.FormatInt(int64(ttl/time.Second), 10),
+		"X-Amz-SignedHeaders": "host",
+	}
+	query := canonicalQuery(params)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		query,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+
+	signature := s.signature(now, canonicalRequest)
+	u.RawQuery = query + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// sign добавляет к запросу заголовки авторизации Signature V4
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+// signature вычисляет подпись канонического запроса
+func (s *S3Store) signature(now time.Time, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope возвращает область действия подписи
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+}
+
+// bucketURL возвращает адрес бакета с учётом стиля адресации
+func (s *S3Store) bucketURL() *url.URL {
+	u := &url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host}
+	base := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.config.UsePathStyle {
+		u.Path = base + "/" + s.config.Bucket + "/"
+	} else {
+		u.Host = s.config.Bucket + "." + s.endpoint.Host
+		u.Path = base + "/"
+	}
+	u.RawPath = escapePath(u.Path)
+	return u
+}
+
+// objectURL возвращает адрес объекта
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	u := s.bucketURL()
+	u.Path += s.config.Prefix + cleaned
+	u.RawPath = escapePath(u.Path)
+	return u, nil
+}
+
+// canonicalQuery кодирует параметры в каноническом для Signature V4 виде
+func canonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, uriEscape(k, true)+"="+uriEscape(params[k], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath кодирует путь, сохраняя разделители
+func escapePath(p string) string {
+	return uriEscape(p, false)
+}
+
+// uriEscape кодирует строку по правилам AWS: без изменений остаются только A-Z a-z 0-9 - _ . ~
+func uriEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3StatusError формирует ошибку по неуспешному ответу S3
+func s3StatusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyLimit))
+	return fmt.Errorf("s3 %s failed with status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,192 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"pdf-service-go/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound возвращается, когда артефакт с указанным ключом отсутствует
+	ErrNotFound = errors.New("artifact not found")
+	// ErrInvalidKey возвращается для пустых ключей и ключей, выходящих за пределы хранилища
+	ErrInvalidKey = errors.New("invalid artifact key")
+	// ErrPresignNotSupported возвращается хранилищами, которые не умеют выдавать прямые ссылки
+	ErrPresignNotSupported = errors.New("presigned urls are not supported by artifact store")
+)
+
+// Префиксы ключей для разных видов артефактов
+const (
+	RequestsPrefix = "requests/"
+	ResultsPrefix  = "results/"
+)
+
+// ObjectInfo описывает сохранённый артефакт
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ArtifactStore абстрагирует хранилище архивных артефактов (тела запросов, PDF результаты)
+type ArtifactStore interface {
+	// Put сохраняет содержимое под указанным ключом. size может быть -1, если размер неизвестен
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает артефакт на чтение. Вызывающий обязан закрыть ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет артефакт. Отсутствие артефакта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// List возвращает артефакты, ключи которых начинаются с prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Presign возвращает временную прямую ссылку на артефакт
+	Presign(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Backend возвращает имя реализации для логов и метрик
+	Backend() string
+}
+
+// Config содержит настройки хранилища артефактов
+type Config struct {
+	// Backend тип хранилища: "local" или "s3"
+	Backend string
+	// Dir корневая директория локального хранилища
+	Dir string
+	// S3 настройки S3-совместимого хранилища
+	S3 S3Config
+}
+
+// ConfigFromEnv читает конфигурацию хранилища из переменных окружения
+func ConfigFromEnv() Config {
+	return Config{
+		Backend: strings.ToLower(getEnvWithDefault("ARTIFACTS_STORE", "local")),
+		Dir:     BaseDir(),
+		S3: S3Config{
+			Endpoint:     os.Getenv("ARTIFACTS_S3_ENDPOINT"),
+			Region:       getEnvWithDefault("ARTIFACTS_S3_REGION", "us-east-1"),
+			Bucket:       os.Getenv("ARTIFACTS_S3_BUCKET"),
+			Prefix:       os.Getenv("ARTIFACTS_S3_PREFIX"),
+			AccessKey:    os.Getenv("ARTIFACTS_S3_ACCESS_KEY"),
+			SecretKey:    os.Getenv("ARTIFACTS_S3_SECRET_KEY"),
+			UsePathStyle: getEnvWithDefault("ARTIFACTS_S3_PATH_STYLE", "true") != "false",
+		},
+	}
+}
+
+// New создаёт хранилище по конфигурации
+func New(cfg Config) (ArtifactStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStore(cfg.Dir)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown artifact store backend %q", cfg.Backend)
+	}
+}
+
+var (
+	defaultStore ArtifactStore
+	defaultMu    sync.Mutex
+)
+
+// Default возвращает глобальное хранилище, создавая его из окружения при первом обращении.
+// Если сконфигурированное хранилище создать не удалось, используется локальный диск.
+func Default() ArtifactStore {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStore != nil {
+		return defaultStore
+	}
+	store, err := New(ConfigFromEnv())
+	if err != nil {
+		log().Warn("Failed to create artifact store, falling back to local disk", zap.Error(err))
+		store = &LocalStore{root: BaseDir()}
+	}
+	defaultStore = store
+	return defaultStore
+}
+
+// SetDefault заменяет глобальное хранилище (используется при старте и в тестах)
+func SetDefault(store ArtifactStore) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = store
+}
+
+// log возвращает логгер сервиса или пустой логгер, если он не инициализирован (тесты)
+func log() *zap.Logger {
+	if logger.Log == nil {
+		return zap.NewNop()
+	}
+	return logger.Log
+}
+
+// BaseDir возвращает базовую директорию локальных артефактов
+func BaseDir() string {
+	return getEnvWithDefault("ARTIFACTS_DIR", "/app/data/artifacts")
+}
+
+// RequestKey возвращает ключ для сохранённого тела запроса
+func RequestKey(requestID string) string {
+	return RequestsPrefix + requestID + ".json"
+}
+
+// ResultKey возвращает ключ для PDF результата запроса
+func ResultKey(requestID string) string {
+	return ResultsPrefix + requestID + ".pdf"
+}
+
+// AnnexKey возвращает ключ для табличного приложения запроса в указанном формате (xlsx, csv)
+func AnnexKey(requestID, format string) string {
+	return ResultsPrefix + requestID + ".annex." + format
+}
+
+// KeyFromPath приводит значение из БД к ключу хранилища.
+// Старые записи содержат абсолютные пути внутри ARTIFACTS_DIR — от них отрезается базовая директория.
+// Возвращает false для абсолютных путей вне хранилища (например, временных файлов).
+func KeyFromPath(p string) (string, bool) {
+	p = strings.ReplaceAll(p, "\\", "/")
+	base := strings.TrimSuffix(strings.ReplaceAll(BaseDir(), "\\", "/"), "/")
+	if base != "" && strings.HasPrefix(p, base+"/") {
+		return strings.TrimPrefix(p, base+"/"), true
+	}
+	if strings.HasPrefix(p, "/") || p == "" {
+		return "", false
+	}
+	return p, true
+}
+
+// cleanKey нормализует ключ и запрещает выход за пределы хранилища
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// getEnvWithDefault возвращает значение переменной окружения или значение по умолчанию
+func getEnvWithDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// apiKeyPrefix префикс выдаваемых API ключей, упрощает поиск утёкших ключей
+const apiKeyPrefix = "pdfs_"
+
+// APIKey описывает выданный API ключ. Сам ключ не хранится — только его хеш
+type APIKey struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Prefix     string            `json:"prefix"`
+	Scopes     []string          `json:"scopes"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time        `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time        `json:"last_used_at,omitempty"`
+}
+
+// Active проверяет, что ключ не отозван и не просрочен
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// KeyStore хранит API ключи (реализация на PostgreSQL — statistics.APIKeyStore)
+type KeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey, hash string) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) error
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+// GenerateAPIKey генерирует новый API ключ и его идентификатор
+func GenerateAPIKey() (plaintext, id string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key id: %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), "key_" + hex.EncodeToString(idBytes), nil
+}
+
+// HashAPIKey возвращает HMAC-SHA256 ключа с pepper. Ключи высокоэнтропийные, поэтому медленный KDF не нужен
+func HashAPIKey(plaintext, pepper string) string {
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(plaintext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// displayPrefix возвращает начало ключа для отображения в списке ключей
+func displayPrefix(plaintext string) string {
+	if len(plaintext) > 12 {
+		return plaintext[:12]
+	}
+	return plaintext
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Области доступа (scopes), проверяемые на группах маршрутов
+const (
+	ScopeGenerate      = "generate"
+	ScopeArchiveRead   = "archive:read"
+	ScopeArchiveAdmin  = "archive:admin"
+	ScopeErrorsRead    = "errors:read"
+	ScopeKeysAdmin     = "keys:admin"
+	ScopeBreakersAdmin = "breakers:admin"
+)
+
+// AllScopes перечень всех известных областей доступа
+var AllScopes = []string{ScopeGenerate, ScopeArchiveRead, ScopeArchiveAdmin, ScopeErrorsRead, ScopeKeysAdmin, ScopeBreakersAdmin}
+
+// Способы аутентификации
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodBootstrap = "bootstrap"
+)
+
+var (
+	// ErrNoCredentials возвращается, когда запрос не содержит учётных данных
+	ErrNoCredentials = errors.New("no credentials provided")
+	// ErrInvalidCredentials возвращается для неизвестных, отозванных или просроченных ключей и токенов
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrKeyNotFound возвращается хранилищем, когда ключ не найден
+	ErrKeyNotFound = errors.New("api key not found")
+)
+
+// Principal описывает аутентифицированного клиента
+type Principal struct {
+	// ID идентификатор API ключа или subject JWT
+	ID string `json:"id"`
+	// Name человекочитаемое имя клиента
+	Name string `json:"name"`
+	// Method способ аутентификации: api_key, jwt или bootstrap
+	Method string `json:"method"`
+	// Scopes выданные области доступа
+	Scopes []string `json:"scopes"`
+	// Attributes дополнительные атрибуты клиента (из API ключа или claims JWT)
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// HasScope проверяет наличие области доступа. Область archive:admin включает archive:read
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+		if s == ScopeArchiveAdmin && scope == ScopeArchiveRead {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidScope проверяет, что область доступа известна
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладёт клиента в контекст
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает клиента из контекста
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Config содержит настройки аутентификации
+type Config struct {
+	// Enabled включает проверку учётных данных на защищённых маршрутах
+	Enabled bool
+	// APIKeyPepper секрет, подмешиваемый в хеш API ключей
+	APIKeyPepper string
+	// BootstrapAPIKey статический ключ со всеми областями доступа для первоначальной настройки
+	BootstrapAPIKey string
+	// KeyCacheTTL время кэширования результатов проверки API ключей
+	KeyCacheTTL time.Duration
+	// JWKSFile путь к файлу JWKS
+	JWKSFile string
+	// JWKSURL адрес JWKS
+	JWKSURL string
+	// JWKSRefreshInterval интервал обновления JWKS
+	JWKSRefreshInterval time.Duration
+	// JWTIssuer ожидаемый iss (пусто — не проверяется)
+	JWTIssuer string
+	// JWTAudience ожидаемый aud (пусто — не проверяется)
+	JWTAudience string
+}
+
+// ConfigFromEnv читает настройки аутентификации из окружения
+func ConfigFromEnv() Config {
+	return Config{
+		Enabled:             getEnvBoolWithDefault("AUTH_ENABLED", false),
+		APIKeyPepper:        os.Getenv("AUTH_API_KEY_PEPPER"),
+		BootstrapAPIKey:     os.Getenv("AUTH_BOOTSTRAP_API_KEY"),
+		KeyCacheTTL:         getEnvDurationWithDefault("AUTH_KEY_CACHE_TTL", 30*time.Second),
+		JWKSFile:            os.Getenv("AUTH_JWKS_FILE"),
+		JWKSURL:             os.Getenv("AUTH_JWKS_URL"),
+		JWKSRefreshInterval: getEnvDurationWithDefault("AUTH_JWKS_REFRESH_INTERVAL", 10*time.Minute),
+		JWTIssuer:           os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience:         os.Getenv("AUTH_JWT_AUDIENCE"),
+	}
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBoolWithDefault возвращает булево значение переменной окружения или значение по умолчанию
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieName имя cookie с учётными данными для веб-интерфейса (дашборды)
+const CookieName = "pdf_service_token"
+
+// Authenticator проверяет учётные данные запроса: API ключи из хранилища, JWT и bootstrap ключ
+type Authenticator struct {
+	enabled       bool
+	keys          KeyStore
+	jwt           *JWTVerifier
+	pepper        string
+	bootstrapHash string
+	cacheTTL      time.Duration
+	now           func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+type cachedKey struct {
+	key      *APIKey
+	cachedAt time.Time
+}
+
+// NewAuthenticator создаёт аутентификатор. keys и jwt могут быть nil, если соответствующий способ не используется
+func NewAuthenticator(cfg Config, keys KeyStore, jwt *JWTVerifier) *Authenticator {
+	a := &Authenticator{
+		enabled:  cfg.Enabled,
+		keys:     keys,
+		jwt:      jwt,
+		pepper:   cfg.APIKeyPepper,
+		cacheTTL: cfg.KeyCacheTTL,
+		now:      time.Now,
+		cache:    make(map[string]cachedKey),
+	}
+	if cfg.BootstrapAPIKey != "" {
+		a.bootstrapHash = HashAPIKey(cfg.BootstrapAPIKey, cfg.APIKeyPepper)
+	}
+	return a
+}
+
+// NewAuthenticatorFromConfig создаёт аутентификатор, настраивая проверку JWT по JWKS из конфигурации
+func NewAuthenticatorFromConfig(cfg Config, keys KeyStore) *Authenticator {
+	var verifier *JWTVerifier
+	switch {
+	case cfg.JWKSFile != "":
+		verifier = NewJWTVerifier(NewJWKSFromFile(cfg.JWKSFile, cfg.JWKSRefreshInterval), cfg.JWTIssuer, cfg.JWTAudience)
+	case cfg.JWKSURL != "":
+		verifier = NewJWTVerifier(NewJWKSFromURL(cfg.JWKSURL, nil, cfg.JWKSRefreshInterval), cfg.JWTIssuer, cfg.JWTAudience)
+	}
+	return NewAuthenticator(cfg, keys, verifier)
+}
+
+// Enabled возвращает true, если проверка учётных данных включена
+func (a *Authenticator) Enabled() bool {
+	return a != nil && a.enabled
+}
+
+// JWTEnabled возвращает true, если настроена проверка JWT
+func (a *Authenticator) JWTEnabled() bool {
+	return a != nil && a.jwt != nil
+}
+
+// Credential извлекает учётные данные из запроса: Authorization: Bearer, X-API-Key или cookie
+func Credential(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if scheme, token, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if cookie, err := r.Cookie(CookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// Authenticate проверяет учётные данные запроса
+func (a *Authenticator) Authenticate(ctx context.Context, r *http.Request) (*Principal, error) {
+	credential := Credential(r)
+	if credential == "" {
+		return nil, ErrNoCredentials
+	}
+	return a.AuthenticateCredential(ctx, credential)
+}
+
+// AuthenticateCredential проверяет API ключ или JWT
+func (a *Authenticator) AuthenticateCredential(ctx context.Context, credential string) (*Principal, error) {
+	if isJWT(credential) {
+		if a.jwt == nil {
+			return nil, ErrInvalidCredentials
+		}
+		principal, err := a.jwt.Verify(ctx, credential)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		return principal, nil
+	}
+
+	hash := HashAPIKey(credential, a.pepper)
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return &Principal{ID: "bootstrap", Name: "bootstrap", Method: MethodBootstrap, Scopes: append([]string(nil), AllScopes...)}, nil
+	}
+
+	key, err := a.lookupKey(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(a.now()) {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		ID:         key.ID,
+		Name:       key.Name,
+		Method:     MethodAPIKey,
+		Scopes:     append([]string(nil), key.Scopes...),
+		Attributes: key.Attributes,
+	}, nil
+}
+
+// lookupKey ищет ключ по хешу с кэшированием, чтобы не обращаться к БД на каждый запрос
+func (a *Authenticator) lookupKey(ctx context.Context, hash string) (*APIKey, error) {
+	now := a.now()
+	a.mu.Lock()
+	if entry, ok := a.cache[hash]; ok && now.Sub(entry.cachedAt) < a.cacheTTL {
+		a.mu.Unlock()
+		if entry.key == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return entry.key, nil
+	}
+	a.mu.Unlock()
+
+	if a.keys == nil {
+		return nil, ErrInvalidCredentials
+	}
+	key, err := a.keys.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			a.storeCache(hash, nil, now)
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	a.storeCache(hash, key, now)
+
+	// Отметка использования обновляется не чаще одного раза за время жизни кэша
+	go func(id string) {
+		touchCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = a.keys.TouchAPIKey(touchCtx, id, now)
+	}(key.ID)
+	return key, nil
+}
+
+func (a *Authenticator) storeCache(hash string, key *APIKey, now time.Time) {
+	if a.cacheTTL <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Ограничиваем рост кэша при переборе ключей
+	if len(a.cache) > 10000 {
+		a.cache = make(map[string]cachedKey)
+	}
+	a.cache[hash] = cachedKey{key: key, cachedAt: now}
+}
+
+// IssueAPIKey создаёт новый API ключ. Открытый ключ возвращается только один раз
+func (a *Authenticator) IssueAPIKey(ctx context.Context, name string, scopes []string, attributes map[string]string, ttl time.Duration) (string, *APIKey, error) {
+	if a.keys == nil {
+		return "", nil, fmt.Errorf("api key store is not configured")
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("name is required")
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, s := range scopes {
+		if !IsValidScope(s) {
+			return "", nil, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+
+	plaintext, id, err := GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	now := a.now().UTC()
+	key := &APIKey{
+		ID:         id,
+		Name:       name,
+		Prefix:     displayPrefix(plaintext),
+		Scopes:     scopes,
+		Attributes: attributes,
+		CreatedAt:  now,
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		key.ExpiresAt = &expires
+	}
+	if err := a.keys.CreateAPIKey(ctx, key, HashAPIKey(plaintext, a.pepper)); err != nil {
+		return "", nil, err
+	}
+	return plaintext, key, nil
+}
+
+// ListAPIKeys возвращает все выданные ключи
+func (a *Authenticator) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	if a.keys == nil {
+		return nil, fmt.Errorf("api key store is not configured")
+	}
+	return a.keys.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey отзывает ключ и сбрасывает кэш проверок
+func (a *Authenticator) RevokeAPIKey(ctx context.Context, id string) error {
+	if a.keys == nil {
+		return fmt.Errorf("api key store is not configured")
+	}
+	if err := a.keys.RevokeAPIKey(ctx, id, a.now().UTC()); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.cache = make(map[string]cachedKey)
+	a.mu.Unlock()
+	return nil
+}
+
+// isJWT отличает JWT (три base64url сегмента) от API ключа
+func isJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2 && !strings.HasPrefix(credential, apiKeyPrefix)
+}
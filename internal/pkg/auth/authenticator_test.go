@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryKeyStore хранилище ключей в памяти для тестов
+type memoryKeyStore struct {
+	mu      sync.Mutex
+	keys    map[string]*APIKey
+	hashes  map[string]string
+	lookups int
+}
+
+func newMemoryKeyStore() *memoryKeyStore {
+	return &memoryKeyStore{keys: map[string]*APIKey{}, hashes: map[string]string{}}
+}
+
+func (m *memoryKeyStore) CreateAPIKey(_ context.Context, key *APIKey, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := *key
+	m.keys[key.ID] = &k
+	m.hashes[hash] = key.ID
+	return nil
+}
+
+func (m *memoryKeyStore) GetAPIKeyByHash(_ context.Context, hash string) (*APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	id, ok := m.hashes[hash]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	k := *m.keys[id]
+	return &k, nil
+}
+
+func (m *memoryKeyStore) ListAPIKeys(_ context.Context) ([]APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []APIKey
+	for _, k := range m.keys {
+		out = append(out, *k)
+	}
+	return out, nil
+}
+
+func (m *memoryKeyStore) RevokeAPIKey(_ context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	k.RevokedAt = &at
+	return nil
+}
+
+func (m *memoryKeyStore) TouchAPIKey(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+func requestWithKey(key string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Key", key)
+	return r
+}
+
+func TestAuthenticator_APIKeyLifecycle(t *testing.T) {
+	store := newMemoryKeyStore()
+	a := NewAuthenticator(Config{Enabled: true, APIKeyPepper: "pepper", KeyCacheTTL: time.Minute}, store, nil)
+	ctx := context.Background()
+
+	plaintext, key, err := a.IssueAPIKey(ctx, "client", []string{ScopeGenerate}, map[string]string{"priority": "high"}, 0)
+	if err != nil {
+		t.Fatalf("IssueAPIKey failed: %v", err)
+	}
+	if _, stored := store.hashes[plaintext]; stored {
+		t.Error("Plaintext key must not be stored")
+	}
+
+	p, err := a.Authenticate(ctx, requestWithKey(plaintext))
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if p.ID != key.ID || !p.HasScope(ScopeGenerate) || p.HasScope(ScopeArchiveRead) {
+		t.Errorf("Unexpected principal %+v", p)
+	}
+	if p.Attributes["priority"] != "high" {
+		t.Errorf("Expected attributes to be propagated, got %v", p.Attributes)
+	}
+
+	// Повторная проверка берётся из кэша
+	if _, err := a.Authenticate(ctx, requestWithKey(plaintext)); err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if store.lookups != 1 {
+		t.Errorf("Expected 1 store lookup, got %d", store.lookups)
+	}
+
+	if err := a.RevokeAPIKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if _, err := a.Authenticate(ctx, requestWithKey(plaintext)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestAuthenticator_Rejects(t *testing.T) {
+	store := newMemoryKeyStore()
+	a := NewAuthenticator(Config{Enabled: true, KeyCacheTTL: time.Minute}, store, nil)
+	ctx := context.Background()
+
+	if _, err := a.Authenticate(ctx, httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Expected ErrNoCredentials, got %v", err)
+	}
+	if _, err := a.Authenticate(ctx, requestWithKey("pdfs_unknown")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	// JWT без настроенного JWKS не принимается
+	if _, err := a.Authenticate(ctx, requestWithKey("a.b.c")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for jwt, got %v", err)
+	}
+
+	plaintext, _, _ := a.IssueAPIKey(ctx, "short-lived", []string{ScopeGenerate}, nil, time.Minute)
+	a.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	a.cache = map[string]cachedKey{}
+	if _, err := a.Authenticate(ctx, requestWithKey(plaintext)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected expired key to be rejected, got %v", err)
+	}
+
+	if _, _, err := a.IssueAPIKey(ctx, "bad", []string{"root"}, nil, 0); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
+
+func TestAuthenticator_BootstrapAndCredentialSources(t *testing.T) {
+	a := NewAuthenticator(Config{Enabled: true, BootstrapAPIKey: "bootstrap-secret"}, nil, nil)
+	ctx := context.Background()
+
+	bearer := httptest.NewRequest(http.MethodGet, "/", nil)
+	bearer.Header.Set("Authorization", "Bearer bootstrap-secret")
+	cookie := httptest.NewRequest(http.MethodGet, "/", nil)
+	cookie.AddCookie(&http.Cookie{Name: CookieName, Value: "bootstrap-secret"})
+
+	for name, r := range map[string]*http.Request{"bearer": bearer, "header": requestWithKey("bootstrap-secret"), "cookie": cookie} {
+		p, err := a.Authenticate(ctx, r)
+		if err != nil {
+			t.Fatalf("%s: Authenticate failed: %v", name, err)
+		}
+		if p.Method != MethodBootstrap || !p.HasScope(ScopeKeysAdmin) {
+			t.Errorf("%s: unexpected principal %+v", name, p)
+		}
+	}
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	p := &Principal{Scopes: []string{ScopeArchiveAdmin}}
+	if !p.HasScope(ScopeArchiveRead) {
+		t.Error("Expected archive:admin to include archive:read")
+	}
+	if p.HasScope(ScopeGenerate) {
+		t.Error("Expected archive:admin not to include generate")
+	}
+	var nilPrincipal *Principal
+	if nilPrincipal.HasScope(ScopeGenerate) {
+		t.Error("Expected nil principal to have no scopes")
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"pdf-service-go/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// minJWKSRefreshGap минимальный интервал между внеплановыми обновлениями JWKS (при неизвестном kid)
+const minJWKSRefreshGap = time.Minute
+
+// jwk ключ в формате RFC 7517 (поддерживаются RSA и EC)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS разбирает набор ключей JWKS. Ключи шифрования (use=enc) и неподдерживаемые типы пропускаются
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAKey(k)
+		case "EC":
+			key, err = parseECKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
+
+func parseECKey(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y: %w", err)
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve")
+	}
+	return key, nil
+}
+
+// JWKS кэширует ключи проверки подписи JWT из файла или по URL и периодически их обновляет.
+// Загрузка выполняется вне блокировки и не чаще раза в minJWKSRefreshGap; при ошибке используются прежние ключи
+type JWKS struct {
+	mu          sync.Mutex
+	source      string
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	load        func(ctx context.Context) ([]byte, error)
+	refresh     time.Duration
+	lastAttempt time.Time
+	// loading закрывается по завершении текущей загрузки; nil, если загрузка не идёт
+	loading chan struct{}
+	now     func() time.Time
+}
+
+// NewJWKSFromFile создаёт источник ключей из файла
+func NewJWKSFromFile(path string, refresh time.Duration) *JWKS {
+	return newJWKS(path, func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}, refresh)
+}
+
+// NewJWKSFromURL создаёт источник ключей, загружаемых по HTTP
+func NewJWKSFromURL(url string, client *http.Client, refresh time.Duration) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return newJWKS(url, func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks endpoint returned status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}, refresh)
+}
+
+func newJWKS(source string, load func(ctx context.Context) ([]byte, error), refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = 10 * time.Minute
+	}
+	return &JWKS{source: source, load: load, refresh: refresh, now: time.Now}
+}
+
+// Key возвращает ключ по kid. Пустой kid допускается, если в наборе единственный ключ.
+// Устаревшие ключи обновляются в фоне, запрос ждёт загрузки только при первой загрузке и при неизвестном kid.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	now := j.now()
+	if j.keys == nil || now.Sub(j.fetchedAt) >= j.refresh {
+		done := j.reloadLocked(now)
+		if j.keys == nil && done != nil {
+			if err := j.waitLocked(ctx, done); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if key, ok := j.lookupLocked(kid); ok {
+		j.mu.Unlock()
+		return key, nil
+	}
+	// Неизвестный kid — возможно, провайдер ротировал ключи
+	if j.keys != nil {
+		if done := j.reloadLocked(now); done != nil {
+			if err := j.waitLocked(ctx, done); err != nil {
+				return nil, err
+			}
+			if key, ok := j.lookupLocked(kid); ok {
+				j.mu.Unlock()
+				return key, nil
+			}
+		}
+	}
+	defer j.mu.Unlock()
+	if j.keys == nil {
+		return nil, fmt.Errorf("jwks is not available")
+	}
+	return nil, fmt.Errorf("unknown jwt key id %q", kid)
+}
+
+// reloadLocked запускает загрузку ключей и возвращает канал её завершения. Если загрузка уже идёт,
+// возвращается её канал; если с прошлой попытки не прошёл minJWKSRefreshGap — nil
+func (j *JWKS) reloadLocked(now time.Time) chan struct{} {
+	if j.loading != nil {
+		return j.loading
+	}
+	if !j.lastAttempt.IsZero() && now.Sub(j.lastAttempt) < minJWKSRefreshGap {
+		return nil
+	}
+	j.lastAttempt = now
+	j.loading = make(chan struct{})
+	go j.fetch(j.loading, now)
+	return j.loading
+}
+
+// fetch загружает ключи без блокировки. При ошибке сохраняются ранее загруженные ключи
+func (j *JWKS) fetch(done chan struct{}, at time.Time) {
+	defer close(done)
+	data, err := j.load(context.Background())
+	var keys map[string]crypto.PublicKey
+	if err == nil {
+		keys, err = ParseJWKS(data)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.loading = nil
+	if err != nil {
+		log().Warn("Failed to load jwks, keeping previous keys",
+			zap.String("source", j.source),
+			zap.Int("keys", len(j.keys)),
+			zap.Error(err))
+		return
+	}
+	j.keys = keys
+	j.fetchedAt = at
+}
+
+// waitLocked отпускает блокировку до завершения загрузки или отмены запроса. При ошибке блокировка не захватывается
+func (j *JWKS) waitLocked(ctx context.Context, done chan struct{}) error {
+	j.mu.Unlock()
+	select {
+	case <-done:
+		j.mu.Lock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *JWKS) lookupLocked(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func log() *zap.Logger {
+	if logger.Log == nil {
+		return zap.NewNop()
+	}
+	return logger.Log
+}
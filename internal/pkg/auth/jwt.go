@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// jwtLeeway допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = 30 * time.Second
+
+// JWTVerifier проверяет bearer JWT, подписанные ключами из JWKS (RS256/384/512, ES256/384/512)
+type JWTVerifier struct {
+	keys     *JWKS
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewJWTVerifier создаёт проверку JWT. Пустые issuer и audience не проверяются
+func NewJWTVerifier(keys *JWKS, issuer, audience string) *JWTVerifier {
+	return &JWTVerifier{keys: keys, issuer: issuer, audience: audience, now: time.Now}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify проверяет подпись и claims токена и возвращает клиента
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed jwt")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt header")
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("malformed jwt header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt signature")
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt claims")
+	}
+	var claims map[string]any
+	decoder := json.NewDecoder(strings.NewReader(string(claimsJSON)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("malformed jwt claims")
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = sub
+	}
+	return &Principal{
+		ID:         sub,
+		Name:       name,
+		Method:     MethodJWT,
+		Scopes:     claimScopes(claims),
+		Attributes: claimAttributes(claims),
+	}, nil
+}
+
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	now := v.now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return fmt.Errorf("jwt has no exp claim")
+	}
+	if now.After(time.Unix(exp, 0).Add(jwtLeeway)) {
+		return fmt.Errorf("jwt expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(jwtLeeway).Before(time.Unix(nbf, 0)) {
+		return fmt.Errorf("jwt is not valid yet")
+	}
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return fmt.Errorf("unexpected jwt issuer")
+		}
+	}
+	if v.audience != "" && !containsString(stringList(claims["aud"]), v.audience) {
+		return fmt.Errorf("unexpected jwt audience")
+	}
+	return nil
+}
+
+// claimScopes извлекает области доступа из scope (строка через пробел) и scp (строка или массив)
+func claimScopes(claims map[string]any) []string {
+	var scopes []string
+	if s, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(s)...)
+	}
+	for _, s := range stringList(claims["scp"]) {
+		scopes = append(scopes, strings.Fields(s)...)
+	}
+	return scopes
+}
+
+// claimAttributes переносит строковый claim attributes (объект) в атрибуты клиента
+func claimAttributes(claims map[string]any) map[string]string {
+	raw, ok := claims["attributes"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	attrs := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			attrs[k] = s
+		}
+	}
+	return attrs
+}
+
+func numericClaim(claims map[string]any, name string) (int64, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, true
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func stringList(v any) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature проверяет подпись JWS. Алгоритм должен соответствовать типу ключа
+func verifySignature(alg string, key crypto.PublicKey, signingInput, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported jwt algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signingInput)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("jwt algorithm %q does not match rsa key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid jwt signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("jwt algorithm %q does not match ec key", alg)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid jwt signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("invalid jwt signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported jwt key type")
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64(key.N.Bytes()),
+		"e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": key.Curve.Params().Name,
+		"x": b64(key.X.FillBytes(make([]byte, size))),
+		"y": b64(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("Failed to marshal jwks: %v", err)
+	}
+	return data
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	default:
+		hash = crypto.SHA512
+	}
+	h := hash.New()
+	h.Write([]byte(input))
+	digest := h.Sum(nil)
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return input + "." + b64(sig)
+}
+
+func writeJWKS(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write jwks: %v", err)
+	}
+	return path
+}
+
+func TestJWTVerifier_RS256AndES256(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	path := writeJWKS(t, jwksJSON(t, rsaJWK("rsa1", &rsaKey.PublicKey), ecJWK("ec1", &ecKey.PublicKey)))
+
+	verifier := NewJWTVerifier(NewJWKSFromFile(path, time.Minute), "https://issuer", "pdf-service")
+	claims := map[string]any{
+		"sub":   "client-1",
+		"iss":   "https://issuer",
+		"aud":   []string{"pdf-service", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "generate archive:read",
+		"scp":   []string{"errors:read"},
+	}
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      crypto.Signer
+	}{
+		{"RS256", "rsa1", rsaKey},
+		{"ES256", "ec1", ecKey},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			p, err := verifier.Verify(context.Background(), signJWT(t, tc.alg, tc.kid, tc.key, claims))
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if p.ID != "client-1" || p.Method != MethodJWT {
+				t.Errorf("Unexpected principal %+v", p)
+			}
+			for _, s := range []string{ScopeGenerate, ScopeArchiveRead, ScopeErrorsRead} {
+				if !p.HasScope(s) {
+					t.Errorf("Expected scope %s in %v", s, p.Scopes)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_Rejects(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := writeJWKS(t, jwksJSON(t, rsaJWK("rsa1", &rsaKey.PublicKey)))
+	verifier := NewJWTVerifier(NewJWKSFromFile(path, time.Minute), "https://issuer", "pdf-service")
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"sub": "client-1", "iss": "https://issuer", "aud": "pdf-service",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	cases := map[string]string{
+		"expired": signJWT(t, "RS256", "rsa1", rsaKey, func() map[string]any {
+			c := valid()
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return c
+		}()),
+		"no exp": signJWT(t, "RS256", "rsa1", rsaKey, func() map[string]any {
+			c := valid()
+			delete(c, "exp")
+			return c
+		}()),
+		"wrong issuer": signJWT(t, "RS256", "rsa1", rsaKey, func() map[string]any {
+			c := valid()
+			c["iss"] = "https://evil"
+			return c
+		}()),
+		"wrong audience": signJWT(t, "RS256", "rsa1", rsaKey, func() map[string]any {
+			c := valid()
+			c["aud"] = "other"
+			return c
+		}()),
+		"foreign key": signJWT(t, "RS256", "rsa1", otherKey, valid()),
+		"unknown kid": signJWT(t, "RS256", "rsa2", rsaKey, valid()),
+		"alg mismatch": func() string {
+			parts := strings.Split(signJWT(t, "RS256", "rsa1", rsaKey, valid()), ".")
+			parts[0] = b64([]byte(`{"alg":"ES256","kid":"rsa1"}`))
+			return strings.Join(parts, ".")
+		}(),
+		"malformed": "a.b",
+	}
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), token); err == nil {
+				t.Error("Expected verification error")
+			}
+		})
+	}
+
+	// alg=none не принимается
+	header := b64([]byte(`{"alg":"none","kid":"rsa1"}`))
+	payload, _ := json.Marshal(valid())
+	if _, err := verifier.Verify(context.Background(), header+"."+b64(payload)+"."); err == nil {
+		t.Error("Expected alg=none to be rejected")
+	}
+}
+
+func TestJWKS_FromURLRefreshesOnUnknownKid(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	current := jwksJSON(t, rsaJWK("k1", &key1.PublicKey))
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(current)
+	}))
+	defer server.Close()
+
+	jwks := NewJWKSFromURL(server.URL, server.Client(), time.Hour)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+
+	if _, err := jwks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Expected k1, got %v", err)
+	}
+
+	// Провайдер ротировал ключи; внеплановое обновление ограничено minJWKSRefreshGap
+	current = jwksJSON(t, rsaJWK("k1", &key1.PublicKey), rsaJWK("k2", &key2.PublicKey))
+	if _, err := jwks.Key(context.Background(), "k2"); err == nil {
+		t.Error("Expected unknown kid before refresh gap elapsed")
+	}
+	now = now.Add(minJWKSRefreshGap)
+	if _, err := jwks.Key(context.Background(), "k2"); err != nil {
+		t.Errorf("Expected k2 after refresh, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 jwks fetches, got %d", requests)
+	}
+}
+
+func TestJWKS_StaleKeysServedWhileReloadFails(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	data := jwksJSON(t, rsaJWK("k1", &key.PublicKey))
+	var loads atomic.Int64
+	var failing atomic.Bool
+	jwks := newJWKS("test", func(context.Context) ([]byte, error) {
+		loads.Add(1)
+		if failing.Load() {
+			return nil, errors.New("jwks unavailable")
+		}
+		return data, nil
+	}, time.Minute)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+
+	if _, err := jwks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Expected k1, got %v", err)
+	}
+
+	// Ключи устарели, источник недоступен: запросы обслуживаются прежними ключами
+	failing.Store(true)
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := jwks.Key(context.Background(), "k1"); err != nil {
+			t.Fatalf("Expected stale k1 to be served, got %v", err)
+		}
+		waitJWKSLoad(jwks)
+	}
+	// Повторные загрузки устаревших ключей ограничены minJWKSRefreshGap
+	if loads.Load() != 2 {
+		t.Errorf("Expected 2 loads within refresh gap, got %d", loads.Load())
+	}
+
+	now = now.Add(minJWKSRefreshGap)
+	failing.Store(false)
+	if _, err := jwks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Expected k1, got %v", err)
+	}
+	waitJWKSLoad(jwks)
+	if loads.Load() != 3 {
+		t.Errorf("Expected reload after refresh gap, got %d loads", loads.Load())
+	}
+}
+
+func TestJWKS_ConcurrentFirstLoadIsSingleFlight(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	data := jwksJSON(t, rsaJWK("k1", &key.PublicKey))
+	release := make(chan struct{})
+	var loads atomic.Int64
+	jwks := newJWKS("test", func(context.Context) ([]byte, error) {
+		loads.Add(1)
+		<-release
+		return data, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := jwks.Key(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+
+	// Пока идёт загрузка, запрос с истёкшим контекстом не блокируется на мьютексе
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := jwks.Key(ctx, "k1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded while waiting for load, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected k1, got %v", err)
+		}
+	}
+	if loads.Load() != 1 {
+		t.Errorf("Expected single load, got %d", loads.Load())
+	}
+}
+
+// waitJWKSLoad ждёт завершения фоновой загрузки ключей
+func waitJWKSLoad(j *JWKS) {
+	j.mu.Lock()
+	done := j.loading
+	j.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
@@ -0,0 +1,228 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pdf-service-go/internal/pkg/waitqueue"
+)
+
+// Причины отказа
+const (
+	ReasonQueueFull    = "queue_full"
+	ReasonQueueTimeout = "queue_timeout"
+)
+
+// Имена отсеков конвейера генерации
+const (
+	Render          = "render"           // Python рендеринг DOCX (черновой и финальный)
+	DraftConversion = "draft_conversion" // Конвертация черновика в PDF для подсчёта страниц
+	FinalConversion = "final_conversion" // Конвертация финального документа
+)
+
+// ErrRejected отсек переполнен или истекло ожидание в его очереди
+var ErrRejected = errors.New("bulkhead rejected")
+
+// RejectedError описывает отказ отсека и рекомендуемую задержку повтора
+type RejectedError struct {
+	Bulkhead   string
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s bulkhead is saturated (%s), retry after %s", e.Bulkhead, e.Reason, e.RetryAfter)
+}
+
+// Is позволяет проверять отказ через errors.Is(err, ErrRejected)
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Config настройки отсека
+type Config struct {
+	Name string
+	// MaxConcurrent максимум одновременных операций (0 — без ограничения)
+	MaxConcurrent int
+	// QueueSize максимум операций, ожидающих свободного места
+	QueueSize int
+	// QueueTimeout максимальное время ожидания в очереди
+	QueueTimeout time.Duration
+}
+
+// ConfigFromEnv читает настройки отсека из BULKHEAD_<NAME>_MAX_CONCURRENT, _QUEUE_SIZE и _QUEUE_TIMEOUT
+func ConfigFromEnv(name string, defaults Config) Config {
+	prefix := "BULKHEAD_" + strings.ToUpper(name) + "_"
+	return Config{
+		Name:          name,
+		MaxConcurrent: getEnvIntWithDefault(prefix+"MAX_CONCURRENT", defaults.MaxConcurrent),
+		QueueSize:     getEnvIntWithDefault(prefix+"QUEUE_SIZE", defaults.QueueSize),
+		QueueTimeout:  getEnvDurationWithDefault(prefix+"QUEUE_TIMEOUT", defaults.QueueTimeout),
+	}
+}
+
+// Bulkhead ограничивает число одновременных операций одного этапа, чтобы перегрузка одного этапа
+// не забирала ресурсы у других. Ожидающие обслуживаются в порядке поступления.
+type Bulkhead struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	inFlight int
+	waiters  *waitqueue.Queue
+	// hold время удержания места, используется для Retry-After
+	hold waitqueue.HoldEstimator
+}
+
+// New создаёт отсек
+func New(cfg Config) *Bulkhead {
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = 30 * time.Second
+	}
+	b := &Bulkhead{cfg: cfg, now: time.Now, waiters: waitqueue.New()}
+	limit.WithLabelValues(cfg.Name).Set(float64(cfg.MaxConcurrent))
+	queueCapacity.WithLabelValues(cfg.Name).Set(float64(cfg.QueueSize))
+	b.updateGaugesLocked()
+	return b
+}
+
+// Name возвращает имя отсека
+func (b *Bulkhead) Name() string {
+	return b.cfg.Name
+}
+
+// Enabled возвращает true, если число одновременных операций ограничено
+func (b *Bulkhead) Enabled() bool {
+	return b != nil && b.cfg.MaxConcurrent > 0
+}
+
+// Execute выполняет fn, заняв место в отсеке. Возвращает *RejectedError при отказе или ошибку контекста.
+func (b *Bulkhead) Execute(ctx context.Context, fn func() error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// Acquire занимает место в отсеке, при необходимости ожидая в очереди.
+// Возвращает функцию освобождения места, *RejectedError при отказе или ошибку контекста.
+func (b *Bulkhead) Acquire(ctx context.Context) (func(), error) {
+	if !b.Enabled() {
+		return func() {}, nil
+	}
+	start := b.now()
+
+	b.mu.Lock()
+	if b.inFlight < b.cfg.MaxConcurrent && b.waiters.Len() == 0 {
+		b.inFlight++
+		b.updateGaugesLocked()
+		b.mu.Unlock()
+		waitDuration.WithLabelValues(b.cfg.Name).Observe(0)
+		return b.releaseFunc(start), nil
+	}
+	if b.waiters.Len() >= b.cfg.QueueSize {
+		retryAfter := b.retryAfterLocked()
+		b.mu.Unlock()
+		rejectedTotal.WithLabelValues(b.cfg.Name, ReasonQueueFull).Inc()
+		return nil, &RejectedError{Bulkhead: b.cfg.Name, Reason: ReasonQueueFull, RetryAfter: retryAfter}
+	}
+	w := b.waiters.Push()
+	b.updateGaugesLocked()
+	b.mu.Unlock()
+
+	waitErr := w.Wait(ctx, b.cfg.QueueTimeout)
+	if waitErr == nil {
+		waitDuration.WithLabelValues(b.cfg.Name).Observe(b.now().Sub(start).Seconds())
+		return b.releaseFunc(b.now()), nil
+	}
+
+	b.mu.Lock()
+	if !b.waiters.Remove(w) {
+		// Место выдано одновременно с таймаутом — возвращаем его следующему
+		b.releaseLocked()
+	}
+	b.updateGaugesLocked()
+	retryAfter := b.retryAfterLocked()
+	b.mu.Unlock()
+
+	waitDuration.WithLabelValues(b.cfg.Name).Observe(b.now().Sub(start).Seconds())
+	if !errors.Is(waitErr, waitqueue.ErrTimeout) {
+		rejectedTotal.WithLabelValues(b.cfg.Name, "canceled").Inc()
+		return nil, waitErr
+	}
+	rejectedTotal.WithLabelValues(b.cfg.Name, ReasonQueueTimeout).Inc()
+	return nil, &RejectedError{Bulkhead: b.cfg.Name, Reason: ReasonQueueTimeout, RetryAfter: retryAfter}
+}
+
+// Stats возвращает число выполняющихся и ожидающих операций
+func (b *Bulkhead) Stats() (inFlight, queued int) {
+	if b == nil {
+		return 0, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inFlight, b.waiters.Len()
+}
+
+func (b *Bulkhead) releaseFunc(start time.Time) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hold := b.now().Sub(start)
+			b.mu.Lock()
+			b.hold.Observe(hold)
+			b.releaseLocked()
+			b.updateGaugesLocked()
+			b.mu.Unlock()
+		})
+	}
+}
+
+// releaseLocked освобождает место и передаёт его первому ожидающему
+func (b *Bulkhead) releaseLocked() {
+	b.inFlight--
+	if b.inFlight < b.cfg.MaxConcurrent && b.waiters.Grant() {
+		b.inFlight++
+	}
+}
+
+// retryAfterLocked оценивает время освобождения места в очереди
+func (b *Bulkhead) retryAfterLocked() time.Duration {
+	return b.hold.RetryAfter(b.waiters.Len(), b.cfg.MaxConcurrent)
+}
+
+func (b *Bulkhead) updateGaugesLocked() {
+	inFlightGauge.WithLabelValues(b.cfg.Name).Set(float64(b.inFlight))
+	queueDepth.WithLabelValues(b.cfg.Name).Set(float64(b.waiters.Len()))
+}
+
+// getEnvIntWithDefault возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
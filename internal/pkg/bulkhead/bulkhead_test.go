@@ -0,0 +1,101 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitForQueue ждёт, пока в очереди отсека окажется n ожидающих
+func waitForQueue(t *testing.T, b *Bulkhead, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, queued := b.Stats(); queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d queued operations", n)
+}
+
+func TestBulkhead_LimitsConcurrency(t *testing.T) {
+	b := New(Config{Name: "test-limit", MaxConcurrent: 1, QueueSize: 1, QueueTimeout: time.Second})
+	release, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Execute(context.Background(), func() error { return nil })
+	}()
+	waitForQueue(t, b, 1)
+
+	_, err = b.Acquire(context.Background())
+	var rej *RejectedError
+	if !errors.As(err, &rej) || rej.Reason != ReasonQueueFull || rej.Bulkhead != "test-limit" {
+		t.Fatalf("Expected queue_full rejection, got %v", err)
+	}
+	if !errors.Is(err, ErrRejected) {
+		t.Error("Expected errors.Is(err, ErrRejected)")
+	}
+
+	release()
+	release() // повторное освобождение не должно ломать счётчик
+	if err := <-done; err != nil {
+		t.Fatalf("Queued operation failed: %v", err)
+	}
+	if inFlight, queued := b.Stats(); inFlight != 0 || queued != 0 {
+		t.Errorf("Expected empty bulkhead, got %d/%d", inFlight, queued)
+	}
+}
+
+func TestBulkhead_QueueTimeoutAndCancel(t *testing.T) {
+	b := New(Config{Name: "test-timeout", MaxConcurrent: 1, QueueSize: 2, QueueTimeout: 20 * time.Millisecond})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	_, err := b.Acquire(context.Background())
+	var rej *RejectedError
+	if !errors.As(err, &rej) || rej.Reason != ReasonQueueTimeout {
+		t.Fatalf("Expected queue_timeout rejection, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if _, queued := b.Stats(); queued != 0 {
+		t.Errorf("Expected abandoned waiters to leave the queue, got %d", queued)
+	}
+}
+
+func TestBulkhead_IsolatesStages(t *testing.T) {
+	final := New(Config{Name: "test-final", MaxConcurrent: 1, QueueSize: 0})
+	draft := New(Config{Name: "test-draft", MaxConcurrent: 1, QueueSize: 0})
+
+	release, _ := final.Acquire(context.Background())
+	defer release()
+	if err := final.Execute(context.Background(), func() error { return nil }); !errors.Is(err, ErrRejected) {
+		t.Fatalf("Expected saturated final bulkhead to reject, got %v", err)
+	}
+	if err := draft.Execute(context.Background(), func() error { return nil }); err != nil {
+		t.Errorf("Saturated final bulkhead must not affect draft bulkhead: %v", err)
+	}
+}
+
+func TestBulkhead_Unlimited(t *testing.T) {
+	var b *Bulkhead
+	if err := b.Execute(context.Background(), func() error { return nil }); err != nil {
+		t.Errorf("Nil bulkhead must not limit: %v", err)
+	}
+	b = New(Config{Name: "test-unlimited"})
+	for i := 0; i < 10; i++ {
+		if _, err := b.Acquire(context.Background()); err != nil {
+			t.Fatalf("Unlimited bulkhead rejected: %v", err)
+		}
+	}
+}
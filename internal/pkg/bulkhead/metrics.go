@@ -0,0 +1,63 @@
+package bulkhead
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// inFlightGauge количество выполняющихся операций отсека
+	inFlightGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_in_flight",
+			Help: "Number of operations currently running in the bulkhead",
+		},
+		[]string{"bulkhead"},
+	)
+
+	// limit максимум одновременных операций отсека
+	limit = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_in_flight_limit",
+			Help: "Maximum number of concurrent operations in the bulkhead (0: unlimited)",
+		},
+		[]string{"bulkhead"},
+	)
+
+	// queueDepth количество операций, ожидающих места в отсеке
+	queueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_queue_depth",
+			Help: "Number of operations waiting for a free bulkhead slot",
+		},
+		[]string{"bulkhead"},
+	)
+
+	// queueCapacity размер очереди ожидания отсека
+	queueCapacity = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bulkhead_queue_capacity",
+			Help: "Maximum number of operations allowed to wait for the bulkhead",
+		},
+		[]string{"bulkhead"},
+	)
+
+	// waitDuration время ожидания места в отсеке
+	waitDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bulkhead_wait_seconds",
+			Help:    "Time spent waiting for a free bulkhead slot",
+			Buckets: []float64{0, 0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30},
+		},
+		[]string{"bulkhead"},
+	)
+
+	// rejectedTotal отказы отсека по причинам
+	rejectedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bulkhead_rejected_total",
+			Help: "Total number of operations rejected by the bulkhead",
+		},
+		[]string{"bulkhead", "reason"},
+	)
+)
@@ -3,6 +3,7 @@ package circuitbreaker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"sync"
 	"time"
@@ -58,6 +59,22 @@ var (
 		[]string{"name", "pod_name", "namespace"},
 	)
 
+	circuitBreakerFailureRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_failure_rate",
+			Help: "Failure rate in percent over the circuit breaker sliding window",
+		},
+		[]string{"name", "pod_name", "namespace"},
+	)
+
+	circuitBreakerSlowCallRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_slow_call_rate",
+			Help: "Slow call rate in percent over the circuit breaker sliding window",
+		},
+		[]string{"name", "pod_name", "namespace"},
+	)
+
 	circuitBreakerRecoveryTime = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "circuit_breaker_recovery_duration_seconds",
@@ -68,6 +85,37 @@ var (
 	)
 )
 
+// OpenError возвращается проверкой Check, когда Circuit Breaker не пропустит запрос
+type OpenError struct {
+	Name       string        // Имя Circuit Breaker (зависимость)
+	RetryAfter time.Duration // Оставшееся время до пробного запроса
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable: circuit breaker is open, retry after %s", e.Name, e.RetryAfter)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrCircuitOpen)
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// Classifier решает, считается ли ошибка сбоем зависимости.
+// Ошибки, которые не считаются сбоем, возвращаются вызывающему, но не влияют на состояние Circuit Breaker.
+type Classifier func(ctx context.Context, err error) bool
+
+// DefaultClassifier считает сбоем любую ошибку, кроме отмены вызывающим.
+// Истёкший дедлайн запроса — сбой: зависимость не ответила за отведённое вызывающим время.
+func DefaultClassifier(ctx context.Context, err error) bool {
+	return err != nil && !CallerCanceled(ctx, err)
+}
+
+// CallerCanceled сообщает, что ошибка вызвана отменой вызывающего (клиент отключился,
+// хеджированный запрос проиграл), а не сбоем зависимости
+func CallerCanceled(ctx context.Context, err error) bool {
+	return err != nil && (errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled))
+}
+
 // Config содержит настройки для Circuit Breaker
 type Config struct {
 	Name             string        // Имя для идентификации в метриках
@@ -77,6 +125,8 @@ type Config struct {
 	SuccessThreshold int           // Количество успешных запросов для перехода из Half-Open в Closed
 	PodName          string        // Имя пода в Kubernetes
 	Namespace        string        // Namespace в Kubernetes
+	Window           WindowConfig  // Скользящее окно; по умолчанию — FailureThreshold ошибок подряд
+	IsFailure        Classifier    // Какие ошибки считаются сбоем; по умолчанию DefaultClassifier
 }
 
 // CircuitBreaker реализует паттерн Circuit Breaker
@@ -90,6 +140,15 @@ type CircuitBreaker struct {
 	halfOpenCalls   int       // Счетчик запросов в Half-Open состоянии
 	openStartTime   time.Time // Время перехода в состояние Open
 
+	window window           // Скользящее окно; nil в режиме ошибок подряд
+	now    func() time.Time // Источник времени для скользящего окна
+
+	forced    bool         // Состояние задано вручную и не меняется по результатам запросов
+	lastError string       // Последняя учтённая ошибка, попадает в причину открытия
+	history   []Transition // Последние смены состояния
+	pending   []Transition // Смены состояния, о которых ещё не уведомлены подписчики
+	listeners []Listener
+
 	mu sync.RWMutex
 }
 
@@ -101,11 +160,16 @@ func NewCircuitBreaker(config Config) *CircuitBreaker {
 	if config.Namespace == "" {
 		config.Namespace = os.Getenv("POD_NAMESPACE")
 	}
+	if config.IsFailure == nil {
+		config.IsFailure = DefaultClassifier
+	}
 
 	cb := &CircuitBreaker{
 		config:          config,
 		state:           StateClosed,
 		lastStateChange: time.Now(),
+		window:          newWindow(config.Window),
+		now:             time.Now,
 	}
 
 	// Инициализация начального состояния в метриках
@@ -118,6 +182,7 @@ func NewCircuitBreaker(config Config) *CircuitBreaker {
 	circuitBreakerState.With(labels).Set(float64(StateClosed))
 	circuitBreakerPodHealth.With(labels).Set(1)
 
+	Default().Register(cb)
 	return cb
 }
 
@@ -128,8 +193,15 @@ func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
 		return ErrCircuitOpen
 	}
 
+	start := time.Now()
 	err := fn()
-	cb.handleResult(err)
+	if err != nil && !cb.config.IsFailure(ctx, err) {
+		// Ошибка не говорит о сбое зависимости (неверный запрос, отмена вызывающим)
+		cb.handleIgnored()
+		circuitBreakerRequests.WithLabelValues(cb.config.Name, cb.config.PodName, cb.config.Namespace, "ignored").Inc()
+		return err
+	}
+	cb.handleResult(err, time.Since(start))
 
 	if err != nil {
 		circuitBreakerRequests.WithLabelValues(cb.config.Name, cb.config.PodName, cb.config.Namespace, "failure").Inc()
@@ -143,14 +215,14 @@ func (cb *CircuitBreaker) Execute(ctx context.Context, fn func() error) error {
 // allowRequest проверяет, можно ли выполнить запрос
 func (cb *CircuitBreaker) allowRequest() bool {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlockAndNotify()
 
 	switch cb.state {
 	case StateClosed:
 		return true
 	case StateOpen:
-		if time.Since(cb.lastStateChange) > cb.config.ResetTimeout {
-			cb.toHalfOpen()
+		if !cb.forced && time.Since(cb.lastStateChange) > cb.config.ResetTimeout {
+			cb.toHalfOpen(ReasonResetTimeout, "")
 			return true
 		}
 		return false
@@ -166,10 +238,24 @@ func (cb *CircuitBreaker) allowRequest() bool {
 }
 
 // handleResult обрабатывает результат выполнения запроса
-func (cb *CircuitBreaker) handleResult(err error) {
+func (cb *CircuitBreaker) handleResult(err error, duration time.Duration) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlockAndNotify()
+
+	slow := cb.config.Window.SlowCallDuration > 0 && duration > cb.config.Window.SlowCallDuration
+	if err != nil {
+		circuitBreakerFailures.WithLabelValues(cb.config.Name, cb.config.PodName, cb.config.Namespace).Inc()
+		cb.lastError = err.Error()
+	}
+	if cb.forced {
+		// Состояние задано вручную — результаты запросов его не меняют
+		return
+	}
 
+	if cb.window != nil {
+		cb.onWindowResult(err != nil, slow)
+		return
+	}
 	if err != nil {
 		cb.onFailure()
 	} else {
@@ -177,18 +263,57 @@ func (cb *CircuitBreaker) handleResult(err error) {
 	}
 }
 
+// handleIgnored освобождает место пробного запроса в Half-Open, не засчитывая ни успех, ни ошибку
+func (cb *CircuitBreaker) handleIgnored() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateHalfOpen && cb.halfOpenCalls > 0 {
+		cb.halfOpenCalls--
+	}
+}
+
+// onWindowResult учитывает результат в скользящем окне и открывает Circuit Breaker по долям ошибок и медленных вызовов
+func (cb *CircuitBreaker) onWindowResult(failed, slow bool) {
+	// Медленный вызов в Half-Open считается неудачной пробой, если оценивается доля медленных вызовов
+	slowFails := slow && cb.config.Window.SlowCallRateThreshold > 0
+
+	switch cb.state {
+	case StateClosed:
+		now := cb.now()
+		cb.window.record(now, failed, slow)
+		stats := cb.window.stats(now)
+		labels := []string{cb.config.Name, cb.config.PodName, cb.config.Namespace}
+		circuitBreakerFailureRate.WithLabelValues(labels...).Set(stats.failureRate())
+		circuitBreakerSlowCallRate.WithLabelValues(labels...).Set(stats.slowRate())
+
+		if stats.calls < cb.config.Window.MinimumCalls {
+			return
+		}
+		if cb.config.Window.FailureRateThreshold > 0 && stats.failureRate() >= cb.config.Window.FailureRateThreshold {
+			cb.toOpen(ReasonFailureRate, fmt.Sprintf("%.1f%% of %d calls failed", stats.failureRate(), stats.calls))
+		} else if cb.config.Window.SlowCallRateThreshold > 0 && stats.slowRate() >= cb.config.Window.SlowCallRateThreshold {
+			cb.toOpen(ReasonSlowCallRate, fmt.Sprintf("%.1f%% of %d calls slower than %s", stats.slowRate(), stats.calls, cb.config.Window.SlowCallDuration))
+		}
+	case StateHalfOpen:
+		if failed || slowFails {
+			cb.toOpen(ReasonProbeFailed, "")
+			return
+		}
+		cb.onSuccess()
+	}
+}
+
 // onFailure обрабатывает ошибку выполнения запроса
 func (cb *CircuitBreaker) onFailure() {
-	circuitBreakerFailures.WithLabelValues(cb.config.Name, cb.config.PodName, cb.config.Namespace).Inc()
-
 	switch cb.state {
 	case StateClosed:
 		cb.failures++
 		if cb.failures >= cb.config.FailureThreshold {
-			cb.toOpen()
+			cb.toOpen(ReasonFailureThreshold, fmt.Sprintf("%d consecutive failures", cb.failures))
 		}
 	case StateHalfOpen:
-		cb.toOpen()
+		cb.toOpen(ReasonProbeFailed, "")
 	}
 }
 
@@ -200,16 +325,16 @@ func (cb *CircuitBreaker) onSuccess() {
 	case StateHalfOpen:
 		cb.successes++
 		if cb.successes >= cb.config.SuccessThreshold {
-			cb.toClosed()
+			cb.toClosed(ReasonRecovered, fmt.Sprintf("%d successful probes", cb.successes))
 		}
 	}
 }
 
 // toOpen переводит Circuit Breaker в состояние Open
-func (cb *CircuitBreaker) toOpen() {
-	cb.state = StateOpen
-	cb.lastStateChange = time.Now()
+func (cb *CircuitBreaker) toOpen(reason, detail string) {
+	cb.record(StateOpen, reason, detail)
 	cb.openStartTime = time.Now()
+	cb.resetWindow()
 	cb.failures = 0
 	cb.successes = 0
 	cb.halfOpenCalls = 0
@@ -224,9 +349,8 @@ func (cb *CircuitBreaker) toOpen() {
 }
 
 // toHalfOpen переводит Circuit Breaker в состояние Half-Open
-func (cb *CircuitBreaker) toHalfOpen() {
-	cb.state = StateHalfOpen
-	cb.lastStateChange = time.Now()
+func (cb *CircuitBreaker) toHalfOpen(reason, detail string) {
+	cb.record(StateHalfOpen, reason, detail)
 	cb.failures = 0
 	cb.successes = 0
 	cb.halfOpenCalls = 0
@@ -241,9 +365,9 @@ func (cb *CircuitBreaker) toHalfOpen() {
 }
 
 // toClosed переводит Circuit Breaker в состояние Closed
-func (cb *CircuitBreaker) toClosed() {
-	cb.state = StateClosed
-	cb.lastStateChange = time.Now()
+func (cb *CircuitBreaker) toClosed(reason, detail string) {
+	cb.record(StateClosed, reason, detail)
+	cb.resetWindow()
 	cb.failures = 0
 	cb.successes = 0
 	cb.halfOpenCalls = 0
@@ -264,6 +388,17 @@ func (cb *CircuitBreaker) toClosed() {
 	}
 }
 
+// resetWindow очищает скользящее окно при смене состояния
+func (cb *CircuitBreaker) resetWindow() {
+	if cb.window == nil {
+		return
+	}
+	cb.window.reset()
+	labels := []string{cb.config.Name, cb.config.PodName, cb.config.Namespace}
+	circuitBreakerFailureRate.WithLabelValues(labels...).Set(0)
+	circuitBreakerSlowCallRate.WithLabelValues(labels...).Set(0)
+}
+
 // State возвращает текущее состояние Circuit Breaker
 func (cb *CircuitBreaker) State() State {
 	cb.mu.RLock()
@@ -293,6 +428,37 @@ func (cb *CircuitBreaker) IsHealthy() bool {
 	return isHealthy
 }
 
+// Check проверяет без изменения состояния, пропустит ли Circuit Breaker запрос.
+// Возвращает *OpenError с оставшимся до пробного запроса временем, если нет.
+func (cb *CircuitBreaker) Check() error {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	var retryAfter time.Duration
+	switch cb.state {
+	case StateClosed:
+		return nil
+	case StateOpen:
+		if cb.forced {
+			// Открыт вручную — время закрытия неизвестно
+			retryAfter = cb.config.ResetTimeout
+			break
+		}
+		retryAfter = cb.config.ResetTimeout - time.Since(cb.lastStateChange)
+		if retryAfter <= 0 {
+			// Время ожидания истекло — следующий запрос станет пробным
+			return nil
+		}
+	case StateHalfOpen:
+		if cb.halfOpenCalls < cb.config.HalfOpenMaxCalls {
+			return nil
+		}
+		// Пробные запросы уже выполняются; при их неудаче Circuit Breaker снова откроется на ResetTimeout
+		retryAfter = cb.config.ResetTimeout
+	}
+	return &OpenError{Name: cb.config.Name, RetryAfter: retryAfter}
+}
+
 // String возвращает строковое представление состояния
 func (s State) String() string {
 	switch s {
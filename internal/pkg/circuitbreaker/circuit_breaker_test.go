@@ -139,3 +139,258 @@ func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
 		t.Errorf("Expected state to be Open after failure in half-open, got %v", cb.State())
 	}
 }
+
+func TestCircuitBreaker_Check(t *testing.T) {
+	cb := NewCircuitBreaker(Config{
+		Name:             "test-check",
+		FailureThreshold: 1,
+		ResetTimeout:     100 * time.Millisecond,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	})
+
+	if err := cb.Check(); err != nil {
+		t.Fatalf("Expected closed circuit to pass check, got: %v", err)
+	}
+
+	_ = cb.Execute(context.Background(), func() error { return errors.New("test error") })
+
+	err := cb.Check()
+	var openErr *OpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Expected OpenError, got: %v", err)
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Error("Expected OpenError to match ErrCircuitOpen")
+	}
+	if openErr.Name != "test-check" {
+		t.Errorf("Expected name test-check, got %s", openErr.Name)
+	}
+	if openErr.RetryAfter <= 0 || openErr.RetryAfter > 100*time.Millisecond {
+		t.Errorf("Expected RetryAfter within reset timeout, got %v", openErr.RetryAfter)
+	}
+	// Проверка не должна менять состояние
+	if cb.State() != StateOpen {
+		t.Errorf("Expected state to stay Open, got %v", cb.State())
+	}
+
+	// После ResetTimeout проверка пропускает пробный запрос
+	time.Sleep(150 * time.Millisecond)
+	if err := cb.Check(); err != nil {
+		t.Errorf("Expected check to pass after reset timeout, got: %v", err)
+	}
+}
+
+// runCalls выполняет вызовы через Circuit Breaker: каждый failEvery-й завершается ошибкой
+func runCalls(cb *CircuitBreaker, n, failEvery int) {
+	for i := 1; i <= n; i++ {
+		_ = cb.Execute(context.Background(), func() error {
+			if failEvery > 0 && i%failEvery == 0 {
+				return errors.New("test error")
+			}
+			return nil
+		})
+	}
+}
+
+func TestCircuitBreaker_CountWindowFailureRate(t *testing.T) {
+	config := Config{
+		Name:             "test-count-window",
+		FailureThreshold: 3,
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		Window: WindowConfig{
+			Type:                 WindowCount,
+			Size:                 10,
+			MinimumCalls:         10,
+			FailureRateThreshold: 30,
+		},
+	}
+
+	// Ошибка в каждом третьем вызове никогда не даёт 3 ошибки подряд
+	consecutive := config
+	consecutive.Window = WindowConfig{}
+	cb := NewCircuitBreaker(consecutive)
+	runCalls(cb, 30, 3)
+	if cb.State() != StateClosed {
+		t.Fatalf("Consecutive mode should stay closed on 33%% errors, got %v", cb.State())
+	}
+
+	cb = NewCircuitBreaker(config)
+	runCalls(cb, 9, 3)
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected no evaluation before MinimumCalls, got %v", cb.State())
+	}
+	runCalls(cb, 1, 0)
+	if cb.State() != StateOpen {
+		t.Errorf("Expected Open at 30%% failure rate over 10 calls, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_CountWindowIgnoresSmallBursts(t *testing.T) {
+	cb := NewCircuitBreaker(Config{
+		Name:             "test-burst",
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		Window: WindowConfig{
+			Type:                 WindowCount,
+			Size:                 20,
+			MinimumCalls:         20,
+			FailureRateThreshold: 50,
+		},
+	})
+	runCalls(cb, 20, 0)
+	// Пять ошибок подряд — 25% окна
+	runCalls(cb, 5, 1)
+	if cb.State() != StateClosed {
+		t.Errorf("Expected a short burst not to open the breaker, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_SlowCallRate(t *testing.T) {
+	cb := NewCircuitBreaker(Config{
+		Name:             "test-slow",
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		Window: WindowConfig{
+			Type:                  WindowCount,
+			Size:                  4,
+			MinimumCalls:          4,
+			SlowCallDuration:      5 * time.Millisecond,
+			SlowCallRateThreshold: 50,
+		},
+	})
+	slow := func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+	fast := func() error { return nil }
+	for _, fn := range []func() error{fast, slow, fast, slow} {
+		_ = cb.Execute(context.Background(), fn)
+	}
+	if cb.State() != StateOpen {
+		t.Errorf("Expected Open at 50%% slow calls, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_TimeWindowExpires(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cb := NewCircuitBreaker(Config{
+		Name:             "test-time-window",
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		Window: WindowConfig{
+			Type:                 WindowTime,
+			Duration:             10 * time.Second,
+			MinimumCalls:         4,
+			FailureRateThreshold: 50,
+		},
+	})
+	cb.now = func() time.Time { return now }
+
+	// Ошибки старше окна не учитываются
+	runCalls(cb, 3, 1)
+	now = now.Add(11 * time.Second)
+	runCalls(cb, 3, 0)
+	runCalls(cb, 1, 1)
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected expired failures to be ignored, got %v", cb.State())
+	}
+
+	runCalls(cb, 2, 1)
+	if cb.State() != StateOpen {
+		t.Errorf("Expected Open at 50%% failures within window, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreaker_ClassifierIgnoresErrors(t *testing.T) {
+	errInvalid := errors.New("invalid input")
+	cb := NewCircuitBreaker(Config{
+		Name:             "test",
+		FailureThreshold: 2,
+		ResetTimeout:     time.Second,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		IsFailure: func(ctx context.Context, err error) bool {
+			return !errors.Is(err, errInvalid) && DefaultClassifier(ctx, err)
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := cb.Execute(context.Background(), func() error { return errInvalid }); err != errInvalid {
+			t.Fatalf("Expected ignored error to be returned, got %v", err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("Ignored errors must not open the breaker, got %v", cb.State())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		_ = cb.Execute(ctx, func() error { return ctx.Err() })
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Caller cancellation must not open the breaker, got %v", cb.State())
+	}
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	<-expired.Done()
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"nil error", context.Background(), nil, false},
+		{"dependency error", context.Background(), errors.New("boom"), true},
+		{"caller canceled", canceled, context.Canceled, false},
+		{"canceled error", context.Background(), context.Canceled, false},
+		{"caller deadline", expired, context.DeadlineExceeded, true},
+	}
+	for _, tc := range cases {
+		if got := DefaultClassifier(tc.ctx, tc.err); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestCircuitBreaker_IgnoredErrorReleasesHalfOpenSlot(t *testing.T) {
+	errInvalid := errors.New("invalid input")
+	cb := NewCircuitBreaker(Config{
+		Name:             "test",
+		FailureThreshold: 1,
+		ResetTimeout:     10 * time.Millisecond,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		IsFailure: func(ctx context.Context, err error) bool {
+			return !errors.Is(err, errInvalid)
+		},
+	})
+
+	_ = cb.Execute(context.Background(), func() error { return errors.New("boom") })
+	time.Sleep(20 * time.Millisecond)
+
+	if err := cb.Execute(context.Background(), func() error { return errInvalid }); err != errInvalid {
+		t.Fatalf("Expected probe to run, got %v", err)
+	}
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("Ignored probe must keep Half-Open, got %v", cb.State())
+	}
+	if err := cb.Execute(context.Background(), func() error { return nil }); err != nil {
+		t.Fatalf("Expected another probe to be allowed, got %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Expected breaker to close after successful probe, got %v", cb.State())
+	}
+}
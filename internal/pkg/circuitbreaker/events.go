@@ -0,0 +1,181 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// historySize сколько последних смен состояния хранит каждый Circuit Breaker
+const historySize = 50
+
+// Причины смены состояния
+const (
+	ReasonFailureThreshold = "failure_threshold" // FailureThreshold ошибок подряд
+	ReasonFailureRate      = "failure_rate"      // Доля ошибок в окне достигла порога
+	ReasonSlowCallRate     = "slow_call_rate"    // Доля медленных вызовов в окне достигла порога
+	ReasonProbeFailed      = "probe_failed"      // Пробный запрос в Half-Open завершился ошибкой
+	ReasonResetTimeout     = "reset_timeout"     // Истёк ResetTimeout, пропускаются пробные запросы
+	ReasonRecovered        = "recovered"         // SuccessThreshold успешных пробных запросов
+	ReasonForcedOpen       = "forced_open"       // Открыт вручную
+	ReasonForcedClosed     = "forced_closed"     // Закрыт вручную
+	ReasonReset            = "reset"             // Сброшен вручную в автоматический режим
+)
+
+var circuitBreakerTransitions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "circuit_breaker_transitions_total",
+		Help: "Total number of circuit breaker state changes by target state and reason",
+	},
+	[]string{"name", "to", "reason"},
+)
+
+// Transition смена состояния Circuit Breaker
+type Transition struct {
+	Name      string    `json:"name"`
+	From      State     `json:"from"`
+	To        State     `json:"to"`
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Listener получает уведомления о сменах состояния; вызывается синхронно, вне блокировки Circuit Breaker
+type Listener func(Transition)
+
+// Status состояние Circuit Breaker для административного API
+type Status struct {
+	Name            string       `json:"name"`
+	State           State        `json:"state"`
+	Forced          bool         `json:"forced"`
+	LastStateChange time.Time    `json:"last_state_change"`
+	Failures        int          `json:"consecutive_failures"`
+	FailureRate     float64      `json:"failure_rate"`
+	SlowCallRate    float64      `json:"slow_call_rate"`
+	LastError       string       `json:"last_error,omitempty"`
+	History         []Transition `json:"history"`
+}
+
+// MarshalText сериализует состояние строкой (Closed, Open, HalfOpen)
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText разбирает строковое представление состояния
+func (s *State) UnmarshalText(text []byte) error {
+	for _, state := range []State{StateClosed, StateOpen, StateHalfOpen} {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown circuit breaker state %q", text)
+}
+
+// Name возвращает имя Circuit Breaker
+func (cb *CircuitBreaker) Name() string {
+	return cb.config.Name
+}
+
+// OnStateChange подписывает listener на смены состояния
+func (cb *CircuitBreaker) OnStateChange(listener Listener) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.listeners = append(cb.listeners, listener)
+}
+
+// History возвращает последние смены состояния, от старых к новым
+func (cb *CircuitBreaker) History() []Transition {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return append([]Transition(nil), cb.history...)
+}
+
+// Status возвращает текущее состояние, счётчики и историю переходов
+func (cb *CircuitBreaker) Status() Status {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	status := Status{
+		Name:            cb.config.Name,
+		State:           cb.state,
+		Forced:          cb.forced,
+		LastStateChange: cb.lastStateChange,
+		Failures:        cb.failures,
+		LastError:       cb.lastError,
+		History:         append([]Transition(nil), cb.history...),
+	}
+	if cb.window != nil {
+		stats := cb.window.stats(cb.now())
+		status.FailureRate = stats.failureRate()
+		status.SlowCallRate = stats.slowRate()
+	}
+	return status
+}
+
+// ForceOpen открывает Circuit Breaker вручную (например, на время обслуживания зависимости).
+// Запросы отклоняются, пока не будет вызван ForceClose или Reset.
+func (cb *CircuitBreaker) ForceOpen(detail string) {
+	cb.mu.Lock()
+	defer cb.unlockAndNotify()
+	cb.forced = true
+	cb.toOpen(ReasonForcedOpen, detail)
+}
+
+// ForceClose закрывает Circuit Breaker вручную; ошибки запросов не откроют его до вызова Reset
+func (cb *CircuitBreaker) ForceClose(detail string) {
+	cb.mu.Lock()
+	defer cb.unlockAndNotify()
+	cb.forced = true
+	cb.toClosed(ReasonForcedClosed, detail)
+}
+
+// Reset закрывает Circuit Breaker, сбрасывает счётчики и возвращает автоматическое управление состоянием
+func (cb *CircuitBreaker) Reset(detail string) {
+	cb.mu.Lock()
+	defer cb.unlockAndNotify()
+	cb.forced = false
+	cb.lastError = ""
+	cb.toClosed(ReasonReset, detail)
+}
+
+// record меняет состояние и запоминает переход; вызывается под блокировкой
+func (cb *CircuitBreaker) record(to State, reason, detail string) {
+	transition := Transition{
+		Name:   cb.config.Name,
+		From:   cb.state,
+		To:     to,
+		Reason: reason,
+		Detail: detail,
+		At:     time.Now(),
+	}
+	if to == StateOpen {
+		transition.LastError = cb.lastError
+	}
+
+	cb.state = to
+	cb.lastStateChange = transition.At
+	if len(cb.history) == historySize {
+		cb.history = append(cb.history[:0], cb.history[1:]...)
+	}
+	cb.history = append(cb.history, transition)
+	cb.pending = append(cb.pending, transition)
+	circuitBreakerTransitions.WithLabelValues(cb.config.Name, to.String(), reason).Inc()
+}
+
+// unlockAndNotify снимает блокировку и уведомляет подписчиков о накопленных сменах состояния
+func (cb *CircuitBreaker) unlockAndNotify() {
+	pending := cb.pending
+	cb.pending = nil
+	listeners := cb.listeners
+	cb.mu.Unlock()
+
+	for _, transition := range pending {
+		for _, listener := range listeners {
+			listener(transition)
+		}
+	}
+}
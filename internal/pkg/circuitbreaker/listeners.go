@@ -0,0 +1,85 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogListener пишет смены состояния в лог: открытие — предупреждением, остальные — информационно
+func LogListener(log *zap.Logger) Listener {
+	return func(t Transition) {
+		fields := []zap.Field{
+			zap.String("circuit_breaker", t.Name),
+			zap.String("from", t.From.String()),
+			zap.String("to", t.To.String()),
+			zap.String("reason", t.Reason),
+		}
+		if t.Detail != "" {
+			fields = append(fields, zap.String("detail", t.Detail))
+		}
+		if t.LastError != "" {
+			fields = append(fields, zap.String("last_error", t.LastError))
+		}
+		if t.To == StateOpen {
+			log.Warn("Circuit breaker opened", fields...)
+			return
+		}
+		log.Info("Circuit breaker state changed", fields...)
+	}
+}
+
+// WebhookListener отправляет смены состояния POST запросом с JSON телом Transition.
+// Отправка асинхронная, чтобы медленный получатель не задерживал запросы через Circuit Breaker.
+func WebhookListener(url string, timeout time.Duration, log *zap.Logger) Listener {
+	client := &http.Client{Timeout: timeout}
+	return func(t Transition) {
+		go func() {
+			if err := postTransition(client, url, t); err != nil {
+				log.Warn("Failed to deliver circuit breaker webhook",
+					zap.String("circuit_breaker", t.Name),
+					zap.String("url", url),
+					zap.Error(err))
+			}
+		}()
+	}
+}
+
+// WebhookListenersFromEnv создаёт подписчиков для адресов из CIRCUIT_BREAKER_WEBHOOK_URLS (через запятую)
+func WebhookListenersFromEnv(log *zap.Logger) []Listener {
+	timeout := getEnvDurationWithDefault("CIRCUIT_BREAKER_WEBHOOK_TIMEOUT", 5*time.Second)
+	var listeners []Listener
+	for _, url := range strings.Split(getEnvWithDefault("CIRCUIT_BREAKER_WEBHOOK_URLS", ""), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			listeners = append(listeners, WebhookListener(url, timeout, log))
+		}
+	}
+	return listeners
+}
+
+func postTransition(client *http.Client, url string, t Transition) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry хранит Circuit Breaker по имени и раздаёт их смены состояния общим подписчикам
+type Registry struct {
+	mu        sync.RWMutex
+	breakers  map[string]*CircuitBreaker
+	listeners []Listener
+}
+
+// NewRegistry создаёт пустой реестр
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*CircuitBreaker)}
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultRegistry = NewRegistry()
+)
+
+// Default возвращает реестр, в котором регистрируются все создаваемые Circuit Breaker
+func Default() *Registry {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRegistry
+}
+
+// SetDefault задаёт реестр по умолчанию
+func SetDefault(r *Registry) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRegistry = r
+}
+
+// Register добавляет Circuit Breaker в реестр; Circuit Breaker с тем же именем заменяется
+func (r *Registry) Register(cb *CircuitBreaker) {
+	r.mu.Lock()
+	r.breakers[cb.Name()] = cb
+	r.mu.Unlock()
+	cb.OnStateChange(r.notify)
+}
+
+// Get возвращает Circuit Breaker по имени
+func (r *Registry) Get(name string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cb, ok := r.breakers[name]
+	return cb, ok
+}
+
+// All возвращает зарегистрированные Circuit Breaker, отсортированные по имени
+func (r *Registry) All() []*CircuitBreaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		breakers = append(breakers, cb)
+	}
+	sort.Slice(breakers, func(i, j int) bool { return breakers[i].Name() < breakers[j].Name() })
+	return breakers
+}
+
+// OnStateChange подписывает listener на смены состояния всех Circuit Breaker реестра, в том числе будущих
+func (r *Registry) OnStateChange(listener Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+func (r *Registry) notify(transition Transition) {
+	r.mu.RLock()
+	listeners := r.listeners
+	r.mu.RUnlock()
+	for _, listener := range listeners {
+		listener(transition)
+	}
+}
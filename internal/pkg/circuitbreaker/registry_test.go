@@ -0,0 +1,139 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestBreaker(name string) *CircuitBreaker {
+	return NewCircuitBreaker(Config{
+		Name:             name,
+		FailureThreshold: 2,
+		ResetTimeout:     10 * time.Millisecond,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	})
+}
+
+// callN выполняет n вызовов, каждый возвращает err
+func callN(cb *CircuitBreaker, n int, err error) {
+	for i := 0; i < n; i++ {
+		_ = cb.Execute(context.Background(), func() error { return err })
+	}
+}
+
+func TestCircuitBreaker_TransitionHistory(t *testing.T) {
+	cb := newTestBreaker("test-history")
+	var got []Transition
+	cb.OnStateChange(func(tr Transition) { got = append(got, tr) })
+
+	callN(cb, 2, errors.New("backend down"))
+	time.Sleep(20 * time.Millisecond)
+	callN(cb, 1, nil)
+
+	want := []struct {
+		to     State
+		reason string
+	}{
+		{StateOpen, ReasonFailureThreshold},
+		{StateHalfOpen, ReasonResetTimeout},
+		{StateClosed, ReasonRecovered},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d transitions, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].To != w.to || got[i].Reason != w.reason {
+			t.Errorf("Transition %d: expected %v/%s, got %v/%s", i, w.to, w.reason, got[i].To, got[i].Reason)
+		}
+	}
+	if got[0].LastError != "backend down" {
+		t.Errorf("Expected last error in open transition, got %q", got[0].LastError)
+	}
+	if history := cb.History(); len(history) != 3 || history[2].To != StateClosed {
+		t.Errorf("Expected history to match notifications, got %+v", history)
+	}
+}
+
+func TestCircuitBreaker_ForceOpenAndClose(t *testing.T) {
+	cb := newTestBreaker("test-forced")
+
+	cb.ForceOpen("maintenance")
+	time.Sleep(20 * time.Millisecond)
+	if err := cb.Execute(context.Background(), func() error { return nil }); err != ErrCircuitOpen {
+		t.Fatalf("Forced open breaker must not move to Half-Open, got %v", err)
+	}
+	var openErr *OpenError
+	if err := cb.Check(); !errors.As(err, &openErr) {
+		t.Errorf("Expected OpenError from Check, got %v", err)
+	}
+
+	cb.ForceClose("")
+	callN(cb, 5, errors.New("fail"))
+	if cb.State() != StateClosed {
+		t.Fatalf("Forced closed breaker must ignore failures, got %v", cb.State())
+	}
+
+	cb.Reset("")
+	callN(cb, 2, errors.New("fail"))
+	if cb.State() != StateOpen {
+		t.Errorf("Expected breaker to open again after Reset, got %v", cb.State())
+	}
+	if status := cb.Status(); status.Forced {
+		t.Error("Expected Reset to return automatic mode")
+	}
+}
+
+func TestRegistry_NotifiesListeners(t *testing.T) {
+	registry := NewRegistry()
+	previous := Default()
+	SetDefault(registry)
+	defer SetDefault(previous)
+
+	var got []Transition
+	registry.OnStateChange(func(tr Transition) { got = append(got, tr) })
+	cb := newTestBreaker("test-registry")
+
+	if found, ok := registry.Get("test-registry"); !ok || found != cb {
+		t.Fatal("Expected breaker to be registered on creation")
+	}
+	cb.ForceOpen("drill")
+	if len(got) != 1 || got[0].Reason != ReasonForcedOpen || got[0].Detail != "drill" {
+		t.Errorf("Expected forced_open notification, got %+v", got)
+	}
+	if all := registry.All(); len(all) != 1 {
+		t.Errorf("Expected one registered breaker, got %d", len(all))
+	}
+}
+
+func TestWebhookListener(t *testing.T) {
+	received := make(chan Transition, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tr Transition
+		if err := json.NewDecoder(r.Body).Decode(&tr); err != nil {
+			t.Errorf("Failed to decode webhook body: %v", err)
+		}
+		received <- tr
+	}))
+	defer srv.Close()
+
+	cb := newTestBreaker("test-webhook")
+	cb.OnStateChange(WebhookListener(srv.URL, time.Second, zap.NewNop()))
+	cb.ForceOpen("")
+
+	select {
+	case tr := <-received:
+		if tr.Name != "test-webhook" || tr.Reason != ReasonForcedOpen {
+			t.Errorf("Unexpected webhook payload: %+v", tr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Webhook was not delivered")
+	}
+}
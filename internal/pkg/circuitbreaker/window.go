@@ -0,0 +1,234 @@
+package circuitbreaker
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WindowType способ подсчёта ошибок в состоянии Closed
+type WindowType string
+
+const (
+	// WindowConsecutive открывает Circuit Breaker после FailureThreshold ошибок подряд (по умолчанию)
+	WindowConsecutive WindowType = "consecutive"
+	// WindowCount оценивает доли ошибок и медленных вызовов среди последних Size вызовов
+	WindowCount WindowType = "count"
+	// WindowTime оценивает доли ошибок и медленных вызовов за последние Duration
+	WindowTime WindowType = "time"
+)
+
+// timeWindowBuckets число корзин, на которые делится временное окно
+const timeWindowBuckets = 10
+
+// WindowConfig настройки скользящего окна
+type WindowConfig struct {
+	Type WindowType
+	// Size число последних вызовов для окна count
+	Size int
+	// Duration длина окна time
+	Duration time.Duration
+	// MinimumCalls минимум вызовов в окне, прежде чем доли начинают оцениваться
+	MinimumCalls int
+	// FailureRateThreshold доля ошибок в процентах, при которой Circuit Breaker открывается (0 — не оценивается)
+	FailureRateThreshold float64
+	// SlowCallDuration вызов дольше этого считается медленным (0 — не оценивается)
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold доля медленных вызовов в процентах, при которой Circuit Breaker открывается (0 — не оценивается)
+	SlowCallRateThreshold float64
+}
+
+// WindowConfigFromEnv читает настройки окна из переменных с префиксом, например CIRCUIT_BREAKER_
+func WindowConfigFromEnv(prefix string) WindowConfig {
+	return WindowConfig{
+		Type:                  WindowType(strings.ToLower(getEnvWithDefault(prefix+"WINDOW_TYPE", string(WindowConsecutive)))),
+		Size:                  getEnvIntWithDefault(prefix+"WINDOW_SIZE", 20),
+		Duration:              getEnvDurationWithDefault(prefix+"WINDOW_DURATION", 60*time.Second),
+		MinimumCalls:          getEnvIntWithDefault(prefix+"MINIMUM_CALLS", 10),
+		FailureRateThreshold:  getEnvFloatWithDefault(prefix+"FAILURE_RATE_THRESHOLD", 50),
+		SlowCallDuration:      getEnvDurationWithDefault(prefix+"SLOW_CALL_DURATION", 0),
+		SlowCallRateThreshold: getEnvFloatWithDefault(prefix+"SLOW_CALL_RATE_THRESHOLD", 0),
+	}
+}
+
+// windowStats вызовы, ошибки и медленные вызовы в окне
+type windowStats struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (s windowStats) failureRate() float64 {
+	if s.calls == 0 {
+		return 0
+	}
+	return float64(s.failures) * 100 / float64(s.calls)
+}
+
+func (s windowStats) slowRate() float64 {
+	if s.calls == 0 {
+		return 0
+	}
+	return float64(s.slow) * 100 / float64(s.calls)
+}
+
+// window скользящее окно результатов вызовов
+type window interface {
+	record(now time.Time, failed, slow bool)
+	stats(now time.Time) windowStats
+	reset()
+}
+
+// newWindow создаёт окно по настройкам; nil для режима consecutive
+func newWindow(cfg WindowConfig) window {
+	switch cfg.Type {
+	case WindowCount:
+		size := cfg.Size
+		if size < 1 {
+			size = 1
+		}
+		return &countWindow{outcomes: make([]outcome, size)}
+	case WindowTime:
+		duration := cfg.Duration
+		if duration <= 0 {
+			duration = time.Minute
+		}
+		width := duration / timeWindowBuckets
+		if width <= 0 {
+			width = time.Millisecond
+		}
+		return &timeWindow{width: width, buckets: make([]bucket, timeWindowBuckets)}
+	default:
+		return nil
+	}
+}
+
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// countWindow кольцевой буфер последних вызовов
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	filled   int
+	total    windowStats
+}
+
+func (w *countWindow) record(_ time.Time, failed, slow bool) {
+	if w.filled == len(w.outcomes) {
+		old := w.outcomes[w.next]
+		w.total.calls--
+		if old.failed {
+			w.total.failures--
+		}
+		if old.slow {
+			w.total.slow--
+		}
+	} else {
+		w.filled++
+	}
+	w.outcomes[w.next] = outcome{failed: failed, slow: slow}
+	w.next = (w.next + 1) % len(w.outcomes)
+	w.total.calls++
+	if failed {
+		w.total.failures++
+	}
+	if slow {
+		w.total.slow++
+	}
+}
+
+func (w *countWindow) stats(time.Time) windowStats {
+	return w.total
+}
+
+func (w *countWindow) reset() {
+	w.next, w.filled, w.total = 0, 0, windowStats{}
+}
+
+// bucket результаты вызовов за один интервал временного окна
+type bucket struct {
+	index int64
+	windowStats
+}
+
+// timeWindow окно из timeWindowBuckets корзин фиксированной ширины
+type timeWindow struct {
+	width   time.Duration
+	buckets []bucket
+}
+
+func (w *timeWindow) record(now time.Time, failed, slow bool) {
+	index := now.UnixNano() / int64(w.width)
+	b := &w.buckets[index%int64(len(w.buckets))]
+	if b.index != index {
+		*b = bucket{index: index}
+	}
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+func (w *timeWindow) stats(now time.Time) windowStats {
+	current := now.UnixNano() / int64(w.width)
+	var total windowStats
+	for _, b := range w.buckets {
+		if b.calls > 0 && current-b.index < int64(len(w.buckets)) {
+			total.calls += b.calls
+			total.failures += b.failures
+			total.slow += b.slow
+		}
+	}
+	return total
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
+
+// getEnvWithDefault возвращает значение переменной окружения или значение по умолчанию
+func getEnvWithDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvIntWithDefault возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloatWithDefault возвращает дробное значение переменной окружения или значение по умолчанию
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -31,186 +31,329 @@ type Config struct {
 	DialTimeout time.Duration
 	// IdleTimeout таймаут на получение соединения из пула
 	IdleTimeout time.Duration
+	// CleanupInterval период фонового удаления устаревших соединений
+	CleanupInterval time.Duration
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
 func DefaultConfig() Config {
 	return Config{
-		MinConns:    20,
-		MaxConns:    100,
-		MaxIdleTime: 2 * time.Minute,
-		MaxLifetime: 30 * time.Minute,
-		DialTimeout: 3 * time.Second,
-		IdleTimeout: 10 * time.Second,
+		MinConns:        20,
+		MaxConns:        100,
+		MaxIdleTime:     2 * time.Minute,
+		MaxLifetime:     30 * time.Minute,
+		DialTimeout:     3 * time.Second,
+		IdleTimeout:     10 * time.Second,
+		CleanupInterval: time.Minute,
 	}
 }
 
-// Connection представляет соединение в пуле
-type Connection struct {
-	conn       interface{}
+// Factory создаёт, проверяет и закрывает ресурсы пула
+type Factory[T any] struct {
+	// Dial создаёт новый ресурс
+	Dial func(ctx context.Context) (T, error)
+	// Close закрывает ресурс (необязательно)
+	Close func(T) error
+	// Check проверяет ресурс перед выдачей; ресурс с ошибкой закрывается (необязательно)
+	Check func(ctx context.Context, value T) error
+}
+
+// Conn ресурс, выданный пулом
+type Conn[T any] struct {
+	value      T
 	createdAt  time.Time
 	lastUsedAt time.Time
-	inUse      bool
-	closeOnce  sync.Once
-	closeFunc  func() error
 }
 
-// GetConn возвращает соединение
-func (c *Connection) GetConn() interface{} {
-	return c.conn
+// Value возвращает ресурс
+func (c *Conn[T]) Value() T {
+	return c.value
 }
 
-// Pool представляет пул соединений
-type Pool struct {
-	config     Config
-	logger     *zap.Logger
-	mu         sync.RWMutex
-	conns      []*Connection
-	closed     bool
-	dialFunc   func(context.Context) (interface{}, func() error, error)
-	cleanupCtx context.Context
-	cancel     context.CancelFunc
+// waiter ожидающий ресурс; nil в ch означает освобождённый слот, ресурс создаёт сам ожидающий
+type waiter[T any] struct {
+	ch chan *Conn[T]
 }
 
-// NewPool создает новый пул соединений
-func NewPool(config Config, logger *zap.Logger, dialFunc func(context.Context) (interface{}, func() error, error)) *Pool {
-	ctx, cancel := context.WithCancel(context.Background())
+// Pool пул ресурсов с ограничением размера и справедливым (FIFO) ожиданием
+type Pool[T any] struct {
+	config  Config
+	logger  *zap.Logger
+	factory Factory[T]
+	now     func() time.Time
+
+	mu sync.Mutex
+	// idle свободные ресурсы; последний добавленный выдаётся первым
+	idle []*Conn[T]
+	// open число созданных ресурсов и зарезервированных под создание слотов
+	open    int
+	waiters []*waiter[T]
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
 
-	p := &Pool{
-		config:     config,
-		logger:     logger,
-		dialFunc:   dialFunc,
-		cleanupCtx: ctx,
-		cancel:     cancel,
+// NewPool создаёт пул и заранее создаёт MinConns ресурсов
+func NewPool[T any](config Config, logger *zap.Logger, factory Factory[T]) *Pool[T] {
+	if config.MaxConns < 1 {
+		config.MaxConns = 1
+	}
+	if config.MinConns > config.MaxConns {
+		config.MinConns = config.MaxConns
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Minute
+	}
+	if logger == nil {
+		logger = zap.NewNop()
 	}
 
-	// Создаем минимальное количество соединений
-	for i := 0; i < config.MinConns; i++ {
-		if err := p.createConnection(); err != nil {
-			logger.Error("failed to create initial connection", zap.Error(err))
-		}
+	p := &Pool[T]{
+		config:  config,
+		logger:  logger,
+		factory: factory,
+		now:     time.Now,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
 	}
+	p.fill()
 
-	// Запускаем горутину для очистки старых соединений
 	go p.cleanup()
-
 	return p
 }
 
-// Get получает соединение из пула
-func (p *Pool) Get(ctx context.Context) (*Connection, error) {
+// Get выдаёт ресурс: свободный, новый (если не достигнут MaxConns) или освобождённый другим вызовом.
+// Ожидающие обслуживаются в порядке очереди; ожидание прерывается ctx или IdleTimeout.
+func (p *Pool[T]) Get(ctx context.Context) (*Conn[T], error) {
 	start := time.Now()
 	defer func() {
 		metrics.ConnectionPoolGetDuration.Observe(time.Since(start).Seconds())
 	}()
 
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	for {
+		conn, reserved, err := p.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return p.dial(ctx)
+		}
+		if p.factory.Check != nil {
+			if err := p.factory.Check(ctx, conn.value); err != nil {
+				metrics.ConnectionPoolErrors.WithLabelValues("check").Inc()
+				p.logger.Debug("pooled connection failed health check", zap.Error(err))
+				p.closeConn(conn, "check_failed")
+				p.releaseSlot()
+				continue
+			}
+		}
+		conn.lastUsedAt = p.now()
+		metrics.ConnectionPoolActiveConnections.Inc()
+		return conn, nil
+	}
+}
 
+// acquire возвращает свободный ресурс или резервирует слот для создания нового (reserved)
+func (p *Pool[T]) acquire(ctx context.Context) (*Conn[T], bool, error) {
+	p.mu.Lock()
 	if p.closed {
-		return nil, ErrPoolClosed
+		p.mu.Unlock()
+		return nil, false, ErrPoolClosed
 	}
 
-	// Ищем свободное соединение
-	for _, conn := range p.conns {
-		if !conn.inUse && !p.isStale(conn) {
-			conn.inUse = true
-			conn.lastUsedAt = time.Now()
-			metrics.ConnectionPoolActiveConnections.Set(float64(p.activeConnections()))
-			return conn, nil
+	// Новые запросы не обгоняют уже ожидающих
+	var stale []*Conn[T]
+	if len(p.waiters) == 0 {
+		for len(p.idle) > 0 {
+			conn := p.idle[len(p.idle)-1]
+			p.idle = p.idle[:len(p.idle)-1]
+			if p.isStale(conn) {
+				p.open--
+				stale = append(stale, conn)
+				continue
+			}
+			p.mu.Unlock()
+			p.closeAll(stale, "stale")
+			return conn, false, nil
 		}
-	}
-
-	// Если есть место для нового соединения, создаем его
-	if len(p.conns) < p.config.MaxConns {
-		conn, err := p.createConnectionLocked()
-		if err != nil {
-			metrics.ConnectionPoolErrors.WithLabelValues("create").Inc()
-			return nil, err
+		if p.open < p.config.MaxConns {
+			p.open++
+			p.mu.Unlock()
+			p.closeAll(stale, "stale")
+			return nil, true, nil
 		}
-		metrics.ConnectionPoolActiveConnections.Set(float64(p.activeConnections()))
-		return conn, nil
 	}
 
-	// Ждем освобождения соединения
+	w := &waiter[T]{ch: make(chan *Conn[T], 1)}
+	p.waiters = append(p.waiters, w)
+	metrics.ConnectionPoolWaitingRequests.Inc()
+	p.mu.Unlock()
+	p.closeAll(stale, "stale")
+
 	timer := time.NewTimer(p.config.IdleTimeout)
 	defer timer.Stop()
 
-	p.mu.Unlock()
 	select {
+	case conn, ok := <-w.ch:
+		if !ok {
+			return nil, false, ErrPoolClosed
+		}
+		return conn, conn == nil, nil
 	case <-ctx.Done():
-		p.mu.Lock()
 		metrics.ConnectionPoolErrors.WithLabelValues("timeout").Inc()
-		return nil, ctx.Err()
+		p.abandon(w)
+		return nil, false, ctx.Err()
 	case <-timer.C:
-		p.mu.Lock()
 		metrics.ConnectionPoolErrors.WithLabelValues("exhausted").Inc()
-		return nil, ErrPoolExhausted
+		p.abandon(w)
+		return nil, false, ErrPoolExhausted
 	}
 }
 
-// Put возвращает соединение в пул
-func (p *Pool) Put(conn *Connection) {
+// abandon убирает ожидающего из очереди; если ресурс уже был передан, возвращает его в пул
+func (p *Pool[T]) abandon(w *waiter[T]) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	for i, other := range p.waiters {
+		if other == w {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			metrics.ConnectionPoolWaitingRequests.Dec()
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.mu.Unlock()
 
-	if p.closed {
-		conn.closeOnce.Do(func() {
-			if err := conn.closeFunc(); err != nil {
-				p.logger.Error("failed to close connection", zap.Error(err))
-			}
-		})
-		return
+	// Ресурс или слот передан одновременно с отменой
+	conn, ok := <-w.ch
+	switch {
+	case !ok:
+		// Пул закрыт, слот не выделялся
+	case conn != nil:
+		p.release(conn)
+	default:
+		p.releaseSlot()
+	}
+}
+
+// dial создаёт ресурс в зарезервированном слоте
+func (p *Pool[T]) dial(ctx context.Context) (*Conn[T], error) {
+	dialCtx := ctx
+	if p.config.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, p.config.DialTimeout)
+		defer cancel()
 	}
+	value, err := p.factory.Dial(dialCtx)
+	if err != nil {
+		metrics.ConnectionPoolErrors.WithLabelValues("create").Inc()
+		p.releaseSlot()
+		return nil, err
+	}
+	now := p.now()
+	metrics.ConnectionPoolCreatedConnections.Inc()
+	metrics.ConnectionPoolTotalConnections.Inc()
+	metrics.ConnectionPoolActiveConnections.Inc()
+	return &Conn[T]{value: value, createdAt: now, lastUsedAt: now}, nil
+}
+
+// Put возвращает ресурс в пул; первый ожидающий получает его сразу
+func (p *Pool[T]) Put(conn *Conn[T]) {
+	metrics.ConnectionPoolActiveConnections.Dec()
+	conn.lastUsedAt = p.now()
+	p.release(conn)
+}
 
-	conn.inUse = false
-	conn.lastUsedAt = time.Now()
-	metrics.ConnectionPoolActiveConnections.Set(float64(p.activeConnections()))
+// Discard закрывает неисправный ресурс вместо возврата в пул
+func (p *Pool[T]) Discard(conn *Conn[T]) {
+	metrics.ConnectionPoolActiveConnections.Dec()
+	p.closeConn(conn, "discarded")
+	p.releaseSlot()
 }
 
-// Close закрывает пул и все соединения
-func (p *Pool) Close() error {
+// release возвращает ресурс свободным или передаёт ожидающему
+func (p *Pool[T]) release(conn *Conn[T]) {
+	p.mu.Lock()
+	if p.closed || p.isStale(conn) {
+		reason := "stale"
+		if p.closed {
+			reason = "closed"
+		}
+		p.mu.Unlock()
+		p.closeConn(conn, reason)
+		p.releaseSlot()
+		return
+	}
+	if w := p.popWaiterLocked(); w != nil {
+		w.ch <- conn
+		p.mu.Unlock()
+		return
+	}
+	p.idle = append(p.idle, conn)
+	p.mu.Unlock()
+}
+
+// releaseSlot освобождает слот закрытого ресурса; первый ожидающий получает право создать новый
+func (p *Pool[T]) releaseSlot() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if !p.closed {
+		if w := p.popWaiterLocked(); w != nil {
+			w.ch <- nil
+			return
+		}
+	}
+	p.open--
+}
 
-	if p.closed {
+func (p *Pool[T]) popWaiterLocked() *waiter[T] {
+	if len(p.waiters) == 0 {
 		return nil
 	}
+	w := p.waiters[0]
+	p.waiters = p.waiters[1:]
+	metrics.ConnectionPoolWaitingRequests.Dec()
+	return w
+}
 
+// Close закрывает пул и свободные ресурсы; выданные ресурсы закрываются при возврате
+func (p *Pool[T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
 	p.closed = true
-	p.cancel()
+	idle := p.idle
+	p.idle = nil
+	p.open -= len(idle)
+	for _, w := range p.waiters {
+		close(w.ch)
+		metrics.ConnectionPoolWaitingRequests.Dec()
+	}
+	p.waiters = nil
+	close(p.stop)
+	p.mu.Unlock()
 
+	<-p.done
 	var lastErr error
-	for _, conn := range p.conns {
-		conn.closeOnce.Do(func() {
-			if err := conn.closeFunc(); err != nil {
-				lastErr = err
-				p.logger.Error("failed to close connection", zap.Error(err))
-			}
-		})
+	for _, conn := range idle {
+		if err := p.closeConn(conn, "closed"); err != nil {
+			lastErr = err
+		}
 	}
-
-	p.conns = nil
-	metrics.ConnectionPoolActiveConnections.Set(0)
 	return lastErr
 }
 
 // Stats возвращает статистику пула
-func (p *Pool) Stats() Stats {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
-	active := 0
-	for _, conn := range p.conns {
-		if conn.inUse {
-			active++
-		}
-	}
-
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return Stats{
-		TotalConnections:  len(p.conns),
-		ActiveConnections: active,
-		IdleConnections:   len(p.conns) - active,
+		TotalConnections:  p.open,
+		ActiveConnections: p.open - len(p.idle),
+		IdleConnections:   len(p.idle),
+		WaitingRequests:   len(p.waiters),
 	}
 }
 
@@ -219,104 +362,90 @@ type Stats struct {
 	TotalConnections  int
 	ActiveConnections int
 	IdleConnections   int
+	WaitingRequests   int
 }
 
-// createConnection создает новое соединение
-func (p *Pool) createConnection() error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	_, err := p.createConnectionLocked()
-	return err
-}
-
-// createConnectionLocked создает новое соединение (должен вызываться с блокировкой)
-func (p *Pool) createConnectionLocked() (*Connection, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), p.config.DialTimeout)
-	defer cancel()
-
-	conn, closeFunc, err := p.dialFunc(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	c := &Connection{
-		conn:       conn,
-		createdAt:  time.Now(),
-		lastUsedAt: time.Now(),
-		inUse:      true,
-		closeFunc:  closeFunc,
-	}
-
-	p.conns = append(p.conns, c)
-	metrics.ConnectionPoolTotalConnections.Set(float64(len(p.conns)))
-	return c, nil
-}
-
-// cleanup периодически очищает старые соединения
-func (p *Pool) cleanup() {
-	ticker := time.NewTicker(time.Minute)
+// cleanup периодически удаляет устаревшие свободные ресурсы и поддерживает MinConns
+func (p *Pool[T]) cleanup() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.config.CleanupInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-p.cleanupCtx.Done():
+		case <-p.stop:
 			return
 		case <-ticker.C:
 			p.removeStaleConnections()
+			p.fill()
 		}
 	}
 }
 
-// removeStaleConnections удаляет старые соединения
-func (p *Pool) removeStaleConnections() {
+// removeStaleConnections закрывает свободные ресурсы с истёкшим временем жизни или простоя
+func (p *Pool[T]) removeStaleConnections() {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	var remaining []*Connection
-	for _, conn := range p.conns {
-		if !conn.inUse && p.isStale(conn) {
-			conn.closeOnce.Do(func() {
-				if err := conn.closeFunc(); err != nil {
-					p.logger.Error("failed to close stale connection", zap.Error(err))
-				}
-			})
-			metrics.ConnectionPoolRemovedConnections.WithLabelValues("stale").Inc()
+	var stale []*Conn[T]
+	remaining := p.idle[:0]
+	for _, conn := range p.idle {
+		if p.isStale(conn) {
+			stale = append(stale, conn)
 			continue
 		}
 		remaining = append(remaining, conn)
 	}
+	p.idle = remaining
+	p.mu.Unlock()
 
-	// Проверяем, нужно ли создать новые соединения
-	if len(remaining) < p.config.MinConns {
-		needed := p.config.MinConns - len(remaining)
-		for i := 0; i < needed; i++ {
-			if conn, err := p.createConnectionLocked(); err != nil {
-				p.logger.Error("failed to create replacement connection", zap.Error(err))
-			} else {
-				conn.inUse = false
-				metrics.ConnectionPoolCreatedConnections.Inc()
-			}
+	for _, conn := range stale {
+		p.closeConn(conn, "stale")
+		p.releaseSlot()
+	}
+}
+
+// fill создаёт свободные ресурсы до MinConns
+func (p *Pool[T]) fill() {
+	for {
+		p.mu.Lock()
+		if p.closed || p.open >= p.config.MinConns || len(p.waiters) > 0 {
+			p.mu.Unlock()
+			return
 		}
+		p.open++
+		p.mu.Unlock()
+
+		conn, err := p.dial(context.Background())
+		if err != nil {
+			p.logger.Error("failed to create pooled connection", zap.Error(err))
+			return
+		}
+		p.Put(conn)
 	}
+}
 
-	p.conns = remaining
-	metrics.ConnectionPoolTotalConnections.Set(float64(len(p.conns)))
+// isStale проверяет, истекло ли время жизни или простоя ресурса
+func (p *Pool[T]) isStale(conn *Conn[T]) bool {
+	now := p.now()
+	return (p.config.MaxLifetime > 0 && now.Sub(conn.createdAt) > p.config.MaxLifetime) ||
+		(p.config.MaxIdleTime > 0 && now.Sub(conn.lastUsedAt) > p.config.MaxIdleTime)
 }
 
-// isStale проверяет, является ли соединение устаревшим
-func (p *Pool) isStale(conn *Connection) bool {
-	now := time.Now()
-	return now.Sub(conn.createdAt) > p.config.MaxLifetime ||
-		now.Sub(conn.lastUsedAt) > p.config.MaxIdleTime
+func (p *Pool[T]) closeAll(conns []*Conn[T], reason string) {
+	for _, conn := range conns {
+		p.closeConn(conn, reason)
+	}
 }
 
-// activeConnections возвращает количество активных соединений
-func (p *Pool) activeConnections() int {
-	active := 0
-	for _, conn := range p.conns {
-		if conn.inUse {
-			active++
-		}
+// closeConn закрывает ресурс; слот освобождает вызывающий
+func (p *Pool[T]) closeConn(conn *Conn[T], reason string) error {
+	metrics.ConnectionPoolRemovedConnections.WithLabelValues(reason).Inc()
+	metrics.ConnectionPoolTotalConnections.Dec()
+	if p.factory.Close == nil {
+		return nil
+	}
+	if err := p.factory.Close(conn.value); err != nil {
+		p.logger.Error("failed to close connection", zap.Error(err))
+		return err
 	}
-	return active
+	return nil
 }
@@ -0,0 +1,211 @@
+package connpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	id     int64
+	closed atomic.Bool
+}
+
+func newTestPool(t *testing.T, config Config, check func(context.Context, *testConn) error) (*Pool[*testConn], *atomic.Int64) {
+	t.Helper()
+	var dialed atomic.Int64
+	p := NewPool(config, nil, Factory[*testConn]{
+		Dial: func(ctx context.Context) (*testConn, error) {
+			return &testConn{id: dialed.Add(1)}, nil
+		},
+		Close: func(c *testConn) error {
+			c.closed.Store(true)
+			return nil
+		},
+		Check: check,
+	})
+	t.Cleanup(func() { _ = p.Close() })
+	return p, &dialed
+}
+
+func testConfig(maxConns int) Config {
+	return Config{
+		MaxConns:        maxConns,
+		MaxIdleTime:     time.Minute,
+		MaxLifetime:     time.Hour,
+		DialTimeout:     time.Second,
+		IdleTimeout:     time.Second,
+		CleanupInterval: time.Hour,
+	}
+}
+
+func TestPool_WaiterIsWokenByPut(t *testing.T) {
+	p, dialed := newTestPool(t, testConfig(1), nil)
+
+	conn, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := make(chan *Conn[*testConn], 1)
+	go func() {
+		c, err := p.Get(context.Background())
+		if err != nil {
+			t.Errorf("Waiter failed: %v", err)
+		}
+		got <- c
+	}()
+
+	for p.Stats().WaitingRequests == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Put(conn)
+
+	select {
+	case c := <-got:
+		if c.Value() != conn.Value() {
+			t.Error("Expected waiter to receive the released connection")
+		}
+		p.Put(c)
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waiter was not woken by Put")
+	}
+	if dialed.Load() != 1 {
+		t.Errorf("Expected a single dial, got %d", dialed.Load())
+	}
+}
+
+func TestPool_FIFOWaiters(t *testing.T) {
+	p, _ := newTestPool(t, testConfig(1), nil)
+	conn, _ := p.Get(context.Background())
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := p.Get(context.Background())
+			if err != nil {
+				t.Errorf("Waiter %d failed: %v", i, err)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			p.Put(c)
+		}(i)
+		// Дожидаемся постановки в очередь, чтобы порядок был детерминированным
+		for p.Stats().WaitingRequests != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	p.Put(conn)
+	wg.Wait()
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("Expected FIFO order, got %v", order)
+		}
+	}
+}
+
+func TestPool_ContextCancellation(t *testing.T) {
+	p, _ := newTestPool(t, testConfig(1), nil)
+	conn, _ := p.Get(context.Background())
+	defer p.Put(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if s := p.Stats(); s.WaitingRequests != 0 {
+		t.Errorf("Expected cancelled waiter to leave the queue, got %+v", s)
+	}
+}
+
+func TestPool_ExhaustedAfterIdleTimeout(t *testing.T) {
+	config := testConfig(1)
+	config.IdleTimeout = 20 * time.Millisecond
+	p, _ := newTestPool(t, config, nil)
+	conn, _ := p.Get(context.Background())
+	defer p.Put(conn)
+
+	if _, err := p.Get(context.Background()); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("Expected ErrPoolExhausted, got %v", err)
+	}
+}
+
+func TestPool_CheckOnBorrow(t *testing.T) {
+	var failFirst atomic.Bool
+	failFirst.Store(true)
+	p, dialed := newTestPool(t, testConfig(2), func(ctx context.Context, c *testConn) error {
+		if c.id == 1 && failFirst.Load() {
+			return errors.New("broken")
+		}
+		return nil
+	})
+
+	conn, _ := p.Get(context.Background())
+	first := conn.Value()
+	p.Put(conn)
+
+	conn, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer p.Put(conn)
+	if conn.Value() == first || !first.closed.Load() {
+		t.Error("Expected broken connection to be closed and replaced")
+	}
+	if dialed.Load() != 2 {
+		t.Errorf("Expected replacement dial, got %d dials", dialed.Load())
+	}
+}
+
+func TestPool_LifetimeAndIdleEviction(t *testing.T) {
+	now := time.Now()
+	config := testConfig(2)
+	config.MaxIdleTime = time.Minute
+	config.MaxLifetime = time.Hour
+	p, _ := newTestPool(t, config, nil)
+	p.now = func() time.Time { return now }
+
+	conn, _ := p.Get(context.Background())
+	first := conn.Value()
+	p.Put(conn)
+
+	now = now.Add(2 * time.Minute)
+	p.removeStaleConnections()
+	if !first.closed.Load() || p.Stats().TotalConnections != 0 {
+		t.Fatalf("Expected idle connection to be evicted, stats %+v", p.Stats())
+	}
+
+	conn, _ = p.Get(context.Background())
+	second := conn.Value()
+	now = now.Add(2 * time.Hour)
+	p.Put(conn)
+	if !second.closed.Load() {
+		t.Error("Expected connection past MaxLifetime to be closed on Put")
+	}
+}
+
+func TestPool_MinConnsAndClose(t *testing.T) {
+	config := testConfig(5)
+	config.MinConns = 3
+	p, dialed := newTestPool(t, config, nil)
+	if s := p.Stats(); s.IdleConnections != 3 || dialed.Load() != 3 {
+		t.Fatalf("Expected 3 idle connections, got %+v", s)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := p.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+}
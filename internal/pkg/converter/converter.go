@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+)
+
+// Имена бэкендов конвертации
+const (
+	BackendGotenberg   = "gotenberg"   // Gotenberg по HTTP (один бэкенд или балансировщик)
+	BackendLibreOffice = "libreoffice" // Локальный soffice --headless --convert-to pdf
+)
+
+// Converter бэкенд конвертации DOCX в PDF
+type Converter interface {
+	ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error)
+	// State возвращает состояние Circuit Breaker бэкенда
+	State() circuitbreaker.State
+	// IsHealthy возвращает true, если бэкенд может принимать запросы
+	IsHealthy() bool
+	// Check возвращает *circuitbreaker.OpenError, если бэкенд сейчас не примет конвертацию
+	Check() error
+	// Ready возвращает результат фоновой проверки бэкенда для readiness
+	Ready() bool
+}
+
+// Config выбор основного и запасного бэкендов
+type Config struct {
+	// Primary основной бэкенд
+	Primary string
+	// Fallback запасной бэкенд, используется при открытом Circuit Breaker основного (пусто — без запасного)
+	Fallback string
+}
+
+// ConfigFromEnv читает выбор бэкендов из CONVERTER_PRIMARY и CONVERTER_FALLBACK
+func ConfigFromEnv() Config {
+	return Config{
+		Primary:  strings.ToLower(strings.TrimSpace(getEnvWithDefault("CONVERTER_PRIMARY", BackendGotenberg))),
+		Fallback: strings.ToLower(strings.TrimSpace(os.Getenv("CONVERTER_FALLBACK"))),
+	}
+}
+
+// Uses возвращает true, если бэкенд выбран основным или запасным
+func (c Config) Uses(backend string) bool {
+	return c.Primary == backend || c.Fallback == backend
+}
+
+// New возвращает основной бэкенд, обёрнутый в Failover, если задан запасной
+func New(cfg Config, backends map[string]Converter) (Converter, error) {
+	primary, ok := backends[cfg.Primary]
+	if !ok {
+		return nil, fmt.Errorf("unknown converter backend %q", cfg.Primary)
+	}
+	if cfg.Fallback == "" || cfg.Fallback == "none" || cfg.Fallback == cfg.Primary {
+		return primary, nil
+	}
+	fallback, ok := backends[cfg.Fallback]
+	if !ok {
+		return nil, fmt.Errorf("unknown converter fallback backend %q", cfg.Fallback)
+	}
+	return NewFailover(cfg.Primary, primary, cfg.Fallback, fallback), nil
+}
+
+// getEnvWithDefault возвращает значение переменной окружения или значение по умолчанию
+func getEnvWithDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
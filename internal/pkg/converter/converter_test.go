@@ -0,0 +1,174 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/resilience"
+)
+
+// stubConverter бэкенд с заданным результатом
+type stubConverter struct {
+	result []byte
+	err    error
+	check  error
+	calls  int
+}
+
+func (s *stubConverter) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func (s *stubConverter) State() circuitbreaker.State { return circuitbreaker.StateClosed }
+func (s *stubConverter) IsHealthy() bool             { return s.check == nil }
+func (s *stubConverter) Check() error                { return s.check }
+func (s *stubConverter) Ready() bool                 { return s.check == nil }
+
+func TestFailover_UsesFallbackOnlyWhenPrimaryBreakerIsOpen(t *testing.T) {
+	openErr := &circuitbreaker.OpenError{Name: "gotenberg", RetryAfter: time.Second}
+	primary := &stubConverter{err: openErr, check: openErr}
+	fallback := &stubConverter{result: []byte("%PDF-local")}
+	f := NewFailover(BackendGotenberg, primary, BackendLibreOffice, fallback)
+
+	result, err := f.ConvertDocxToPDF(context.Background(), "in.docx")
+	if err != nil || string(result) != "%PDF-local" {
+		t.Fatalf("Expected fallback result, got %q, %v", result, err)
+	}
+	if err := f.Check(); err != nil {
+		t.Errorf("Expected fallback to keep converter available, got %v", err)
+	}
+
+	// Ошибка данных основного бэкенда не маскируется запасным
+	primary.err = errors.New("bad document")
+	fallback.calls = 0
+	if _, err := f.ConvertDocxToPDF(context.Background(), "in.docx"); err == nil || err.Error() != "bad document" {
+		t.Errorf("Expected primary error, got %v", err)
+	}
+	if fallback.calls != 0 {
+		t.Errorf("Expected no fallback calls, got %d", fallback.calls)
+	}
+
+	fallback.check = ErrLibreOfficeUnavailable
+	if err := f.Check(); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Errorf("Expected primary open error when both backends are down, got %v", err)
+	}
+}
+
+func TestNew_SelectsBackends(t *testing.T) {
+	gotenberg := &stubConverter{}
+	local := &stubConverter{}
+	backends := map[string]Converter{BackendGotenberg: gotenberg, BackendLibreOffice: local}
+
+	c, err := New(Config{Primary: BackendLibreOffice}, backends)
+	if err != nil || c != Converter(local) {
+		t.Errorf("Expected local backend as primary, got %v, %v", c, err)
+	}
+	c, err = New(Config{Primary: BackendGotenberg, Fallback: BackendLibreOffice}, backends)
+	if _, ok := c.(*Failover); err != nil || !ok {
+		t.Errorf("Expected failover converter, got %T, %v", c, err)
+	}
+	if _, err := New(Config{Primary: "unknown"}, backends); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+// fakeSoffice создаёт скрипт, который записывает аргументы и имитирует soffice --convert-to pdf
+func fakeSoffice(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake soffice requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "soffice")
+	script := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(dir, "args") + "\n" + body
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake soffice: %v", err)
+	}
+	return path
+}
+
+// newTestLibreOffice создаёт локальный бэкенд без отсека и с Circuit Breaker, открывающимся после одного сбоя
+func newTestLibreOffice(name, binary string) *LibreOffice {
+	return NewLibreOffice(LibreOfficeConfig{
+		Binary: binary,
+		Resilience: resilience.Settings{
+			Name:           name,
+			Order:          []resilience.Stage{resilience.StageCircuitBreaker, resilience.StageAttemptTimeout},
+			AttemptTimeout: 5 * time.Second,
+			Breaker:        circuitbreaker.Config{FailureThreshold: 1, ResetTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1},
+		},
+	})
+}
+
+func TestLibreOffice_ConvertsInIsolatedProfile(t *testing.T) {
+	// Последние аргументы: --outdir <dir> <docx>
+	binary := fakeSoffice(t, `for last; do :; done
+eval "outdir=\${$(($#-1))}"
+mkdir -p "$outdir"
+name=$(basename "$last" .docx)
+printf '%%PDF-%s' "$name" > "$outdir/$name.pdf"
+`)
+	l := newTestLibreOffice("libreoffice-test-ok", binary)
+
+	docx := filepath.Join(t.TempDir(), "report.docx")
+	if err := os.WriteFile(docx, []byte("docx"), 0644); err != nil {
+		t.Fatalf("Failed to write docx: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		result, err := l.ConvertDocxToPDF(context.Background(), docx)
+		if err != nil || string(result) != "%PDF-report" {
+			t.Fatalf("Expected converted pdf, got %q, %v", result, err)
+		}
+	}
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(binary), "args"))
+	if err != nil {
+		t.Fatalf("Failed to read args: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(args)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 invocations, got %d", len(lines))
+	}
+	profiles := make(map[string]bool)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if !strings.HasPrefix(fields[0], "-env:UserInstallation=file://") || !strings.Contains(line, "--headless") {
+			t.Errorf("Expected isolated headless invocation, got %q", line)
+		}
+		profile := strings.TrimPrefix(fields[0], "-env:UserInstallation=file://")
+		if _, err := os.Stat(profile); !os.IsNotExist(err) {
+			t.Errorf("Expected profile directory %s to be removed", profile)
+		}
+		profiles[profile] = true
+	}
+	if len(profiles) != 2 {
+		t.Errorf("Expected a separate profile per conversion, got %v", profiles)
+	}
+}
+
+func TestLibreOffice_MissingOutputOpensBreaker(t *testing.T) {
+	l := newTestLibreOffice("libreoffice-test-fail", fakeSoffice(t, "exit 0\n"))
+
+	if _, err := l.ConvertDocxToPDF(context.Background(), "broken.docx"); err == nil {
+		t.Fatal("Expected error when soffice produced no pdf")
+	}
+	if err := l.Check(); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Errorf("Expected open breaker after failure, got %v", err)
+	}
+
+	missing := newTestLibreOffice("libreoffice-test-missing", filepath.Join(t.TempDir(), "no-soffice"))
+	if err := missing.Check(); !errors.Is(err, ErrLibreOfficeUnavailable) {
+		t.Errorf("Expected ErrLibreOfficeUnavailable, got %v", err)
+	}
+	if missing.Ready() {
+		t.Error("Expected missing binary not to be ready")
+	}
+}
@@ -0,0 +1,89 @@
+package converter
+
+import (
+	"context"
+	"errors"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// failoversTotal конвертации, переданные запасному бэкенду
+var failoversTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "converter_failovers_total",
+		Help: "Total number of conversions handed to the fallback backend because the primary circuit breaker is open",
+	},
+	[]string{"primary", "fallback"},
+)
+
+// Failover конвертирует основным бэкендом и переключается на запасной, пока Circuit Breaker основного открыт.
+// Остальные ошибки основного бэкенда возвращаются как есть: запасной бэкенд не должен маскировать ошибки данных.
+type Failover struct {
+	primaryName  string
+	primary      Converter
+	fallbackName string
+	fallback     Converter
+}
+
+// NewFailover создаёт конвертер с автоматическим переключением на запасной бэкенд
+func NewFailover(primaryName string, primary Converter, fallbackName string, fallback Converter) *Failover {
+	return &Failover{
+		primaryName:  primaryName,
+		primary:      primary,
+		fallbackName: fallbackName,
+		fallback:     fallback,
+	}
+}
+
+// ConvertDocxToPDF конвертирует основным бэкендом, при открытом Circuit Breaker — запасным
+func (f *Failover) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	result, err := f.primary.ConvertDocxToPDF(ctx, docxPath)
+	if err != nil {
+		return f.failover(ctx, docxPath, err)
+	}
+	return result, nil
+}
+
+// failover передаёт конвертацию запасному бэкенду, если основной отказал из-за открытого Circuit Breaker
+func (f *Failover) failover(ctx context.Context, docxPath string, err error) ([]byte, error) {
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) || ctx.Err() != nil {
+		return nil, err
+	}
+	failoversTotal.WithLabelValues(f.primaryName, f.fallbackName).Inc()
+	log().Warn("Primary converter unavailable, using fallback",
+		zap.String("primary", f.primaryName),
+		zap.String("fallback", f.fallbackName),
+		zap.Error(err))
+	return f.fallback.ConvertDocxToPDF(ctx, docxPath)
+}
+
+// State возвращает состояние Circuit Breaker основного бэкенда
+func (f *Failover) State() circuitbreaker.State {
+	return f.primary.State()
+}
+
+// IsHealthy возвращает true, если запрос может принять основной или запасной бэкенд
+func (f *Failover) IsHealthy() bool {
+	return f.primary.IsHealthy() || f.fallback.IsHealthy()
+}
+
+// Check возвращает ошибку, только если ни основной, ни запасной бэкенд не примут конвертацию
+func (f *Failover) Check() error {
+	err := f.primary.Check()
+	if err == nil {
+		return nil
+	}
+	if f.fallback.Check() == nil {
+		return nil
+	}
+	return err
+}
+
+// Ready возвращает true, если готов основной или запасной бэкенд
+func (f *Failover) Ready() bool {
+	return f.primary.Ready() || f.fallback.Ready()
+}
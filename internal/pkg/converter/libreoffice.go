@@ -0,0 +1,205 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pdf-service-go/internal/pkg/bulkhead"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/resilience"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// libreOfficeDuration длительность локальных конвертаций
+var libreOfficeDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "libreoffice_conversion_duration_seconds",
+		Help:    "Duration of local LibreOffice DOCX to PDF conversions",
+		Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+	},
+	[]string{"status"},
+)
+
+// ErrLibreOfficeUnavailable исполняемый файл soffice не найден
+var ErrLibreOfficeUnavailable = errors.New("libreoffice is not installed")
+
+// LibreOfficeConfig настройки локального бэкенда
+type LibreOfficeConfig struct {
+	// Binary исполняемый файл soffice
+	Binary string
+	// Resilience таймаут, отсек и Circuit Breaker запуска soffice
+	Resilience resilience.Settings
+}
+
+// LibreOfficeConfigFromEnv читает настройки из LIBREOFFICE_BINARY, LIBREOFFICE_PIPELINE_*,
+// LIBREOFFICE_CIRCUIT_BREAKER_* и BULKHEAD_LIBREOFFICE_*
+func LibreOfficeConfigFromEnv() LibreOfficeConfig {
+	return LibreOfficeConfig{
+		Binary: getEnvWithDefault("LIBREOFFICE_BINARY", "soffice"),
+		Resilience: resilience.SettingsFromEnv(resilience.Settings{
+			Name: BackendLibreOffice,
+			// soffice медленный и тяжёлый: ограничиваем одновременные процессы, повторы не нужны.
+			// Таймаут внутри Circuit Breaker, чтобы зависший soffice учитывался как сбой
+			Order:          []resilience.Stage{resilience.StageBulkhead, resilience.StageCircuitBreaker, resilience.StageAttemptTimeout},
+			AttemptTimeout: 2 * time.Minute,
+			Breaker: circuitbreaker.Config{
+				FailureThreshold: 3,
+				ResetTimeout:     30 * time.Second,
+				HalfOpenMaxCalls: 1,
+				SuccessThreshold: 1,
+			},
+			Bulkhead: bulkhead.Config{MaxConcurrent: 2, QueueSize: 8, QueueTimeout: 30 * time.Second},
+		}, resilience.EnvPrefixes{Pipeline: "LIBREOFFICE_", Retry: "LIBREOFFICE_", Breaker: "LIBREOFFICE_"}),
+	}
+}
+
+// LibreOffice конвертирует DOCX в PDF локальным soffice --headless.
+// Каждая конвертация запускается с отдельным профилем пользователя во временной директории:
+// параллельные процессы soffice не делят профиль и не блокируют друг друга.
+type LibreOffice struct {
+	// binary полный путь к soffice; пустой, если исполняемый файл не найден при создании
+	binary   string
+	pipeline *resilience.Pipeline[[]byte]
+}
+
+// NewLibreOffice создаёт локальный бэкенд конвертации.
+// Путь к soffice ищется один раз: состав образа не меняется во время работы сервиса.
+func NewLibreOffice(cfg LibreOfficeConfig) *LibreOffice {
+	if cfg.Binary == "" {
+		cfg.Binary = "soffice"
+	}
+	if cfg.Resilience.Name == "" {
+		cfg.Resilience.Name = BackendLibreOffice
+	}
+	binary, err := exec.LookPath(cfg.Binary)
+	if err != nil {
+		log().Warn("LibreOffice binary not found, local conversion disabled",
+			zap.String("binary", cfg.Binary), zap.Error(err))
+		binary = ""
+	}
+	return &LibreOffice{
+		binary:   binary,
+		pipeline: resilience.Build[[]byte](cfg.Resilience, nil),
+	}
+}
+
+// ConvertDocxToPDF конвертирует DOCX в PDF через soffice --convert-to pdf
+func (l *LibreOffice) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	if !l.Ready() {
+		return nil, ErrLibreOfficeUnavailable
+	}
+	return l.pipeline.Execute(ctx, func(ctx context.Context) ([]byte, error) {
+		start := time.Now()
+		result, err := l.convert(ctx, docxPath)
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		libreOfficeDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+		return result, err
+	})
+}
+
+// convert запускает soffice с изолированным профилем и читает результат
+func (l *LibreOffice) convert(ctx context.Context, docxPath string) ([]byte, error) {
+	workDir, err := os.MkdirTemp("", "soffice_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create libreoffice work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	profileDir := filepath.Join(workDir, "profile")
+	outDir := filepath.Join(workDir, "out")
+	profileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(profileDir)}).String()
+
+	cmd := exec.CommandContext(ctx, l.binary,
+		"-env:UserInstallation="+profileURL,
+		"--headless",
+		"--invisible",
+		"--nodefault",
+		"--nolockcheck",
+		"--nologo",
+		"--norestore",
+		"--convert-to", "pdf",
+		"--outdir", outDir,
+		docxPath,
+	)
+	// soffice пишет в HOME даже с отдельным профилем; у пользователя nobody домашней директории нет
+	cmd.Env = append(os.Environ(), "HOME="+workDir)
+	configureCommand(cmd)
+
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("libreoffice conversion interrupted: %w", ctxErr)
+	}
+	if err != nil {
+		log().Error("LibreOffice conversion failed",
+			zap.Error(err),
+			zap.String("docx_path", docxPath),
+			zap.String("output", string(output)))
+		return nil, fmt.Errorf("libreoffice conversion failed: %w", err)
+	}
+
+	// soffice завершается с кодом 0, даже если не смог открыть документ, поэтому проверяем результат
+	name := strings.TrimSuffix(filepath.Base(docxPath), filepath.Ext(docxPath)) + ".pdf"
+	pdf, err := os.ReadFile(filepath.Join(outDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("libreoffice produced no pdf: %s: %w", bytes.TrimSpace(output), err)
+	}
+	return pdf, nil
+}
+
+// State возвращает состояние Circuit Breaker локального бэкенда
+func (l *LibreOffice) State() circuitbreaker.State {
+	if cb := l.pipeline.Breaker(); cb != nil {
+		return cb.State()
+	}
+	return circuitbreaker.StateClosed
+}
+
+// IsHealthy возвращает true, если soffice установлен и Circuit Breaker пропускает запросы
+func (l *LibreOffice) IsHealthy() bool {
+	if !l.Ready() {
+		return false
+	}
+	if cb := l.pipeline.Breaker(); cb != nil {
+		return cb.IsHealthy()
+	}
+	return true
+}
+
+// Check возвращает ошибку, если soffice не установлен или Circuit Breaker сейчас не пропустит конвертацию
+func (l *LibreOffice) Check() error {
+	if !l.Ready() {
+		return ErrLibreOfficeUnavailable
+	}
+	if cb := l.pipeline.Breaker(); cb != nil {
+		return cb.Check()
+	}
+	return nil
+}
+
+// Ready возвращает true, если исполняемый файл soffice доступен
+func (l *LibreOffice) Ready() bool {
+	return l.binary != ""
+}
+
+// log возвращает логгер сервиса или пустой логгер, если он не инициализирован (тесты)
+func log() *zap.Logger {
+	if logger.Log == nil {
+		return zap.NewNop()
+	}
+	return logger.Log
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package converter
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// configureCommand запускает soffice в отдельной группе процессов: при отмене завершается
+// и дочерний soffice.bin, а не только скрипт-обёртка
+func configureCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package converter
+
+import (
+	"os/exec"
+	"time"
+)
+
+// configureCommand ограничивает ожидание вывода после отмены soffice
+func configureCommand(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -2,6 +2,7 @@ package docxgen
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,6 +15,7 @@ import (
 	"pdf-service-go/internal/pkg/cache"
 	"pdf-service-go/internal/pkg/circuitbreaker"
 	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/resilience"
 	"pdf-service-go/internal/pkg/retry"
 	"pdf-service-go/internal/pkg/tracing"
 
@@ -64,31 +66,24 @@ var (
 	)
 )
 
+// exitCodeInvalidInput код выхода скрипта генерации, если шаблон или данные не удалось обработать
+const exitCodeInvalidInput = 2
+
 // Config содержит настройки для генератора DOCX
 type Config struct {
-	ScriptPath       string
-	FailureThreshold int
-	ResetTimeout     time.Duration
-	HalfOpenMaxCalls int
-	SuccessThreshold int
-	PodName          string
-	Namespace        string
-	CacheTTL         time.Duration
-	// Retry конфигурация
-	RetryMaxAttempts   int
-	RetryInitialDelay  time.Duration
-	RetryMaxDelay      time.Duration
-	RetryBackoffFactor float64
+	ScriptPath string
+	CacheTTL   time.Duration
+	// Resilience retry, Circuit Breaker и таймауты запуска скрипта
+	Resilience resilience.Settings
 }
 
 // Generator представляет генератор DOCX файлов с Circuit Breaker
 type Generator struct {
 	config       Config
-	cb           *circuitbreaker.CircuitBreaker
+	pipeline     *resilience.Pipeline[struct{}]
 	cache        *cache.Cache
 	tempManager  *TempManager
 	gotenbergURL string
-	retrier      *retry.Retrier
 }
 
 // getEnvWithDefault возвращает значение переменной окружения или значение по умолчанию
@@ -122,19 +117,25 @@ func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Dura
 // NewGenerator создает новый экземпляр генератора DOCX
 func NewGenerator(scriptPath string) *Generator {
 	config := Config{
-		ScriptPath:       scriptPath,
-		FailureThreshold: getEnvIntWithDefault("DOCX_CIRCUIT_BREAKER_FAILURE_THRESHOLD", 3),
-		ResetTimeout:     getEnvDurationWithDefault("DOCX_CIRCUIT_BREAKER_RESET_TIMEOUT", 5*time.Second),
-		HalfOpenMaxCalls: getEnvIntWithDefault("DOCX_CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS", 2),
-		SuccessThreshold: getEnvIntWithDefault("DOCX_CIRCUIT_BREAKER_SUCCESS_THRESHOLD", 2),
-		PodName:          os.Getenv("POD_NAME"),
-		Namespace:        os.Getenv("POD_NAMESPACE"),
-		CacheTTL:         getEnvDurationWithDefault("DOCX_TEMPLATE_CACHE_TTL", 5*time.Minute),
-		// Retry конфигурация
-		RetryMaxAttempts:   getEnvIntWithDefault("DOCX_RETRY_MAX_ATTEMPTS", 3),
-		RetryInitialDelay:  getEnvDurationWithDefault("DOCX_RETRY_INITIAL_DELAY", 100*time.Millisecond),
-		RetryMaxDelay:      getEnvDurationWithDefault("DOCX_RETRY_MAX_DELAY", 2*time.Second),
-		RetryBackoffFactor: float64(getEnvIntWithDefault("DOCX_RETRY_BACKOFF_FACTOR", 2)),
+		ScriptPath: scriptPath,
+		CacheTTL:   getEnvDurationWithDefault("DOCX_TEMPLATE_CACHE_TTL", 5*time.Minute),
+		// Переменные DOCX_PIPELINE_*, DOCX_RETRY_* и DOCX_CIRCUIT_BREAKER_*
+		Resilience: resilience.SettingsFromEnv(resilience.Settings{
+			Name: "docx-generator",
+			Retry: retry.Config{
+				MaxAttempts:   3,
+				InitialDelay:  100 * time.Millisecond,
+				MaxDelay:      2 * time.Second,
+				BackoffFactor: 2,
+			},
+			Breaker: circuitbreaker.Config{
+				FailureThreshold: 3,
+				ResetTimeout:     5 * time.Second,
+				HalfOpenMaxCalls: 2,
+				SuccessThreshold: 2,
+				IsFailure:        isGenerationFailure,
+			},
+		}, resilience.EnvPrefixes{Pipeline: "DOCX_", Retry: "DOCX_", Breaker: "DOCX_"}),
 	}
 
 	tempManager, err := NewTempManager(TempManagerConfig{
@@ -146,33 +147,12 @@ func NewGenerator(scriptPath string) *Generator {
 		logger.Error("Failed to create temp manager", zap.Error(err))
 	}
 
-	cb := circuitbreaker.NewCircuitBreaker(circuitbreaker.Config{
-		Name:             "docx-generator",
-		FailureThreshold: config.FailureThreshold,
-		ResetTimeout:     config.ResetTimeout,
-		HalfOpenMaxCalls: config.HalfOpenMaxCalls,
-		SuccessThreshold: config.SuccessThreshold,
-		PodName:          config.PodName,
-		Namespace:        config.Namespace,
-	})
-
-	// Создаем retrier с конфигурацией
-	retrier := retry.New(
-		"docx-generator",
-		logger.Log,
-		retry.WithMaxAttempts(config.RetryMaxAttempts),
-		retry.WithInitialDelay(config.RetryInitialDelay),
-		retry.WithMaxDelay(config.RetryMaxDelay),
-		retry.WithBackoffFactor(config.RetryBackoffFactor),
-	)
-
 	return &Generator{
 		config:       config,
-		cb:           cb,
+		pipeline:     resilience.Build[struct{}](config.Resilience, nil),
 		cache:        cache.NewCache(config.CacheTTL),
 		tempManager:  tempManager,
 		gotenbergURL: getEnvWithDefault("GOTENBERG_API_URL", "http://nas-pdf-service-gotenberg:3000"),
-		retrier:      retrier,
 	}
 }
 
@@ -237,75 +217,77 @@ func (g *Generator) Generate(ctx context.Context, templatePath, dataPath, output
 	outputDocx := filepath.Join(tempDir, fmt.Sprintf("output_%s.docx",
 		hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))))
 
-	// Оборачиваем выполнение Python-скрипта в retry механизм
-	err = g.retrier.Do(ctx, func(ctx context.Context) error {
-		return g.cb.Execute(ctx, func() error {
-			// Определяем, какую версию Python использовать
-			pythonCmd := "python"
-			if os.Getenv("PYTHON_IMPLEMENTATION") == "pypy3" {
-				pythonCmd = "pypy3"
-			}
+	// Выполняем Python-скрипт через конвейер устойчивости (retry и Circuit Breaker)
+	err = resilience.Do(ctx, g.pipeline, func(ctx context.Context) error {
+		// Определяем, какую версию Python использовать
+		pythonCmd := "python"
+		if os.Getenv("PYTHON_IMPLEMENTATION") == "pypy3" {
+			pythonCmd = "pypy3"
+		}
 
-			// Устанавливаем переменную окружения для параллельной обработки
-			cmd := exec.CommandContext(ctx, pythonCmd, g.config.ScriptPath, tempPath, dataPath, outputDocx)
-			cmd.Env = append(os.Environ(), "DOCX_PARALLEL_PROCESSING=true")
-
-			output, err := cmd.CombinedOutput()
-			// Логируем вывод Python-скрипта В ЛЮБОМ СЛУЧАЕ для отладки
-			if len(output) > 0 {
-				logger.Info("Python script output",
-					zap.String("script", g.config.ScriptPath),
-					zap.String("output", string(output)),
-				)
-			}
+		// Устанавливаем переменную окружения для параллельной обработки
+		cmd := exec.CommandContext(ctx, pythonCmd, g.config.ScriptPath, tempPath, dataPath, outputDocx)
+		cmd.Env = append(os.Environ(), "DOCX_PARALLEL_PROCESSING=true")
 
-			if err != nil {
-				logger.Error("Failed to generate DOCX",
-					zap.Error(err),
-					zap.String("template", tempPath),
-					zap.String("data", dataPath),
-					zap.String("output_docx", outputDocx),
-					zap.String("python_implementation", pythonCmd),
-				)
-				docxGenerationErrors.WithLabelValues("python_error", pythonImpl).Inc()
-				return err
-			}
+		output, err := cmd.CombinedOutput()
+		// Логируем вывод Python-скрипта В ЛЮБОМ СЛУЧАЕ для отладки
+		if len(output) > 0 {
+			logger.Info("Python script output",
+				zap.String("script", g.config.ScriptPath),
+				zap.String("output", string(output)),
+			)
+		}
 
-			// Используем буферизированное копирование для выходного файла
-			outputFile, err := os.Create(outputPath)
-			if err != nil {
-				logger.Error("Failed to create output file",
-					zap.Error(err))
-				return err
+		if err != nil {
+			logger.Error("Failed to generate DOCX",
+				zap.Error(err),
+				zap.String("template", tempPath),
+				zap.String("data", dataPath),
+				zap.String("output_docx", outputDocx),
+				zap.String("python_implementation", pythonCmd),
+			)
+			errorType := "python_error"
+			if isInvalidInput(err) {
+				errorType = "invalid_input"
 			}
-			defer outputFile.Close()
+			docxGenerationErrors.WithLabelValues(errorType, pythonImpl).Inc()
+			return err
+		}
 
-			inputFile, err := os.Open(outputDocx)
-			if err != nil {
-				logger.Error("Failed to open generated DOCX",
-					zap.Error(err))
-				return err
-			}
-			defer inputFile.Close()
+		// Используем буферизированное копирование для выходного файла
+		outputFile, err := os.Create(outputPath)
+		if err != nil {
+			logger.Error("Failed to create output file",
+				zap.Error(err))
+			return err
+		}
+		defer outputFile.Close()
 
-			bufCopy := make([]byte, 1024*1024) // 1MB buffer
-			if _, err = io.CopyBuffer(outputFile, inputFile, bufCopy); err != nil {
-				logger.Error("Failed to copy output file",
-					zap.Error(err))
-				return err
-			}
+		inputFile, err := os.Open(outputDocx)
+		if err != nil {
+			logger.Error("Failed to open generated DOCX",
+				zap.Error(err))
+			return err
+		}
+		defer inputFile.Close()
 
-			// Обновляем метрики
-			duration := time.Since(start).Seconds()
-			docxGenerationDuration.WithLabelValues("success", pythonImpl).Observe(duration)
-			docxGenerationTotal.WithLabelValues("success", pythonImpl).Inc()
+		bufCopy := make([]byte, 1024*1024) // 1MB buffer
+		if _, err = io.CopyBuffer(outputFile, inputFile, bufCopy); err != nil {
+			logger.Error("Failed to copy output file",
+				zap.Error(err))
+			return err
+		}
 
-			if fi, err := os.Stat(outputPath); err == nil {
-				docxFileSize.WithLabelValues("success", pythonImpl).Observe(float64(fi.Size()))
-			}
+		// Обновляем метрики
+		duration := time.Since(start).Seconds()
+		docxGenerationDuration.WithLabelValues("success", pythonImpl).Observe(duration)
+		docxGenerationTotal.WithLabelValues("success", pythonImpl).Inc()
 
-			return nil
-		})
+		if fi, err := os.Stat(outputPath); err == nil {
+			docxFileSize.WithLabelValues("success", pythonImpl).Observe(float64(fi.Size()))
+		}
+
+		return nil
 	})
 
 	if err != nil {
@@ -316,6 +298,18 @@ func (g *Generator) Generate(ctx context.Context, templatePath, dataPath, output
 	return nil
 }
 
+// isInvalidInput сообщает, что скрипт отклонил шаблон или данные запроса
+func isInvalidInput(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == exitCodeInvalidInput
+}
+
+// isGenerationFailure классификатор Circuit Breaker генератора: некорректный шаблон или данные
+// одного запроса и отмена вызывающим не считаются сбоем генерации
+func isGenerationFailure(ctx context.Context, err error) bool {
+	return !isInvalidInput(err) && circuitbreaker.DefaultClassifier(ctx, err)
+}
+
 // getStatus возвращает статус операции для метрик
 func (g *Generator) getStatus(err error) string {
 	if err == nil {
@@ -326,12 +320,17 @@ func (g *Generator) getStatus(err error) string {
 
 // State возвращает текущее состояние Circuit Breaker
 func (g *Generator) State() circuitbreaker.State {
-	return g.cb.State()
+	return g.pipeline.Breaker().State()
 }
 
 // IsHealthy возвращает true, если Circuit Breaker в здоровом состоянии
 func (g *Generator) IsHealthy() bool {
-	return g.cb.IsHealthy()
+	return g.pipeline.Breaker().IsHealthy()
+}
+
+// Check возвращает *circuitbreaker.OpenError, если Circuit Breaker сейчас не пропустит генерацию
+func (g *Generator) Check() error {
+	return g.pipeline.Breaker().Check()
 }
 
 // GeneratePDF генерирует PDF из DOCX шаблона
@@ -0,0 +1,75 @@
+package docxgen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	// Генератор пишет в глобальный логгер сервиса
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// newTestGenerator создаёт генератор, у которого вместо python в PATH скрипт, завершающийся с exitCode.
+// Circuit Breaker открывается после двух сбоев, повторов нет.
+func newTestGenerator(t *testing.T, exitCode string) (*Generator, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake python requires a POSIX shell")
+	}
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho 'simulated failure' >&2\nexit " + exitCode + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "python"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake python: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+	t.Setenv("PYTHON_IMPLEMENTATION", "")
+	t.Setenv("DOCX_RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("DOCX_CIRCUIT_BREAKER_FAILURE_THRESHOLD", "2")
+
+	dir := t.TempDir()
+	template := filepath.Join(dir, "template.docx")
+	if err := os.WriteFile(template, []byte("docx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewGenerator(filepath.Join(dir, "generate_docx.py")), template
+}
+
+func TestGenerator_IOFailureOpensBreaker(t *testing.T) {
+	g, template := newTestGenerator(t, "1")
+	output := filepath.Join(t.TempDir(), "out.docx")
+
+	for i := 0; i < 2; i++ {
+		if err := g.Generate(context.Background(), template, "data.json", output); err == nil {
+			t.Fatal("Expected generation error")
+		}
+	}
+	if err := g.Check(); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Errorf("Expected I/O failures to open the breaker, got %v", err)
+	}
+}
+
+func TestGenerator_InvalidInputKeepsBreakerClosed(t *testing.T) {
+	g, template := newTestGenerator(t, "2")
+	output := filepath.Join(t.TempDir(), "out.docx")
+
+	for i := 0; i < 3; i++ {
+		err := g.Generate(context.Background(), template, "data.json", output)
+		if !isInvalidInput(err) {
+			t.Fatalf("Expected invalid input error, got %v", err)
+		}
+	}
+	if err := g.Check(); err != nil {
+		t.Errorf("Expected invalid input not to open the breaker, got %v", err)
+	}
+}
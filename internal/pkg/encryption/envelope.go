@@ -0,0 +1,197 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Формат конверта (envelope):
+//
+//	magic(4) | len(keyID)(1) | keyID | nonce(12) | wrapped DEK(32+16) | nonce(12) | ciphertext+tag
+//
+// Данные шифруются случайным ключом данных (DEK), который в свою очередь
+// шифруется ключом из keyring (KEK). Заголовок используется как AAD.
+var envelopeMagic = []byte("PSE1")
+
+// textPrefix префикс зашифрованных строк, хранимых в БД: enc:v1:<keyID>:<base64>
+const textPrefix = "enc:v1:"
+
+const dekSize = 32
+
+// MaxHeaderSize максимальный размер заголовка конверта (magic, длина и идентификатор ключа):
+// его достаточно прочитать, чтобы узнать ключ шифрования через KeyID
+const MaxHeaderSize = 4 + 1 + 255
+
+// ErrMalformed возвращается для повреждённых или обрезанных конвертов
+var ErrMalformed = errors.New("malformed encrypted envelope")
+
+// IsEncrypted проверяет, являются ли данные зашифрованным конвертом
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// KeyID возвращает идентификатор ключа, которым зашифрован конверт
+func KeyID(data []byte) (string, bool) {
+	if !IsEncrypted(data) || len(data) < len(envelopeMagic)+1 {
+		return "", false
+	}
+	n := int(data[len(envelopeMagic)])
+	start := len(envelopeMagic) + 1
+	if len(data) < start+n {
+		return "", false
+	}
+	return string(data[start : start+n]), true
+}
+
+// NeedsRotation возвращает true, если данные не зашифрованы активным ключом
+func (k *Keyring) NeedsRotation(data []byte) bool {
+	if !k.Enabled() {
+		return false
+	}
+	id, ok := KeyID(data)
+	return !ok || id != k.active
+}
+
+// Encrypt шифрует данные активным ключом. Без keyring данные возвращаются как есть
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	if !k.Enabled() {
+		return plaintext, nil
+	}
+	kek, err := k.key(k.active)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(envelopeMagic)+1+len(k.active))
+	header = append(header, envelopeMagic...)
+	header = append(header, byte(len(k.active)))
+	header = append(header, k.active...)
+
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrapped, err := seal(kek, dek, header)
+	if err != nil {
+		return nil, err
+	}
+	body, err := seal(dek, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(header)+len(wrapped)+len(body))
+	out = append(out, header...)
+	out = append(out, wrapped...)
+	out = append(out, body...)
+	return out, nil
+}
+
+// Decrypt расшифровывает конверт. Незашифрованные данные (старые записи) возвращаются как есть
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	id, ok := KeyID(data)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	kek, err := k.key(id)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLen := len(envelopeMagic) + 1 + len(id)
+	header := data[:headerLen]
+	rest := data[headerLen:]
+
+	wrappedLen := 12 + dekSize + 16
+	if len(rest) < wrappedLen {
+		return nil, ErrMalformed
+	}
+	dek, err := open(kek, rest[:wrappedLen], header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	plaintext, err := open(dek, rest[wrappedLen:], header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptString шифрует строку для хранения в текстовой колонке БД
+func (k *Keyring) EncryptString(s string) (string, error) {
+	if !k.Enabled() || s == "" {
+		return s, nil
+	}
+	data, err := k.Encrypt([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	return textPrefix + k.active + ":" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptString расшифровывает строку из БД. Незашифрованные строки возвращаются как есть
+func (k *Keyring) DecryptString(s string) (string, error) {
+	if !strings.HasPrefix(s, textPrefix) {
+		return s, nil
+	}
+	_, encoded, ok := strings.Cut(strings.TrimPrefix(s, textPrefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := k.Decrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// ActiveStringPrefix возвращает префикс строк, зашифрованных активным ключом (для выборки в SQL)
+func (k *Keyring) ActiveStringPrefix() string {
+	return textPrefix + k.ActiveKeyID() + ":"
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
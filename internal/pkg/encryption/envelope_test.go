@@ -0,0 +1,140 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	kr, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	plaintext := []byte(`{"inn":"7707083893","phone":"+79990000000"}`)
+	data, err := kr.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(data) {
+		t.Fatal("expected envelope header")
+	}
+	if bytes.Contains(data, []byte("7707083893")) {
+		t.Error("ciphertext contains plaintext")
+	}
+	if id, _ := KeyID(data); id != "k1" {
+		t.Errorf("expected key id k1, got %q", id)
+	}
+
+	got, err := kr.Decrypt(data)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("expected %q, got %q", plaintext, got)
+	}
+}
+
+func TestDecryptPlaintextPassthrough(t *testing.T) {
+	var kr *Keyring
+	got, err := kr.Decrypt([]byte("legacy"))
+	if err != nil || string(got) != "legacy" {
+		t.Errorf("expected passthrough, got %q, %v", got, err)
+	}
+	s, err := kr.DecryptString("legacy")
+	if err != nil || s != "legacy" {
+		t.Errorf("expected passthrough, got %q, %v", s, err)
+	}
+}
+
+func TestDisabledKeyringDoesNotEncrypt(t *testing.T) {
+	var kr *Keyring
+	data, err := kr.Encrypt([]byte("plain"))
+	if err != nil || string(data) != "plain" {
+		t.Errorf("expected plaintext, got %q, %v", data, err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	kr, _ := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	data, _ := kr.Encrypt([]byte("secret"))
+	data[len(data)-1] ^= 0xff
+	if _, err := kr.Decrypt(data); err == nil {
+		t.Error("expected error for tampered ciphertext")
+	}
+	if _, err := kr.Decrypt(data[:10]); err == nil {
+		t.Error("expected error for truncated ciphertext")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldRing, _ := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	data, _ := oldRing.Encrypt([]byte("secret"))
+
+	newRing, _ := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if !newRing.NeedsRotation(data) {
+		t.Error("expected data encrypted with k1 to need rotation")
+	}
+	got, err := newRing.Decrypt(data)
+	if err != nil || string(got) != "secret" {
+		t.Fatalf("expected old data to decrypt, got %q, %v", got, err)
+	}
+	rotated, _ := newRing.Encrypt(got)
+	if newRing.NeedsRotation(rotated) {
+		t.Error("expected rotated data not to need rotation")
+	}
+
+	onlyNew, _ := NewKeyring(map[string][]byte{"k2": testKey(2)}, "k2")
+	if _, err := onlyNew.Decrypt(data); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestEncryptString(t *testing.T) {
+	kr, _ := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	s, err := kr.EncryptString("body")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	if !strings.HasPrefix(s, kr.ActiveStringPrefix()) {
+		t.Errorf("expected prefix %q, got %q", kr.ActiveStringPrefix(), s)
+	}
+	got, err := kr.DecryptString(s)
+	if err != nil || got != "body" {
+		t.Errorf("expected body, got %q, %v", got, err)
+	}
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	t.Setenv("ARCHIVE_ENCRYPTION_KEYRING_FILE", "")
+	t.Setenv("ARCHIVE_ENCRYPTION_KEYS", "k1:"+k1+",k2:"+k2)
+	t.Setenv("ARCHIVE_ENCRYPTION_ACTIVE_KEY", "")
+
+	kr, err := KeyringFromEnv()
+	if err != nil {
+		t.Fatalf("KeyringFromEnv: %v", err)
+	}
+	if kr.ActiveKeyID() != "k2" {
+		t.Errorf("expected last key to be active, got %q", kr.ActiveKeyID())
+	}
+
+	t.Setenv("ARCHIVE_ENCRYPTION_ACTIVE_KEY", "k3")
+	if _, err := KeyringFromEnv(); err == nil {
+		t.Error("expected error for unknown active key")
+	}
+
+	t.Setenv("ARCHIVE_ENCRYPTION_KEYS", "")
+	kr, err = KeyringFromEnv()
+	if err != nil || kr.Enabled() {
+		t.Errorf("expected disabled keyring, got %v, %v", kr, err)
+	}
+}
@@ -0,0 +1,166 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownKey возвращается, когда данные зашифрованы ключом, которого нет в keyring
+	ErrUnknownKey = errors.New("encryption key not found in keyring")
+	// ErrDisabled возвращается при попытке расшифровать данные без сконфигурированного keyring
+	ErrDisabled = errors.New("encryption keyring is not configured")
+)
+
+// Keyring хранит ключи шифрования (KEK) по идентификаторам и активный ключ для новых данных.
+// Старые ключи остаются в keyring, пока все данные не будут перешифрованы активным ключом.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+// keyringFile формат файла keyring
+type keyringFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// NewKeyring создаёт keyring. Ключи должны быть длиной 16, 24 или 32 байта (AES-128/192/256)
+func NewKeyring(keys map[string][]byte, active string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring has no keys")
+	}
+	kr := &Keyring{keys: make(map[string][]byte, len(keys)), active: active}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 || strings.ContainsAny(id, ":,") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("key %q has invalid length %d", id, len(key))
+		}
+		kr.keys[id] = append([]byte(nil), key...)
+	}
+	if _, ok := kr.keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in keyring", active)
+	}
+	return kr, nil
+}
+
+// KeyringFromEnv читает keyring из окружения.
+// ARCHIVE_ENCRYPTION_KEYRING_FILE — JSON файл {"active": "k2", "keys": {"k1": "<base64>", "k2": "<base64>"}};
+// либо ARCHIVE_ENCRYPTION_KEYS="k1:<base64>,k2:<base64>" и ARCHIVE_ENCRYPTION_ACTIVE_KEY.
+// Если ничего не задано, возвращается nil без ошибки — шифрование выключено.
+func KeyringFromEnv() (*Keyring, error) {
+	if path := os.Getenv("ARCHIVE_ENCRYPTION_KEYRING_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyring file: %w", err)
+		}
+		var file keyringFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse keyring file: %w", err)
+		}
+		keys := make(map[string][]byte, len(file.Keys))
+		for id, encoded := range file.Keys {
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+			}
+			keys[id] = key
+		}
+		return NewKeyring(keys, file.Active)
+	}
+
+	raw := os.Getenv("ARCHIVE_ENCRYPTION_KEYS")
+	if raw == "" {
+		return nil, nil
+	}
+	keys := make(map[string][]byte)
+	var ids []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ARCHIVE_ENCRYPTION_KEYS entry, expected id:base64")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+		ids = append(ids, id)
+	}
+	active := os.Getenv("ARCHIVE_ENCRYPTION_ACTIVE_KEY")
+	if active == "" && len(ids) > 0 {
+		// По умолчанию активным считается последний ключ в списке
+		active = ids[len(ids)-1]
+	}
+	return NewKeyring(keys, active)
+}
+
+// Enabled возвращает true, если keyring сконфигурирован
+func (k *Keyring) Enabled() bool {
+	return k != nil && len(k.keys) > 0
+}
+
+// ActiveKeyID возвращает идентификатор ключа, которым шифруются новые данные
+func (k *Keyring) ActiveKeyID() string {
+	if !k.Enabled() {
+		return ""
+	}
+	return k.active
+}
+
+// KeyIDs возвращает идентификаторы всех ключей keyring
+func (k *Keyring) KeyIDs() []string {
+	if !k.Enabled() {
+		return nil
+	}
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	if !k.Enabled() {
+		return nil, ErrDisabled
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+var (
+	defaultKeyring *Keyring
+	defaultMu      sync.RWMutex
+)
+
+// Default возвращает глобальный keyring (nil, если шифрование выключено)
+func Default() *Keyring {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultKeyring
+}
+
+// SetDefault устанавливает глобальный keyring (используется при старте и в тестах)
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultKeyring = k
+}
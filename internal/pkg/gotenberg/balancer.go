@@ -0,0 +1,643 @@
+package gotenberg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/connpool"
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/resilience"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Стратегии выбора бэкенда
+const (
+	StrategyLeastOutstanding   = "least_outstanding"
+	StrategyWeightedRoundRobin = "weighted_round_robin"
+)
+
+var (
+	backendOutstanding = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gotenberg_backend_outstanding_requests",
+			Help: "Number of in-flight conversions per Gotenberg backend",
+		},
+		[]string{"backend"},
+	)
+
+	backendRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gotenberg_backend_requests_total",
+			Help: "Total number of conversions per Gotenberg backend",
+		},
+		[]string{"backend", "status"},
+	)
+
+	backendEjected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gotenberg_backend_ejected",
+			Help: "Whether the Gotenberg backend is ejected by outlier detection (1: ejected)",
+		},
+		[]string{"backend"},
+	)
+
+	backendEjections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gotenberg_backend_ejections_total",
+			Help: "Total number of outlier ejections per Gotenberg backend",
+		},
+		[]string{"backend"},
+	)
+
+	backendFailovers = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gotenberg_backend_failovers_total",
+			Help: "Total number of conversions retried on another Gotenberg backend",
+		},
+		[]string{"from"},
+	)
+)
+
+// BackendConfig адрес и вес бэкенда Gotenberg
+type BackendConfig struct {
+	URL    string
+	Weight int
+}
+
+// BalancerConfig настройки балансировки между бэкендами Gotenberg
+type BalancerConfig struct {
+	Backends []BackendConfig
+	// Strategy стратегия выбора: least_outstanding или weighted_round_robin
+	Strategy string
+	// OutlierConsecutiveFailures число ошибок подряд, после которого бэкенд исключается (0 — не исключать)
+	OutlierConsecutiveFailures int
+	// OutlierBaseEjection базовое время исключения, растёт с каждым повторным исключением
+	OutlierBaseEjection time.Duration
+	// OutlierMaxEjectionPercent максимальная доля одновременно исключённых бэкендов
+	OutlierMaxEjectionPercent int
+	// MaxFailover сколько других бэкендов пробовать при ошибке соединения
+	MaxFailover int
+	// Resilience настройки конвейера устойчивости каждого бэкенда, те же, что у одиночного клиента.
+	// Имя Circuit Breaker задаётся автоматически: gotenberg:<host>
+	Resilience resilience.Settings
+	// Hedge настройки хеджирования медленных конвертаций
+	Hedge HedgeConfig
+	// Probe настройки фоновой проверки /health каждого бэкенда
+	Probe ProberConfig
+}
+
+// ParseBackends разбирает список бэкендов вида "http://a:3000;weight=2,http://b:3000"
+func ParseBackends(value string) ([]BackendConfig, error) {
+	var backends []BackendConfig
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ";")
+		u, err := url.Parse(strings.TrimSpace(parts[0]))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid gotenberg backend URL %q", parts[0])
+		}
+		backend := BackendConfig{URL: strings.TrimRight(u.String(), "/"), Weight: 1}
+		for _, opt := range parts[1:] {
+			key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			if key != "weight" {
+				return nil, fmt.Errorf("unknown option %q for gotenberg backend %s", key, backend.URL)
+			}
+			w, err := strconv.Atoi(val)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight %q for gotenberg backend %s", val, backend.URL)
+			}
+			backend.Weight = w
+		}
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// BalancerConfigFromEnv читает настройки из окружения. Без GOTENBERG_BACKENDS используется fallbackURL
+func BalancerConfigFromEnv(fallbackURL string) (BalancerConfig, error) {
+	cfg := BalancerConfig{
+		Strategy:                   os.Getenv("GOTENBERG_LB_STRATEGY"),
+		OutlierConsecutiveFailures: getEnvIntWithDefault("GOTENBERG_OUTLIER_CONSECUTIVE_FAILURES", 5),
+		OutlierBaseEjection:        getEnvDurationWithDefault("GOTENBERG_OUTLIER_EJECTION_TIME", 30*time.Second),
+		OutlierMaxEjectionPercent:  getEnvIntWithDefault("GOTENBERG_OUTLIER_MAX_EJECTION_PERCENT", 50),
+		MaxFailover:                getEnvIntWithDefault("GOTENBERG_MAX_FAILOVER", 1),
+		Resilience:                 pipelineSettings(backendSettings()),
+		Hedge:                      HedgeConfigFromEnv(),
+		Probe:                      ProberConfigFromEnv(),
+	}
+	if value := os.Getenv("GOTENBERG_BACKENDS"); value != "" {
+		backends, err := ParseBackends(value)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Backends = backends
+	}
+	if len(cfg.Backends) == 0 && fallbackURL != "" {
+		cfg.Backends = []BackendConfig{{URL: fallbackURL, Weight: 1}}
+	}
+	return cfg, nil
+}
+
+// backend бэкенд Gotenberg со своим конвейером устойчивости и Circuit Breaker
+type backend struct {
+	name     string
+	weight   int
+	client   *Client
+	pool     *ClientWithPool
+	pipeline *resilience.Pipeline[[]byte]
+	// cb Circuit Breaker конвейера; nil, если звено исключено из GOTENBERG_PIPELINE_ORDER
+	cb          *circuitbreaker.CircuitBreaker
+	prober      *Prober
+	outstanding atomic.Int64
+
+	// Поля ниже защищены Balancer.mu
+	current             int
+	consecutiveFailures int
+	ejections           int
+	ejectedUntil        time.Time
+}
+
+// BackendStatus состояние бэкенда для диагностики
+type BackendStatus struct {
+	URL          string      `json:"url"`
+	Weight       int         `json:"weight"`
+	State        string      `json:"state"`
+	Outstanding  int64       `json:"outstanding"`
+	Ejected      bool        `json:"ejected"`
+	EjectedUntil time.Time   `json:"ejected_until,omitempty"`
+	Probe        ProbeStatus `json:"probe"`
+}
+
+// check возвращает ошибку, если бэкенд сейчас не примет конвертацию по Circuit Breaker или фоновой проверке
+func (be *backend) check() error {
+	if be.cb != nil {
+		if err := be.cb.Check(); err != nil {
+			return err
+		}
+	}
+	return be.prober.Check()
+}
+
+// state возвращает состояние Circuit Breaker бэкенда
+func (be *backend) state() circuitbreaker.State {
+	if be.cb == nil {
+		return circuitbreaker.StateClosed
+	}
+	return be.cb.State()
+}
+
+// Balancer распределяет конвертации между несколькими бэкендами Gotenberg
+// с отдельным Circuit Breaker на бэкенд, пассивным исключением выбросов и переключением при ошибках соединения
+type Balancer struct {
+	cfg      BalancerConfig
+	backends []*backend
+	now      func() time.Time
+	log      *zap.Logger
+	// hedger nil, если хеджирование выключено или бэкенд один
+	hedger *hedger
+
+	mu     sync.Mutex
+	cursor int
+}
+
+// NewBalancer создаёт балансировщик
+func NewBalancer(cfg BalancerConfig) (*Balancer, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, errors.New("no gotenberg backends configured")
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = StrategyLeastOutstanding
+	}
+	if cfg.Strategy != StrategyLeastOutstanding && cfg.Strategy != StrategyWeightedRoundRobin {
+		return nil, fmt.Errorf("unknown gotenberg balancing strategy %q", cfg.Strategy)
+	}
+	if cfg.OutlierBaseEjection <= 0 {
+		cfg.OutlierBaseEjection = 30 * time.Second
+	}
+	if cfg.OutlierMaxEjectionPercent <= 0 || cfg.OutlierMaxEjectionPercent > 100 {
+		cfg.OutlierMaxEjectionPercent = 50
+	}
+	if cfg.MaxFailover < 0 {
+		cfg.MaxFailover = 0
+	}
+
+	log := logger.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+	b := &Balancer{cfg: cfg, now: time.Now, log: log}
+	for _, bc := range cfg.Backends {
+		weight := bc.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		settings := cfg.Resilience
+		if settings.Name == "" {
+			settings.Name = "gotenberg"
+		}
+		if len(settings.Order) == 0 {
+			settings.Order = backendSettings().Order
+		}
+		if settings.Breaker.IsFailure == nil {
+			settings.Breaker.IsFailure = isBackendFailure
+		}
+		settings.Breaker.Name = "gotenberg:" + backendName(bc.URL)
+		pipeline := resilience.Build[[]byte](settings, nil)
+		client := NewClient(bc.URL)
+		b.backends = append(b.backends, &backend{
+			name:     backendName(bc.URL),
+			weight:   weight,
+			client:   client,
+			pool:     newBackendPool(bc.URL),
+			pipeline: pipeline,
+			cb:       pipeline.Breaker(),
+			prober:   NewProber(backendName(bc.URL), client, cfg.Probe),
+		})
+		backendEjected.WithLabelValues(backendName(bc.URL)).Set(0)
+	}
+	if cfg.Hedge.Enabled && len(b.backends) > 1 {
+		b.hedger = newHedger(cfg.Hedge)
+	}
+	return b, nil
+}
+
+// backendName возвращает host:port бэкенда для меток метрик
+func backendName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// ConvertDocxToPDF конвертирует DOCX в PDF на выбранном бэкенде, при ошибке соединения переключается на другой.
+// Если включено хеджирование, медленная конвертация дублируется на другой бэкенд.
+func (b *Balancer) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	return b.do(ctx, docxConversion(docxPath))
+}
+
+// ConvertHTMLToPDF конвертирует HTML в PDF через Chromium с теми же балансировкой, переключением и хеджированием
+func (b *Balancer) ConvertHTMLToPDF(ctx context.Context, doc HTMLDocument) ([]byte, error) {
+	return b.do(ctx, htmlConversion(doc))
+}
+
+// do выполняет конвертацию с хеджированием, если оно включено
+func (b *Balancer) do(ctx context.Context, conv conversion) ([]byte, error) {
+	if b.hedger != nil {
+		return b.convertHedged(ctx, conv)
+	}
+	return b.convertWithFailover(ctx, conv, make(map[*backend]bool, len(b.backends)))
+}
+
+// convertWithFailover выполняет конвертацию, пробуя другие бэкенды при ошибках соединения.
+// tried общий для основного и хеджирующего запроса и защищён b.mu.
+func (b *Balancer) convertWithFailover(ctx context.Context, conv conversion, tried map[*backend]bool) ([]byte, error) {
+	var lastErr error
+	var from *backend
+
+	for attempt := 0; attempt <= b.cfg.MaxFailover && ctx.Err() == nil; attempt++ {
+		be := b.pick(tried)
+		if be == nil {
+			break
+		}
+		if from != nil {
+			backendFailovers.WithLabelValues(from.name).Inc()
+			b.log.Warn("Gotenberg backend failed, failing over",
+				zap.String("from", from.name),
+				zap.String("to", be.name),
+				zap.Error(lastErr))
+		}
+
+		result, err := b.convert(ctx, be, conv)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		from = be
+		if !isFailoverError(err) {
+			return nil, err
+		}
+	}
+
+	if lastErr == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Все бэкенды недоступны по Circuit Breaker
+		return nil, b.Check()
+	}
+	return nil, lastErr
+}
+
+// convert выполняет конвертацию через конвейер устойчивости бэкенда
+func (b *Balancer) convert(ctx context.Context, be *backend, conv conversion) ([]byte, error) {
+	backendOutstanding.WithLabelValues(be.name).Set(float64(be.outstanding.Add(1)))
+	defer func() {
+		backendOutstanding.WithLabelValues(be.name).Set(float64(be.outstanding.Add(-1)))
+	}()
+
+	// Отмена вызывающим или балансировщиком (выиграл другой запрос), нехватка соединений в пуле
+	// и ошибки самого документа не считаются сбоем бэкенда — это решает классификатор Circuit Breaker
+	result, convErr := be.pipeline.Execute(ctx, func(ctx context.Context) ([]byte, error) {
+		return convertVia(ctx, be.client, be.pool, conv)
+	})
+	if callerGone(ctx, convErr) {
+		backendRequests.WithLabelValues(be.name, "canceled").Inc()
+		return nil, convErr
+	}
+	if errors.Is(convErr, connpool.ErrPoolExhausted) {
+		backendRequests.WithLabelValues(be.name, "pool_exhausted").Inc()
+		return nil, convErr
+	}
+	if convErr != nil && !errors.Is(convErr, circuitbreaker.ErrCircuitOpen) && !isBackendFailure(ctx, convErr) {
+		// Документ отклонён бэкендом — это не сбой, исключение выбросов его не учитывает
+		backendRequests.WithLabelValues(be.name, "client_error").Inc()
+		return nil, convErr
+	}
+	b.record(be, convErr)
+	return result, convErr
+}
+
+// hasCandidate проверяет, есть ли ещё не опробованный доступный бэкенд
+func (b *Balancer) hasCandidate(tried map[*backend]bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, be := range b.backends {
+		if !tried[be] && be.check() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pick выбирает бэкенд среди ещё не опробованных, пропуская открытые Circuit Breaker, нездоровые и исключённые бэкенды
+func (b *Balancer) pick(tried map[*backend]bool) *backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var candidates, ejected []*backend
+	for _, be := range b.backends {
+		if tried[be] || be.check() != nil {
+			continue
+		}
+		if now.Before(be.ejectedUntil) {
+			ejected = append(ejected, be)
+			continue
+		}
+		candidates = append(candidates, be)
+	}
+	if len(candidates) == 0 {
+		// Все доступные бэкенды исключены — лучше попробовать исключённый, чем отказать
+		candidates = ejected
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var picked *backend
+	if b.cfg.Strategy == StrategyWeightedRoundRobin {
+		picked = pickWeighted(candidates)
+	} else {
+		picked = b.pickLeastOutstandingLocked(candidates)
+	}
+	tried[picked] = true
+	return picked
+}
+
+// pickLeastOutstandingLocked выбирает бэкенд с минимальной нагрузкой на единицу веса; при равенстве — по кругу
+func (b *Balancer) pickLeastOutstandingLocked(candidates []*backend) *backend {
+	b.cursor++
+	var best *backend
+	var bestLoad float64
+	for i := range candidates {
+		be := candidates[(b.cursor+i)%len(candidates)]
+		load := float64(be.outstanding.Load()) / float64(be.weight)
+		if best == nil || load < bestLoad {
+			best, bestLoad = be, load
+		}
+	}
+	return best
+}
+
+// pickWeighted выбирает бэкенд по smooth weighted round robin
+func pickWeighted(candidates []*backend) *backend {
+	var best *backend
+	total := 0
+	for _, be := range candidates {
+		be.current += be.weight
+		total += be.weight
+		if best == nil || be.current > best.current {
+			best = be
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// record учитывает результат для пассивного исключения выбросов
+func (b *Balancer) record(be *backend, err error) {
+	if err == nil {
+		backendRequests.WithLabelValues(be.name, "success").Inc()
+	} else {
+		backendRequests.WithLabelValues(be.name, "error").Inc()
+	}
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if err == nil {
+		be.consecutiveFailures = 0
+		if !be.ejectedUntil.IsZero() && !now.Before(be.ejectedUntil) {
+			// Бэкенд вернулся после исключения
+			be.ejectedUntil = time.Time{}
+			backendEjected.WithLabelValues(be.name).Set(0)
+		}
+		return
+	}
+
+	be.consecutiveFailures++
+	if b.cfg.OutlierConsecutiveFailures <= 0 || be.consecutiveFailures < b.cfg.OutlierConsecutiveFailures {
+		return
+	}
+	if now.Before(be.ejectedUntil) || !b.canEjectLocked(now) {
+		return
+	}
+	be.ejections++
+	multiplier := be.ejections
+	if multiplier > 10 {
+		multiplier = 10
+	}
+	ejection := b.cfg.OutlierBaseEjection * time.Duration(multiplier)
+	be.ejectedUntil = now.Add(ejection)
+	be.consecutiveFailures = 0
+	backendEjections.WithLabelValues(be.name).Inc()
+	backendEjected.WithLabelValues(be.name).Set(1)
+	b.log.Warn("Gotenberg backend ejected as outlier",
+		zap.String("backend", be.name),
+		zap.Duration("ejection", ejection),
+		zap.Error(err))
+}
+
+// canEjectLocked проверяет лимит доли одновременно исключённых бэкендов
+func (b *Balancer) canEjectLocked(now time.Time) bool {
+	ejected := 0
+	for _, be := range b.backends {
+		if now.Before(be.ejectedUntil) {
+			ejected++
+		}
+	}
+	maxEjected := len(b.backends) * b.cfg.OutlierMaxEjectionPercent / 100
+	return ejected < maxEjected
+}
+
+// isFailoverError возвращает true для ошибок, при которых запрос не дошёл до бэкенда или соединение оборвалось
+func isFailoverError(err error) bool {
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// State возвращает наилучшее состояние среди Circuit Breaker бэкендов
+func (b *Balancer) State() circuitbreaker.State {
+	state := circuitbreaker.StateOpen
+	for _, be := range b.backends {
+		switch be.state() {
+		case circuitbreaker.StateClosed:
+			return circuitbreaker.StateClosed
+		case circuitbreaker.StateHalfOpen:
+			state = circuitbreaker.StateHalfOpen
+		}
+	}
+	return state
+}
+
+// IsHealthy возвращает true, если хотя бы один бэкенд может принимать запросы
+func (b *Balancer) IsHealthy() bool {
+	for _, be := range b.backends {
+		if be.cb == nil || be.cb.IsHealthy() {
+			return true
+		}
+	}
+	return false
+}
+
+// Check возвращает *circuitbreaker.OpenError с минимальным временем ожидания, если ни один бэкенд не пропустит запрос
+func (b *Balancer) Check() error {
+	var soonest *circuitbreaker.OpenError
+	for _, be := range b.backends {
+		err := be.check()
+		if err == nil {
+			return nil
+		}
+		var openErr *circuitbreaker.OpenError
+		if errors.As(err, &openErr) && (soonest == nil || openErr.RetryAfter < soonest.RetryAfter) {
+			soonest = openErr
+		}
+	}
+	if soonest == nil {
+		return nil
+	}
+	return &circuitbreaker.OpenError{Name: "gotenberg", RetryAfter: soonest.RetryAfter}
+}
+
+// Ready возвращает true, если фоновая проверка считает здоровым хотя бы один бэкенд
+func (b *Balancer) Ready() bool {
+	for _, be := range b.backends {
+		if be.prober.Healthy() {
+			return true
+		}
+	}
+	return false
+}
+
+// Probes возвращает состояние фоновой проверки по бэкендам
+func (b *Balancer) Probes() map[string]ProbeStatus {
+	probes := make(map[string]ProbeStatus, len(b.backends))
+	for _, be := range b.backends {
+		probes[be.client.baseURL] = be.prober.Status()
+	}
+	return probes
+}
+
+// StartProbing запускает фоновую проверку /health всех бэкендов
+func (b *Balancer) StartProbing() {
+	for _, be := range b.backends {
+		be.prober.Start()
+	}
+}
+
+// StopProbing останавливает фоновую проверку /health
+func (b *Balancer) StopProbing() {
+	for _, be := range b.backends {
+		be.prober.Stop()
+	}
+}
+
+// Backends возвращает состояние бэкендов
+func (b *Balancer) Backends() []BackendStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	statuses := make([]BackendStatus, 0, len(b.backends))
+	for _, be := range b.backends {
+		status := BackendStatus{
+			URL:         be.client.baseURL,
+			Weight:      be.weight,
+			State:       be.state().String(),
+			Outstanding: be.outstanding.Load(),
+			Ejected:     now.Before(be.ejectedUntil),
+			Probe:       be.prober.Status(),
+		}
+		if status.Ejected {
+			status.EjectedUntil = be.ejectedUntil
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// SetHandler устанавливает обработчик статистики для всех бэкендов
+func (b *Balancer) SetHandler(handler interface {
+	TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
+}) {
+	for _, be := range b.backends {
+		be.client.SetHandler(handler)
+		if be.pool != nil {
+			be.pool.SetHandler(handler)
+		}
+	}
+}
+
+// GetHandler возвращает обработчик статистики
+func (b *Balancer) GetHandler() (interface {
+	TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
+}, bool) {
+	return b.backends[0].client.GetHandler()
+}
@@ -0,0 +1,237 @@
+package gotenberg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/resilience"
+	"pdf-service-go/internal/pkg/retry"
+)
+
+// newTestDocx создаёт во временной директории файл test.docx размером size байт
+func newTestDocx(tb testing.TB, size int) string {
+	tb.Helper()
+	docxPath := filepath.Join(tb.TempDir(), "test.docx")
+	if err := os.WriteFile(docxPath, bytes.Repeat([]byte("d"), size), 0644); err != nil {
+		tb.Fatalf("Failed to create test file: %v", err)
+	}
+	return docxPath
+}
+
+// newTestBackend возвращает сервер Gotenberg со счётчиком конвертаций
+func newTestBackend(t *testing.T, status int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testBalancerConfig(backends ...BackendConfig) BalancerConfig {
+	return BalancerConfig{
+		Backends:                   backends,
+		OutlierConsecutiveFailures: 2,
+		OutlierBaseEjection:        time.Minute,
+		OutlierMaxEjectionPercent:  50,
+		MaxFailover:                1,
+		Resilience: resilience.Settings{
+			Breaker: circuitbreaker.Config{
+				FailureThreshold: 100,
+				ResetTimeout:     time.Minute,
+				HalfOpenMaxCalls: 1,
+				SuccessThreshold: 1,
+			},
+		},
+	}
+}
+
+func TestParseBackends(t *testing.T) {
+	backends, err := ParseBackends("http://a:3000/;weight=3, http://b:3000")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(backends) != 2 || backends[0] != (BackendConfig{"http://a:3000", 3}) || backends[1] != (BackendConfig{"http://b:3000", 1}) {
+		t.Errorf("Unexpected backends: %+v", backends)
+	}
+
+	for _, bad := range []string{"a:3000", "http://a:3000;weight=0", "http://a:3000;prio=1"} {
+		if _, err := ParseBackends(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestBalancer_WeightedRoundRobin(t *testing.T) {
+	srvA, callsA := newTestBackend(t, http.StatusOK)
+	srvB, callsB := newTestBackend(t, http.StatusOK)
+	cfg := testBalancerConfig(BackendConfig{srvA.URL, 3}, BackendConfig{srvB.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	b, err := NewBalancer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docx := newTestDocx(t, 16)
+
+	for i := 0; i < 8; i++ {
+		if _, err := b.ConvertDocxToPDF(context.Background(), docx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if callsA.Load() != 6 || callsB.Load() != 2 {
+		t.Errorf("Expected 6/2 split for weights 3:1, got %d/%d", callsA.Load(), callsB.Load())
+	}
+}
+
+func TestBalancer_LeastOutstandingSpreadsLoad(t *testing.T) {
+	srvA, callsA := newTestBackend(t, http.StatusOK)
+	srvB, callsB := newTestBackend(t, http.StatusOK)
+	b, err := NewBalancer(testBalancerConfig(BackendConfig{srvA.URL, 1}, BackendConfig{srvB.URL, 1}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docx := newTestDocx(t, 16)
+
+	for i := 0; i < 10; i++ {
+		if _, err := b.ConvertDocxToPDF(context.Background(), docx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if callsA.Load() == 0 || callsB.Load() == 0 {
+		t.Errorf("Expected both idle backends to receive requests, got %d/%d", callsA.Load(), callsB.Load())
+	}
+}
+
+func TestBalancer_FailoverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+	srv, calls := newTestBackend(t, http.StatusOK)
+
+	cfg := testBalancerConfig(BackendConfig{downURL, 1}, BackendConfig{srv.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	b, err := NewBalancer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docx := newTestDocx(t, 16)
+
+	// Первый запрос уходит на недоступный бэкенд и переключается на рабочий
+	if _, err := b.ConvertDocxToPDF(context.Background(), docx); err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected healthy backend to serve the request, got %d calls", calls.Load())
+	}
+}
+
+func TestBalancer_NoFailoverOnConversionError(t *testing.T) {
+	srvA, callsA := newTestBackend(t, http.StatusBadRequest)
+	srvB, callsB := newTestBackend(t, http.StatusOK)
+	cfg := testBalancerConfig(BackendConfig{srvA.URL, 1}, BackendConfig{srvB.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	b, _ := NewBalancer(cfg)
+
+	if _, err := b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); err == nil {
+		t.Fatal("Expected conversion error to be returned")
+	}
+	if callsA.Load() != 1 || callsB.Load() != 0 {
+		t.Errorf("Expected no failover for HTTP errors, got %d/%d", callsA.Load(), callsB.Load())
+	}
+}
+
+func TestBalancer_OutlierEjection(t *testing.T) {
+	bad, badCalls := newTestBackend(t, http.StatusInternalServerError)
+	good, _ := newTestBackend(t, http.StatusOK)
+	cfg := testBalancerConfig(BackendConfig{bad.URL, 1}, BackendConfig{good.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	b, _ := NewBalancer(cfg)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	docx := newTestDocx(t, 16)
+
+	for i := 0; i < 4; i++ {
+		_, _ = b.ConvertDocxToPDF(context.Background(), docx)
+	}
+	if badCalls.Load() != 2 {
+		t.Fatalf("Expected 2 calls to bad backend before ejection, got %d", badCalls.Load())
+	}
+	statuses := b.Backends()
+	if !statuses[0].Ejected || statuses[1].Ejected {
+		t.Fatalf("Expected only the failing backend to be ejected, got %+v", statuses)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := b.ConvertDocxToPDF(context.Background(), docx); err != nil {
+			t.Errorf("Expected requests to go to healthy backend, got %v", err)
+		}
+	}
+	if badCalls.Load() != 2 {
+		t.Errorf("Expected ejected backend to receive no traffic, got %d calls", badCalls.Load())
+	}
+
+	// После истечения времени исключения бэкенд снова получает запросы
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		_, _ = b.ConvertDocxToPDF(context.Background(), docx)
+	}
+	if badCalls.Load() == 2 {
+		t.Error("Expected backend to return to rotation after ejection time")
+	}
+}
+
+func TestBalancer_CheckAllOpen(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	cfg := testBalancerConfig(BackendConfig{downURL, 1})
+	cfg.Resilience.Breaker.FailureThreshold = 1
+	b, _ := NewBalancer(cfg)
+
+	if err := b.Check(); err != nil {
+		t.Fatalf("Expected closed breakers to pass check, got %v", err)
+	}
+	_, _ = b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16))
+
+	err := b.Check()
+	var openErr *circuitbreaker.OpenError
+	if !errors.As(err, &openErr) || openErr.Name != "gotenberg" {
+		t.Fatalf("Expected OpenError for gotenberg, got %v", err)
+	}
+	if _, err := b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen when all backends are open, got %v", err)
+	}
+	if b.State() != circuitbreaker.StateOpen || b.IsHealthy() {
+		t.Errorf("Expected balancer to be unhealthy, got %v", b.State())
+	}
+}
+
+func TestBalancer_BackendPipelineRetries(t *testing.T) {
+	srv, calls := newTestBackend(t, http.StatusServiceUnavailable)
+
+	cfg := testBalancerConfig(BackendConfig{srv.URL, 1})
+	cfg.MaxFailover = 0
+	cfg.Resilience.Order = []resilience.Stage{resilience.StageRetry, resilience.StageCircuitBreaker}
+	cfg.Resilience.Retry = retry.Config{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1}
+	b, _ := NewBalancer(cfg)
+
+	if _, err := b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); err == nil {
+		t.Fatal("Expected error from failing backend")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected backend pipeline to retry 3 times, got %d calls", calls.Load())
+	}
+}
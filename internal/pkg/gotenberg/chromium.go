@@ -0,0 +1,115 @@
+package gotenberg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strconv"
+	"time"
+
+	"pdf-service-go/internal/pkg/metrics"
+)
+
+// HTMLOptions параметры страницы Chromium; пустые значения — значения Gotenberg по умолчанию.
+// Размеры задаются в дюймах или с единицами измерения ("210mm"), как их принимает Gotenberg.
+type HTMLOptions struct {
+	PaperWidth   string
+	PaperHeight  string
+	MarginTop    string
+	MarginBottom string
+	MarginLeft   string
+	MarginRight  string
+	Landscape    bool
+	// PrintBackground печатать фоновые цвета и изображения
+	PrintBackground bool
+	// PreferCSSPageSize размер страницы из CSS @page имеет приоритет над PaperWidth и PaperHeight
+	PreferCSSPageSize bool
+}
+
+// HTMLDocument HTML документ для конвертации через Chromium
+type HTMLDocument struct {
+	// Index основной документ (index.html)
+	Index []byte
+	// Header колонтитул, повторяемый на каждой странице (необязательный)
+	Header []byte
+	// Footer нижний колонтитул (необязательный)
+	Footer  []byte
+	Options HTMLOptions
+}
+
+// ConvertHTMLToPDF конвертирует HTML в PDF маршрутом Chromium; отмена ctx прерывает запрос к Gotenberg
+func (c *Client) ConvertHTMLToPDF(ctx context.Context, doc HTMLDocument) ([]byte, error) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		metrics.GotenbergRequestDuration.WithLabelValues("convert_html").Observe(duration.Seconds())
+		if c.handler != nil {
+			c.handler.TrackGotenbergRequest(duration, false, false)
+		}
+	}()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writeHTMLForm(writer, doc); err != nil {
+		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
+		return nil, err
+	}
+	return c.postForm(ctx, "/forms/chromium/convert/html", body, writer.FormDataContentType())
+}
+
+// writeHTMLForm записывает файлы и параметры страницы в multipart форму Chromium
+func writeHTMLForm(writer *multipart.Writer, doc HTMLDocument) error {
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"index.html", doc.Index},
+		{"header.html", doc.Header},
+		{"footer.html", doc.Footer},
+	}
+	for _, f := range files {
+		if len(f.content) == 0 {
+			continue
+		}
+		part, err := writer.CreateFormFile("files", f.name)
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+		if _, err := part.Write(f.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	opts := doc.Options
+	fields := []struct{ name, value string }{
+		{"paperWidth", opts.PaperWidth},
+		{"paperHeight", opts.PaperHeight},
+		{"marginTop", opts.MarginTop},
+		{"marginBottom", opts.MarginBottom},
+		{"marginLeft", opts.MarginLeft},
+		{"marginRight", opts.MarginRight},
+	}
+	if opts.Landscape {
+		fields = append(fields, struct{ name, value string }{"landscape", strconv.FormatBool(true)})
+	}
+	if opts.PrintBackground {
+		fields = append(fields, struct{ name, value string }{"printBackground", strconv.FormatBool(true)})
+	}
+	if opts.PreferCSSPageSize {
+		fields = append(fields, struct{ name, value string }{"preferCssPageSize", strconv.FormatBool(true)})
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		if err := writer.WriteField(f.name, f.value); err != nil {
+			return fmt.Errorf("failed to write field %s: %w", f.name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package gotenberg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ConvertHTMLToPDF(t *testing.T) {
+	var path string
+	files := make(map[string]string)
+	fields := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+			return
+		}
+		for _, fh := range r.MultipartForm.File["files"] {
+			f, _ := fh.Open()
+			content, _ := io.ReadAll(f)
+			f.Close()
+			files[fh.Filename] = string(content)
+		}
+		for name, values := range r.MultipartForm.Value {
+			fields[name] = values[0]
+		}
+		_, _ = w.Write([]byte("%PDF-html"))
+	}))
+	defer srv.Close()
+
+	result, err := NewClient(srv.URL).ConvertHTMLToPDF(context.Background(), HTMLDocument{
+		Index:  []byte("<p>body</p>"),
+		Footer: []byte("<p>footer</p>"),
+		Options: HTMLOptions{
+			PaperWidth:      "210mm",
+			PaperHeight:     "297mm",
+			MarginTop:       "1in",
+			PrintBackground: true,
+		},
+	})
+	if err != nil || string(result) != "%PDF-html" {
+		t.Fatalf("Expected pdf, got %q, %v", result, err)
+	}
+	if path != "/forms/chromium/convert/html" {
+		t.Errorf("Expected Chromium HTML route, got %s", path)
+	}
+	if files["index.html"] != "<p>body</p>" || files["footer.html"] != "<p>footer</p>" {
+		t.Errorf("Unexpected files: %v", files)
+	}
+	if _, ok := files["header.html"]; ok {
+		t.Error("Expected empty header not to be sent")
+	}
+	expected := map[string]string{"paperWidth": "210mm", "paperHeight": "297mm", "marginTop": "1in", "printBackground": "true"}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("Expected field %s=%s, got %q", name, value, fields[name])
+		}
+	}
+	if _, ok := fields["landscape"]; ok {
+		t.Error("Expected unset landscape not to be sent")
+	}
+}
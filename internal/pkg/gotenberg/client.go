@@ -2,15 +2,20 @@ package gotenberg
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/connpool"
 	"pdf-service-go/internal/pkg/metrics"
 )
 
@@ -62,7 +67,14 @@ func (c *Client) GetHandler() (interface {
 	return c.handler, true
 }
 
-func (c *Client) ConvertDocxToPDF(docxPath string) ([]byte, error) {
+// ConvertDocxToPDF конвертирует DOCX в PDF; отмена ctx прерывает запрос к Gotenberg
+func (c *Client) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	return c.ConvertDocxFilesToPDF(ctx, docxPath)
+}
+
+// ConvertDocxFilesToPDF конвертирует несколько документов одним запросом и объединяет их в один PDF
+// в порядке аргументов. Файлы передаются потоком с диска, тело запроса не собирается в памяти.
+func (c *Client) ConvertDocxFilesToPDF(ctx context.Context, paths ...string) ([]byte, error) {
 	start := time.Now()
 	defer func() {
 		duration := time.Since(start)
@@ -72,53 +84,44 @@ func (c *Client) ConvertDocxToPDF(docxPath string) ([]byte, error) {
 		}
 	}()
 
-	// Создаем буфер для multipart формы с оптимизированным размером
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Открываем файл DOCX с буферизированным чтением
-	file, err := os.OpenFile(docxPath, os.O_RDONLY, 0)
-	if err != nil {
+	if len(paths) == 0 {
 		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
-		return nil, fmt.Errorf("failed to open DOCX file: %w", err)
+		return nil, errors.New("no files to convert")
 	}
-	defer file.Close()
-
-	// Создаем часть формы для файла
-	part, err := writer.CreateFormFile("files", filepath.Base(docxPath))
+	files, err := openFormFiles(paths)
 	if err != nil {
 		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	// Используем буферизированное копирование для улучшения производительности
-	copyBuf := make([]byte, 64*1024)
-	if _, err := io.CopyBuffer(part, file, copyBuf); err != nil {
-		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
+		return nil, err
 	}
 
-	// Закрываем writer
-	if err := writer.Close(); err != nil {
-		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
-		return nil, fmt.Errorf("failed to close writer: %w", err)
-	}
+	body, contentType := streamForm(func(writer *multipart.Writer) error {
+		return writeDocxForm(writer, files)
+	})
+	return c.postForm(ctx, "/forms/libreoffice/convert", body, contentType)
+}
 
+// postForm отправляет multipart форму в маршрут Gotenberg и возвращает PDF из ответа.
+// Тело может быть потоковым (io.Pipe): транспорт закрывает его после отправки или при ошибке.
+func (c *Client) postForm(ctx context.Context, route string, body io.Reader, contentType string) ([]byte, error) {
 	// Создаем запрос к Gotenberg с оптимизированными заголовками
-	req, err := http.NewRequest("POST", c.baseURL+"/forms/libreoffice/convert", body)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+route, body)
 	if err != nil {
+		// Запрос не отправлен: закрываем тело, чтобы завершить запись потоковой формы
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
 		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept-Encoding", "gzip")
 	req.Header.Set("Connection", "keep-alive")
 
 	// Отправляем запрос
 	resp, err := c.client.Do(req)
 	if err != nil {
-		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
+		metrics.GotenbergRequestsTotal.WithLabelValues(requestStatus(ctx, err)).Inc()
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -127,13 +130,13 @@ func (c *Client) ConvertDocxToPDF(docxPath string) ([]byte, error) {
 	if resp.StatusCode != http.StatusOK {
 		metrics.GotenbergRequestsTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("conversion failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body), RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
 	}
 
 	// Читаем PDF из ответа с буферизацией
 	responseBuf := new(bytes.Buffer)
 	if _, err := io.Copy(responseBuf, resp.Body); err != nil {
-		metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
+		metrics.GotenbergRequestsTotal.WithLabelValues(requestStatus(ctx, err)).Inc()
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
@@ -142,7 +145,7 @@ func (c *Client) ConvertDocxToPDF(docxPath string) ([]byte, error) {
 }
 
 // HealthCheck выполняет проверку здоровья сервиса Gotenberg
-func (c *Client) HealthCheck(skipStats ...bool) error {
+func (c *Client) HealthCheck(ctx context.Context, skipStats ...bool) error {
 	start := time.Now()
 	shouldSkip := len(skipStats) > 0 && skipStats[0]
 
@@ -156,10 +159,14 @@ func (c *Client) HealthCheck(skipStats ...bool) error {
 		}
 	}()
 
-	resp, err := c.client.Get(c.baseURL + "/health")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
 		if !shouldSkip {
-			metrics.GotenbergRequestsTotal.WithLabelValues("error").Inc()
+			metrics.GotenbergRequestsTotal.WithLabelValues(requestStatus(ctx, err)).Inc()
 			if c.handler != nil {
 				c.handler.TrackGotenbergRequest(time.Since(start), true, true)
 			}
@@ -183,3 +190,88 @@ func (c *Client) HealthCheck(skipStats ...bool) error {
 	}
 	return nil
 }
+
+// requestStatus возвращает статус метрики для ошибки запроса: отмена вызывающим,
+// истечение его дедлайна (REQUEST_TIMEOUT) или таймаут самого Gotenberg
+func requestStatus(ctx context.Context, err error) string {
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.Canceled):
+		return "canceled"
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "backend_timeout"
+	}
+	return "error"
+}
+
+// callerGone сообщает, что ошибка вызвана отменой вызывающего, а не сбоем Gotenberg.
+// Истёкший дедлайн вызывающего, пока Gotenberg обрабатывал запрос, считается сбоем бэкенда.
+func callerGone(ctx context.Context, err error) bool {
+	return circuitbreaker.CallerCanceled(ctx, err)
+}
+
+// notBackendFailure сообщает, что ошибка не должна учитываться Circuit Breaker:
+// вызывающий отменил запрос или запрос не дождался свободного соединения в пуле
+func notBackendFailure(ctx context.Context, err error) bool {
+	return callerGone(ctx, err) || errors.Is(err, connpool.ErrPoolExhausted)
+}
+
+// StatusError ответ Gotenberg с кодом, отличным от 200
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter задержка из заголовка Retry-After (0 — заголовка нет)
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("conversion failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatus возвращает код ответа для типизированной классификации в retry
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// RetryAfterDelay возвращает задержку, запрошенную Gotenberg
+func (e *StatusError) RetryAfterDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP даты
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// isBackendFailure классификатор Circuit Breaker для Gotenberg: сбоем считаются таймауты, ошибки соединения
+// и ответы 5xx, 408 и 429. Прочие 4xx (неконвертируемый документ), ошибки чтения локального файла,
+// отмена вызывающим и нехватка соединений в пуле не говорят о неисправности Gotenberg.
+func isBackendFailure(ctx context.Context, err error) bool {
+	if err == nil || notBackendFailure(ctx, err) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var pathErr *fs.PathError
+	return !errors.As(err, &pathErr)
+}
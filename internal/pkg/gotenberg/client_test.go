@@ -1,7 +1,17 @@
 package gotenberg
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/resilience"
+	"pdf-service-go/internal/pkg/retry"
 )
 
 func TestClient_SetHandler(t *testing.T) {
@@ -27,3 +37,181 @@ func TestClient_GetHandler(t *testing.T) {
 		t.Error("Expected non-nil handler")
 	}
 }
+
+// newBlockingServer возвращает сервер, который отвечает на /health и держит конвертацию до отмены запроса
+func newBlockingServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_ConvertDocxToPDF_CallerCancel(t *testing.T) {
+	srv := newBlockingServer(t)
+	client := NewClient(srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.ConvertDocxToPDF(ctx, newTestDocx(t, 16))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Conversion was not interrupted by cancellation")
+	}
+	if status := requestStatus(ctx, err); status != "canceled" {
+		t.Errorf("Expected status canceled, got %s", status)
+	}
+}
+
+func TestClient_RequestStatusDistinguishesTimeouts(t *testing.T) {
+	srv := newBlockingServer(t)
+	client := NewClient(srv.URL)
+	client.client.Timeout = 50 * time.Millisecond
+
+	_, err := client.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16))
+	if err == nil {
+		t.Fatal("Expected backend timeout error")
+	}
+	if status := requestStatus(context.Background(), err); status != "backend_timeout" {
+		t.Errorf("Expected status backend_timeout, got %s", status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if status := requestStatus(ctx, err); status != "deadline_exceeded" {
+		t.Errorf("Expected status deadline_exceeded, got %s", status)
+	}
+}
+
+// newTestBreakerClient создаёт клиента, конвейер которого состоит только из заданного Circuit Breaker
+func newTestBreakerClient(baseURL string, cfg circuitbreaker.Config) *ClientWithCircuitBreaker {
+	return &ClientWithCircuitBreaker{
+		client: NewClient(baseURL),
+		pipeline: resilience.Build[[]byte](resilience.Settings{
+			Name:    cfg.Name,
+			Order:   []resilience.Stage{resilience.StageCircuitBreaker},
+			Breaker: cfg,
+		}, nil),
+	}
+}
+
+func TestClientWithCircuitBreaker_CallerCancelIsNotFailure(t *testing.T) {
+	srv := newBlockingServer(t)
+	client := newTestBreakerClient(srv.URL, circuitbreaker.Config{
+		Name:             "test-cancel",
+		FailureThreshold: 1,
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	})
+	docx := newTestDocx(t, 16)
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, err := client.ConvertDocxToPDF(ctx, docx)
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	}
+	if state := client.State(); state != circuitbreaker.StateClosed {
+		t.Errorf("Expected circuit breaker to stay closed, got %v", state)
+	}
+}
+
+func TestClientWithCircuitBreaker_CallerDeadlineIsFailure(t *testing.T) {
+	srv := newBlockingServer(t)
+	client := newTestBreakerClient(srv.URL, circuitbreaker.Config{
+		Name:             "test-deadline",
+		FailureThreshold: 2,
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		IsFailure:        isBackendFailure,
+	})
+	docx := newTestDocx(t, 16)
+
+	// Зависший Gotenberg не успевает ответить до дедлайна запроса (REQUEST_TIMEOUT) — это сбой бэкенда
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := client.ConvertDocxToPDF(ctx, docx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	}
+	if state := client.State(); state != circuitbreaker.StateOpen {
+		t.Errorf("Expected hung backend to open the breaker, got %v", state)
+	}
+}
+
+func TestClientWithCircuitBreaker_ClassifiesStatusCodes(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	client := newTestBreakerClient(srv.URL, circuitbreaker.Config{
+		Name:             "test-classifier",
+		FailureThreshold: 2,
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+		IsFailure:        isBackendFailure,
+	})
+	docx := newTestDocx(t, 16)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.ConvertDocxToPDF(context.Background(), docx)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Expected StatusError 400, got %v", err)
+		}
+	}
+	if state := client.State(); state != circuitbreaker.StateClosed {
+		t.Fatalf("4xx responses must not open the breaker, got %v", state)
+	}
+
+	status = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		_, _ = client.ConvertDocxToPDF(context.Background(), docx)
+	}
+	if state := client.State(); state != circuitbreaker.StateOpen {
+		t.Errorf("5xx responses must open the breaker, got %v", state)
+	}
+}
+
+func TestClient_StatusErrorCarriesRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewClient(srv.URL).ConvertDocxToPDF(context.Background(), newTestDocx(t, 16))
+	if retry.Classify(err) != retry.ErrorTypeRateLimited {
+		t.Errorf("Expected rate_limited classification, got %s", retry.Classify(err))
+	}
+	if d := retry.RetryAfter(err); d != 3*time.Second {
+		t.Errorf("Expected Retry-After 3s, got %v", d)
+	}
+
+	now := time.Now()
+	if d := parseRetryAfter(now.Add(10*time.Second).UTC().Format(http.TimeFormat), now); d < 9*time.Second || d > 10*time.Second {
+		t.Errorf("Expected HTTP date Retry-After ~10s, got %v", d)
+	}
+	if d := parseRetryAfter("soon", now); d != 0 {
+		t.Errorf("Expected invalid Retry-After to be ignored, got %v", d)
+	}
+}
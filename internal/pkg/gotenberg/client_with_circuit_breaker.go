@@ -2,10 +2,10 @@ package gotenberg
 
 import (
 	"context"
-	"os"
 	"time"
 
 	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/resilience"
 )
 
 
@@ -13,52 +13,93 @@ import (
 // ClientWithCircuitBreaker добавляет Circuit Breaker к клиенту Gotenberg
 type ClientWithCircuitBreaker struct {
 	client *Client
-	cb     *circuitbreaker.CircuitBreaker
+	// pool пул соединений для конвертаций; nil — конвертации идут напрямую через client
+	pool     *ClientWithPool
+	pipeline *resilience.Pipeline[[]byte]
+	prober   *Prober
 }
 
 // NewClientWithCircuitBreaker создает нового клиента с Circuit Breaker
 func NewClientWithCircuitBreaker(baseURL string) *ClientWithCircuitBreaker {
 	client := NewClient(baseURL)
-	cb := circuitbreaker.NewCircuitBreaker(circuitbreaker.Config{
-		Name:             "gotenberg",
-		FailureThreshold: getEnvIntWithDefault("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5),
-		ResetTimeout:     getEnvDurationWithDefault("CIRCUIT_BREAKER_RESET_TIMEOUT", 10*time.Second),
-		HalfOpenMaxCalls: getEnvIntWithDefault("CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS", 2),
-		SuccessThreshold: getEnvIntWithDefault("CIRCUIT_BREAKER_SUCCESS_THRESHOLD", 2),
-		PodName:          os.Getenv("POD_NAME"),
-		Namespace:        os.Getenv("POD_NAMESPACE"),
-	})
+	pipeline := newPipeline(backendSettings())
 
 	return &ClientWithCircuitBreaker{
-		client: client,
-		cb:     cb,
+		client:   client,
+		pool:     newBackendPool(baseURL),
+		pipeline: pipeline,
+		prober:   NewProber(backendName(baseURL), client, ProberConfigFromEnv()),
 	}
 }
 
-// ConvertDocxToPDF конвертирует DOCX в PDF с использованием Circuit Breaker
-func (c *ClientWithCircuitBreaker) ConvertDocxToPDF(docxPath string) ([]byte, error) {
-	var result []byte
-	err := c.cb.Execute(context.Background(), func() error {
-		// Сначала выполняем проверку здоровья
-		if err := c.client.HealthCheck(); err != nil {
-			return err
-		}
-		// Если проверка здоровья прошла успешно, выполняем конвертацию
-		var err error
-		result, err = c.client.ConvertDocxToPDF(docxPath)
-		return err
+// ConvertDocxToPDF конвертирует DOCX в PDF с использованием Circuit Breaker.
+// Какие ошибки считаются сбоем Gotenberg, решает isBackendFailure.
+func (c *ClientWithCircuitBreaker) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	return c.convert(ctx, docxConversion(docxPath))
+}
+
+// ConvertHTMLToPDF конвертирует HTML в PDF через Chromium тем же конвейером устойчивости, что и DOCX
+func (c *ClientWithCircuitBreaker) ConvertHTMLToPDF(ctx context.Context, doc HTMLDocument) ([]byte, error) {
+	return c.convert(ctx, htmlConversion(doc))
+}
+
+// convert выполняет конвертацию через фоновую проверку и конвейер устойчивости
+func (c *ClientWithCircuitBreaker) convert(ctx context.Context, conv conversion) ([]byte, error) {
+	// Состояние здоровья берём из фоновой проверки, не отправляя /health на каждую конвертацию
+	if err := c.prober.Check(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.pipeline.Execute(ctx, func(ctx context.Context) ([]byte, error) {
+		return convertVia(ctx, c.client, c.pool, conv)
 	})
-	return result, err
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // State возвращает текущее состояние Circuit Breaker
 func (c *ClientWithCircuitBreaker) State() circuitbreaker.State {
-	return c.cb.State()
+	return c.pipeline.Breaker().State()
 }
 
 // IsHealthy возвращает true, если Circuit Breaker в здоровом состоянии
 func (c *ClientWithCircuitBreaker) IsHealthy() bool {
-	return c.cb.IsHealthy()
+	return c.pipeline.Breaker().IsHealthy()
+}
+
+// Check возвращает *circuitbreaker.OpenError, если Circuit Breaker сейчас не пропустит конвертацию
+// или фоновая проверка считает Gotenberg нездоровым
+func (c *ClientWithCircuitBreaker) Check() error {
+	if err := c.pipeline.Breaker().Check(); err != nil {
+		return err
+	}
+	return c.prober.Check()
+}
+
+// Ready возвращает результат фоновой проверки /health для readiness
+func (c *ClientWithCircuitBreaker) Ready() bool {
+	return c.prober.Healthy()
+}
+
+// Probes возвращает состояние фоновой проверки
+func (c *ClientWithCircuitBreaker) Probes() map[string]ProbeStatus {
+	return map[string]ProbeStatus{c.client.baseURL: c.prober.Status()}
+}
+
+// StartProbing запускает фоновую проверку /health
+func (c *ClientWithCircuitBreaker) StartProbing() {
+	if c.prober != nil {
+		c.prober.Start()
+	}
+}
+
+// StopProbing останавливает фоновую проверку /health
+func (c *ClientWithCircuitBreaker) StopProbing() {
+	if c.prober != nil {
+		c.prober.Stop()
+	}
 }
 
 // GetHandler возвращает обработчик статистики из базового клиента
@@ -73,4 +114,7 @@ func (c *ClientWithCircuitBreaker) SetHandler(handler interface {
 	TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
 }) {
 	c.client.SetHandler(handler)
+	if c.pool != nil {
+		c.pool.SetHandler(handler)
+	}
 }
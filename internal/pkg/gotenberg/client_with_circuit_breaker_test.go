@@ -1,6 +1,7 @@
 package gotenberg
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -29,7 +30,7 @@ func TestClientWithCircuitBreaker_ConvertDocxToPDF(t *testing.T) {
 
 	// Вызываем ошибки до срабатывания Circuit Breaker
 	for i := 0; i < 6; i++ {
-		_, err := client.ConvertDocxToPDF(docxPath)
+		_, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 		if err == nil {
 			t.Error("Expected error from invalid URL")
 		}
@@ -65,7 +66,7 @@ func TestClientWithCircuitBreaker_Integration(t *testing.T) {
 	client.SetHandler(handler)
 
 	// Проверяем успешную конвертацию
-	pdf, err := client.ConvertDocxToPDF(docxPath)
+	pdf, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	if err != nil {
 		t.Errorf("Failed to convert DOCX to PDF: %v", err)
 	}
@@ -2,6 +2,7 @@ package gotenberg
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -9,48 +10,61 @@ import (
 	"pdf-service-go/internal/pkg/logger"
 )
 
-// ClientWithPool представляет клиент Gotenberg с пулом соединений
+// ClientWithPool представляет клиент Gotenberg с пулом соединений.
+// Каждый ресурс пула — HTTP клиент с одним keep-alive соединением, размер пула ограничивает
+// число одновременных запросов к бэкенду, а ожидающие обслуживаются по очереди.
 type ClientWithPool struct {
 	baseURL string
-	pool    *connpool.Pool
+	pool    *connpool.Pool[*http.Client]
+	handler interface {
+		TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
+	}
 }
 
-// NewClientWithPool создает нового клиента с пулом соединений
-func NewClientWithPool(baseURL string) *ClientWithPool {
-	config := connpool.DefaultConfig()
+// PoolEnabled возвращает true, если конвертации идут через пул соединений (GOTENBERG_POOL_ENABLED)
+func PoolEnabled() bool {
+	return getEnvBoolWithDefault("GOTENBERG_POOL_ENABLED", true)
+}
 
-	// Настраиваем конфигурацию пула из переменных окружения
+// PoolConfigFromEnv читает настройки пула соединений из окружения
+func PoolConfigFromEnv() connpool.Config {
+	config := connpool.DefaultConfig()
 	config.MinConns = getEnvIntWithDefault("GOTENBERG_POOL_MIN_CONNS", config.MinConns)
 	config.MaxConns = getEnvIntWithDefault("GOTENBERG_POOL_MAX_CONNS", config.MaxConns)
 	config.MaxIdleTime = getEnvDurationWithDefault("GOTENBERG_POOL_MAX_IDLE_TIME", config.MaxIdleTime)
 	config.MaxLifetime = getEnvDurationWithDefault("GOTENBERG_POOL_MAX_LIFETIME", config.MaxLifetime)
 	config.DialTimeout = getEnvDurationWithDefault("GOTENBERG_POOL_DIAL_TIMEOUT", config.DialTimeout)
 	config.IdleTimeout = getEnvDurationWithDefault("GOTENBERG_POOL_IDLE_TIMEOUT", config.IdleTimeout)
+	return config
+}
 
-	// Создаем функцию для создания новых HTTP клиентов
-	dialFunc := func(ctx context.Context) (interface{}, func() error, error) {
-		transport := &http.Transport{
-			MaxIdleConns:        1,
-			MaxIdleConnsPerHost: 1,
-			IdleConnTimeout:     90 * time.Second,
-			DisableCompression:  false,
-			ForceAttemptHTTP2:   true,
-			WriteBufferSize:     64 * 1024,
-			ReadBufferSize:      64 * 1024,
-		}
-
-		client := &http.Client{
-			Transport: transport,
-			Timeout:   getEnvDurationWithDefault("GOTENBERG_CLIENT_TIMEOUT", 60 * time.Second),
-		}
+// NewClientWithPool создает нового клиента с пулом соединений
+func NewClientWithPool(baseURL string) *ClientWithPool {
+	return newClientWithPool(baseURL, PoolConfigFromEnv())
+}
 
-		return client, func() error {
-			transport.CloseIdleConnections()
+func newClientWithPool(baseURL string, config connpool.Config) *ClientWithPool {
+	timeout := getEnvDurationWithDefault("GOTENBERG_CLIENT_TIMEOUT", 60*time.Second)
+
+	// Проверка при выдаче не задана: дешёвой проверки HTTP клиента нет, а здоровье бэкенда отслеживает Prober
+	pool := connpool.NewPool(config, logger.Log, connpool.Factory[*http.Client]{
+		Dial: func(ctx context.Context) (*http.Client, error) {
+			transport := &http.Transport{
+				MaxIdleConns:        1,
+				MaxIdleConnsPerHost: 1,
+				IdleConnTimeout:     90 * time.Second,
+				DisableCompression:  false,
+				ForceAttemptHTTP2:   true,
+				WriteBufferSize:     64 * 1024,
+				ReadBufferSize:      64 * 1024,
+			}
+			return &http.Client{Transport: transport, Timeout: timeout}, nil
+		},
+		Close: func(client *http.Client) error {
+			client.CloseIdleConnections()
 			return nil
-		}, nil
-	}
-
-	pool := connpool.NewPool(config, logger.Log, dialFunc)
+		},
+	})
 
 	return &ClientWithPool{
 		baseURL: baseURL,
@@ -59,26 +73,45 @@ func NewClientWithPool(baseURL string) *ClientWithPool {
 }
 
 // ConvertDocxToPDF конвертирует DOCX в PDF используя соединение из пула
-func (c *ClientWithPool) ConvertDocxToPDF(docxPath string) ([]byte, error) {
-	// Получаем соединение из пула
-	conn, err := c.pool.Get(context.Background())
+func (c *ClientWithPool) ConvertDocxToPDF(ctx context.Context, docxPath string) ([]byte, error) {
+	return c.do(ctx, docxConversion(docxPath))
+}
+
+// ConvertHTMLToPDF конвертирует HTML в PDF через Chromium используя соединение из пула
+func (c *ClientWithPool) ConvertHTMLToPDF(ctx context.Context, doc HTMLDocument) ([]byte, error) {
+	return c.do(ctx, htmlConversion(doc))
+}
+
+// do выполняет конвертацию на соединении из пула
+func (c *ClientWithPool) do(ctx context.Context, conv conversion) ([]byte, error) {
+	// Получаем соединение из пула, ожидание прерывается отменой ctx
+	conn, err := c.pool.Get(ctx)
 	if err != nil {
+		if ctx.Err() != nil {
+			// Дедлайн истёк в очереди пула, а не в Gotenberg — это нехватка соединений, а не сбой бэкенда
+			return nil, fmt.Errorf("%w: %w", connpool.ErrPoolExhausted, err)
+		}
 		return nil, err
 	}
-	defer c.pool.Put(conn)
 
-	// Используем базовый клиент для конвертации
 	client := &Client{
 		baseURL: c.baseURL,
-		client:  conn.GetConn().(*http.Client),
+		client:  conn.Value(),
+		handler: c.handler,
 	}
-
-	return client.ConvertDocxToPDF(docxPath)
+	result, err := conv(ctx, client)
+	if isFailoverError(err) {
+		// Соединение могло оборваться — не возвращаем его в пул
+		c.pool.Discard(conn)
+		return nil, err
+	}
+	c.pool.Put(conn)
+	return result, err
 }
 
 // HealthCheck выполняет проверку здоровья сервиса
-func (c *ClientWithPool) HealthCheck() error {
-	conn, err := c.pool.Get(context.Background())
+func (c *ClientWithPool) HealthCheck(ctx context.Context) error {
+	conn, err := c.pool.Get(ctx)
 	if err != nil {
 		return err
 	}
@@ -86,10 +119,17 @@ func (c *ClientWithPool) HealthCheck() error {
 
 	client := &Client{
 		baseURL: c.baseURL,
-		client:  conn.GetConn().(*http.Client),
+		client:  conn.Value(),
 	}
 
-	return client.HealthCheck(true)
+	return client.HealthCheck(ctx, true)
+}
+
+// SetHandler устанавливает обработчик для сбора статистики
+func (c *ClientWithPool) SetHandler(handler interface {
+	TrackGotenbergRequest(duration time.Duration, hasError bool, isHealthCheck bool)
+}) {
+	c.handler = handler
 }
 
 // Close закрывает пул соединений
@@ -101,3 +141,36 @@ func (c *ClientWithPool) Close() error {
 func (c *ClientWithPool) Stats() connpool.Stats {
 	return c.pool.Stats()
 }
+
+// conversion запрос конвертации, выполняемый базовым клиентом бэкенда
+type conversion func(ctx context.Context, client *Client) ([]byte, error)
+
+// docxConversion конвертация DOCX маршрутом LibreOffice
+func docxConversion(docxPath string) conversion {
+	return func(ctx context.Context, client *Client) ([]byte, error) {
+		return client.ConvertDocxToPDF(ctx, docxPath)
+	}
+}
+
+// htmlConversion конвертация HTML маршрутом Chromium
+func htmlConversion(doc HTMLDocument) conversion {
+	return func(ctx context.Context, client *Client) ([]byte, error) {
+		return client.ConvertHTMLToPDF(ctx, doc)
+	}
+}
+
+// convertVia конвертирует через пул соединений, если он включён, иначе напрямую через client
+func convertVia(ctx context.Context, client *Client, pool *ClientWithPool, conv conversion) ([]byte, error) {
+	if pool != nil {
+		return pool.do(ctx, conv)
+	}
+	return conv(ctx, client)
+}
+
+// newBackendPool создаёт пул соединений к бэкенду, если он включён
+func newBackendPool(baseURL string) *ClientWithPool {
+	if !PoolEnabled() {
+		return nil
+	}
+	return NewClientWithPool(baseURL)
+}
@@ -1,10 +1,18 @@
 package gotenberg
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"pdf-service-go/internal/pkg/connpool"
 )
 
 func TestClientWithPool_ConvertDocxToPDF(t *testing.T) {
@@ -21,7 +29,7 @@ func TestClientWithPool_ConvertDocxToPDF(t *testing.T) {
 	defer client.Close()
 
 	// Проверяем, что конвертация завершится ошибкой
-	_, err := client.ConvertDocxToPDF(docxPath)
+	_, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	if err == nil {
 		t.Error("Expected error from invalid URL")
 	}
@@ -53,12 +61,12 @@ func TestClientWithPool_Integration(t *testing.T) {
 	defer client.Close()
 
 	// Проверяем здоровье сервиса
-	if err := client.HealthCheck(); err != nil {
+	if err := client.HealthCheck(context.Background()); err != nil {
 		t.Errorf("Health check failed: %v", err)
 	}
 
 	// Проверяем успешную конвертацию
-	pdf, err := client.ConvertDocxToPDF(docxPath)
+	pdf, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	if err != nil {
 		t.Errorf("Failed to convert DOCX to PDF: %v", err)
 	}
@@ -101,7 +109,7 @@ func TestClientWithPool_Concurrency(t *testing.T) {
 
 	for i := 0; i < concurrency; i++ {
 		go func() {
-			pdf, err := client.ConvertDocxToPDF(docxPath)
+			pdf, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 			if err != nil {
 				t.Errorf("Concurrent conversion failed: %v", err)
 			}
@@ -132,3 +140,73 @@ func TestClientWithPool_Concurrency(t *testing.T) {
 		t.Errorf("Expected no active connections after test, got %d", stats.ActiveConnections)
 	}
 }
+
+func TestClientWithPool_LimitsConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	}))
+	defer srv.Close()
+
+	config := connpool.DefaultConfig()
+	config.MinConns = 0
+	config.MaxConns = 2
+	client := newClientWithPool(srv.URL, config)
+	defer client.Close()
+	docx := newTestDocx(t, 16)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ConvertDocxToPDF(context.Background(), docx); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", maxInFlight.Load())
+	}
+	if stats := client.Stats(); stats.ActiveConnections != 0 || stats.TotalConnections > 2 {
+		t.Errorf("Unexpected pool stats after conversions: %+v", stats)
+	}
+}
+
+func TestClientWithPool_DeadlineInQueueIsNotBackendFailure(t *testing.T) {
+	srv := newBlockingServer(t)
+	config := connpool.DefaultConfig()
+	config.MinConns = 0
+	config.MaxConns = 1
+	config.IdleTimeout = time.Minute
+	client := newClientWithPool(srv.URL, config)
+	defer client.Close()
+	docx := newTestDocx(t, 16)
+
+	// Единственное соединение занято зависшей конвертацией
+	busyCtx, cancelBusy := context.WithCancel(context.Background())
+	defer cancelBusy()
+	go func() { _, _ = client.ConvertDocxToPDF(busyCtx, docx) }()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.ConvertDocxToPDF(ctx, docx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, connpool.ErrPoolExhausted) {
+		t.Fatalf("Expected deadline in pool queue, got %v", err)
+	}
+	if isBackendFailure(ctx, err) {
+		t.Error("Deadline while waiting for a pooled connection must not count as backend failure")
+	}
+}
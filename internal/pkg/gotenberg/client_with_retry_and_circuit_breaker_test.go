@@ -1,12 +1,14 @@
 package gotenberg
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/retry"
 )
 
 func TestClientWithRetryAndCircuitBreaker_ConvertDocxToPDF(t *testing.T) {
@@ -31,7 +33,7 @@ func TestClientWithRetryAndCircuitBreaker_ConvertDocxToPDF(t *testing.T) {
 	// Проверяем, что все попытки завершатся ошибкой
 	start := time.Now()
 	for i := 0; i < 6; i++ {
-		_, err := client.ConvertDocxToPDF(docxPath)
+		_, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 		if err == nil {
 			t.Error("Expected error from invalid URL")
 		}
@@ -46,15 +48,19 @@ func TestClientWithRetryAndCircuitBreaker_ConvertDocxToPDF(t *testing.T) {
 		t.Errorf("Expected state to be Open after failures, got %v", state)
 	}
 
-	// Проверяем, что время выполнения соответствует ожидаемому количеству retry
-	expectedMinDuration := 300 * time.Millisecond
+	// Проверяем, что время выполнения соответствует ожидаемому количеству retry:
+	// до открытия Circuit Breaker (10 сбоев) первые три вызова делают по MaxAttempts попыток
+	// с задержками политики ошибок соединения (20ms и 30ms)
+	policy := retry.RetryConfigs[retry.ErrorTypeConnection]
+	perCall := policy.InitialDelay + time.Duration(float64(policy.InitialDelay)*policy.BackoffFactor)
+	expectedMinDuration := 3 * perCall
 	if duration < expectedMinDuration {
 		t.Errorf("Expected duration >= %v, got %v", expectedMinDuration, duration)
 	}
 
 	// Проверяем быстрый отказ при открытом Circuit Breaker
 	start = time.Now()
-	_, err := client.ConvertDocxToPDF(docxPath)
+	_, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	fastFailDuration := time.Since(start)
 
 	if err == nil {
@@ -88,7 +94,7 @@ func TestClientWithRetryAndCircuitBreaker_Integration(t *testing.T) {
 	client.SetHandler(handler)
 
 	// Проверяем успешную конвертацию
-	pdf, err := client.ConvertDocxToPDF(docxPath)
+	pdf, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	if err != nil {
 		t.Errorf("Failed to convert DOCX to PDF: %v", err)
 	}
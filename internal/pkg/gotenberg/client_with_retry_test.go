@@ -1,6 +1,7 @@
 package gotenberg
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -21,7 +22,7 @@ func TestClientWithRetry_ConvertDocxToPDF(t *testing.T) {
 
 	// Проверяем, что все попытки завершатся ошибкой
 	start := time.Now()
-	_, err := client.ConvertDocxToPDF(docxPath)
+	_, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	duration := time.Since(start)
 
 	// Проверяем, что была ошибка
@@ -55,7 +56,7 @@ func TestClientWithRetry_Integration(t *testing.T) {
 	client := NewClientWithRetry(gotenbergURL)
 
 	// Проверяем успешную конвертацию
-	pdf, err := client.ConvertDocxToPDF(docxPath)
+	pdf, err := client.ConvertDocxToPDF(context.Background(), docxPath)
 	if err != nil {
 		t.Errorf("Failed to convert DOCX to PDF: %v", err)
 	}
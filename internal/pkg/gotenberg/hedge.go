@@ -0,0 +1,222 @@
+package gotenberg
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	hedgeRequests = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gotenberg_hedge_requests_total",
+			Help: "Total number of hedged (duplicate) conversions sent to another Gotenberg backend",
+		},
+	)
+
+	hedgeWins = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gotenberg_hedge_wins_total",
+			Help: "Total number of conversions where the hedged request finished first",
+		},
+	)
+
+	hedgeSkipped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gotenberg_hedge_skipped_total",
+			Help: "Total number of hedges not sent (budget exhausted or no other backend available)",
+		},
+		[]string{"reason"},
+	)
+
+	hedgeDelay = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gotenberg_hedge_delay_seconds",
+			Help: "Current delay after which a slow conversion is hedged",
+		},
+	)
+)
+
+// hedgeSampleSize количество последних длительностей для расчёта перцентиля
+const hedgeSampleSize = 256
+
+// hedgeMinSamples минимум наблюдений, после которого задержка считается по перцентилю
+const hedgeMinSamples = 20
+
+// HedgeConfig настройки хеджирования медленных конвертаций
+type HedgeConfig struct {
+	Enabled bool
+	// Percentile перцентиль длительности успешных конвертаций, после которого отправляется дубль
+	Percentile float64
+	// MinDelay и MaxDelay ограничивают задержку; MaxDelay используется, пока наблюдений мало
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// BudgetPercent максимум дублей в процентах от числа конвертаций
+	BudgetPercent float64
+}
+
+// HedgeConfigFromEnv читает настройки хеджирования из окружения
+func HedgeConfigFromEnv() HedgeConfig {
+	return HedgeConfig{
+		Enabled:       getEnvBoolWithDefault("GOTENBERG_HEDGE_ENABLED", false),
+		Percentile:    getEnvFloatWithDefault("GOTENBERG_HEDGE_PERCENTILE", 0.95),
+		MinDelay:      getEnvDurationWithDefault("GOTENBERG_HEDGE_MIN_DELAY", 500*time.Millisecond),
+		MaxDelay:      getEnvDurationWithDefault("GOTENBERG_HEDGE_MAX_DELAY", 30*time.Second),
+		BudgetPercent: getEnvFloatWithDefault("GOTENBERG_HEDGE_BUDGET_PERCENT", 10),
+	}
+}
+
+// hedger считает задержку хеджирования по перцентилю и ограничивает число дублей бюджетом
+type hedger struct {
+	cfg HedgeConfig
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	// tokens бюджет дублей: пополняется на BudgetPercent/100 с каждой конвертацией
+	tokens    float64
+	maxTokens float64
+}
+
+func newHedger(cfg HedgeConfig) *hedger {
+	if cfg.Percentile <= 0 || cfg.Percentile >= 1 {
+		cfg.Percentile = 0.95
+	}
+	if cfg.MinDelay <= 0 {
+		cfg.MinDelay = 500 * time.Millisecond
+	}
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay
+	}
+	if cfg.BudgetPercent < 0 {
+		cfg.BudgetPercent = 0
+	}
+	// Небольшой запас позволяет пережить короткий всплеск медленных ответов
+	maxTokens := math.Max(1, cfg.BudgetPercent/10)
+	return &hedger{cfg: cfg, samples: make([]time.Duration, 0, hedgeSampleSize), maxTokens: maxTokens}
+}
+
+// delay возвращает задержку перед отправкой дубля
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d := h.cfg.MaxDelay
+	if len(h.samples) >= hedgeMinSamples {
+		sorted := make([]time.Duration, len(h.samples))
+		copy(sorted, h.samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		idx := int(math.Ceil(h.cfg.Percentile*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		d = sorted[idx]
+	}
+	if d < h.cfg.MinDelay {
+		d = h.cfg.MinDelay
+	}
+	if d > h.cfg.MaxDelay {
+		d = h.cfg.MaxDelay
+	}
+	hedgeDelay.Set(d.Seconds())
+	return d
+}
+
+// observe запоминает длительность успешной конвертации
+func (h *hedger) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < hedgeSampleSize {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % hedgeSampleSize
+}
+
+// deposit пополняет бюджет при каждой конвертации
+func (h *hedger) deposit() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens = math.Min(h.maxTokens, h.tokens+h.cfg.BudgetPercent/100)
+}
+
+// withdraw списывает дубль из бюджета
+func (h *hedger) withdraw() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+type hedgeOutcome struct {
+	result []byte
+	err    error
+	hedged bool
+}
+
+// convertHedged отправляет основной запрос и, если он не завершился за задержку хеджирования,
+// дубль на другой бэкенд. Используется первый успешный ответ, второй запрос отменяется.
+func (b *Balancer) convertHedged(parent context.Context, conv conversion) ([]byte, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	start := b.now()
+	tried := make(map[*backend]bool, len(b.backends))
+	results := make(chan hedgeOutcome, 2)
+	run := func(hedged bool) {
+		result, err := b.convertWithFailover(ctx, conv, tried)
+		results <- hedgeOutcome{result: result, err: err, hedged: hedged}
+	}
+
+	b.hedger.deposit()
+	go run(false)
+	timer := time.NewTimer(b.hedger.delay())
+	defer timer.Stop()
+
+	pending := 1
+	var firstErr error
+	for pending > 0 {
+		select {
+		case o := <-results:
+			pending--
+			if o.err == nil {
+				b.hedger.observe(b.now().Sub(start))
+				if o.hedged {
+					hedgeWins.Inc()
+				}
+				return o.result, nil
+			}
+			if firstErr == nil {
+				firstErr = o.err
+			}
+		case <-timer.C:
+			if ctx.Err() != nil {
+				continue
+			}
+			if !b.hasCandidate(tried) {
+				hedgeSkipped.WithLabelValues("no_backend").Inc()
+				continue
+			}
+			if !b.hedger.withdraw() {
+				hedgeSkipped.WithLabelValues("budget").Inc()
+				continue
+			}
+			hedgeRequests.Inc()
+			b.log.Info("Gotenberg conversion is slow, sending hedged request",
+				zap.Duration("elapsed", b.now().Sub(start)))
+			pending++
+			go run(true)
+		}
+	}
+	return nil, firstErr
+}
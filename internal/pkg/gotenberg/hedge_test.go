@@ -0,0 +1,118 @@
+package gotenberg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHedger_DelayPercentile(t *testing.T) {
+	h := newHedger(HedgeConfig{Percentile: 0.9, MinDelay: 5 * time.Millisecond, MaxDelay: time.Second})
+
+	// Пока наблюдений мало, используется максимальная задержка
+	if d := h.delay(); d != time.Second {
+		t.Errorf("Expected MaxDelay without samples, got %v", d)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if d := h.delay(); d != 90*time.Millisecond {
+		t.Errorf("Expected p90 = 90ms, got %v", d)
+	}
+
+	fast := newHedger(HedgeConfig{Percentile: 0.9, MinDelay: 200 * time.Millisecond, MaxDelay: time.Second})
+	for i := 0; i < hedgeMinSamples; i++ {
+		fast.observe(time.Millisecond)
+	}
+	if d := fast.delay(); d != 200*time.Millisecond {
+		t.Errorf("Expected delay clamped to MinDelay, got %v", d)
+	}
+}
+
+func TestHedger_Budget(t *testing.T) {
+	h := newHedger(HedgeConfig{BudgetPercent: 50})
+	if h.withdraw() {
+		t.Fatal("Expected empty budget initially")
+	}
+	h.deposit()
+	if h.withdraw() {
+		t.Fatal("Expected half a token to be insufficient")
+	}
+	h.deposit()
+	if !h.withdraw() {
+		t.Fatal("Expected hedge to be allowed after two conversions at 50%")
+	}
+	if h.withdraw() {
+		t.Error("Expected budget to be exhausted")
+	}
+}
+
+func TestBalancer_HedgedRequestWins(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Сервер замечает разрыв соединения только после чтения тела
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer slow.Close()
+	fast, fastCalls := newTestBackend(t, http.StatusOK)
+
+	cfg := testBalancerConfig(BackendConfig{slow.URL, 1}, BackendConfig{fast.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	cfg.Hedge = HedgeConfig{Enabled: true, Percentile: 0.95, MinDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond, BudgetPercent: 100}
+	b, err := NewBalancer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); err != nil {
+		t.Fatalf("Expected hedged request to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected hedge to avoid waiting for the slow backend, took %v", elapsed)
+	}
+	if fastCalls.Load() != 1 {
+		t.Errorf("Expected one hedged call to the fast backend, got %d", fastCalls.Load())
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected slow request to be canceled")
+	}
+	// Отменённый запрос не должен считаться сбоем бэкенда
+	if statuses := b.Backends(); statuses[0].State != "Closed" || statuses[0].Ejected {
+		t.Errorf("Expected slow backend to stay healthy, got %+v", statuses[0])
+	}
+}
+
+func TestBalancer_HedgeBudgetExhausted(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	fast, fastCalls := newTestBackend(t, http.StatusOK)
+
+	cfg := testBalancerConfig(BackendConfig{slow.URL, 1}, BackendConfig{fast.URL, 1})
+	cfg.Strategy = StrategyWeightedRoundRobin
+	cfg.Hedge = HedgeConfig{Enabled: true, MinDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond, BudgetPercent: 0}
+	b, _ := NewBalancer(cfg)
+
+	if _, err := b.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fastCalls.Load() != 0 {
+		t.Errorf("Expected no hedges with zero budget, got %d", fastCalls.Load())
+	}
+}
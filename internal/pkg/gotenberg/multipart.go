@@ -0,0 +1,96 @@
+package gotenberg
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// copyBufferSize размер буфера копирования файла в тело запроса
+const copyBufferSize = 64 * 1024
+
+// copyBuffers переиспользуемые буферы копирования: один на одновременно передаваемый файл
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// formFile открытый файл для передачи в multipart форме
+type formFile struct {
+	name string
+	file *os.File
+}
+
+// openFormFiles открывает файлы до отправки запроса, чтобы ошибка чтения с диска
+// возвращалась сразу, а не обрывала уже начатую передачу.
+// При нескольких файлах к имени добавляется номер: Gotenberg объединяет файлы в алфавитном порядке,
+// номер сохраняет порядок аргументов и различает файлы с одинаковыми именами.
+func openFormFiles(paths []string) ([]formFile, error) {
+	files := make([]formFile, 0, len(paths))
+	for i, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			closeFormFiles(files)
+			return nil, fmt.Errorf("failed to open DOCX file: %w", err)
+		}
+		name := filepath.Base(path)
+		if len(paths) > 1 {
+			name = fmt.Sprintf("%03d_%s", i+1, name)
+		}
+		files = append(files, formFile{name: name, file: file})
+	}
+	return files, nil
+}
+
+func closeFormFiles(files []formFile) {
+	for _, f := range files {
+		f.file.Close()
+	}
+}
+
+// streamForm пишет multipart форму в io.Pipe в отдельной горутине: тело запроса передаётся
+// по мере чтения транспортом и не собирается в памяти целиком. Ошибка записи обрывает запрос;
+// закрытие читающей стороны (транспорт закрывает тело при ошибке) завершает горутину.
+func streamForm(write func(*multipart.Writer) error) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		err := write(writer)
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, writer.FormDataContentType()
+}
+
+// writeDocxForm копирует файлы в форму LibreOffice маршрута и закрывает их.
+// Несколько файлов объединяются Gotenberg в один PDF.
+func writeDocxForm(writer *multipart.Writer, files []formFile) error {
+	defer closeFormFiles(files)
+
+	bufPtr := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(bufPtr)
+
+	for _, f := range files {
+		part, err := writer.CreateFormFile("files", f.name)
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+		// Обёртка скрывает os.File.WriteTo: иначе io.CopyBuffer выделит свой буфер вместо буфера из пула
+		if _, err := io.CopyBuffer(part, struct{ io.Reader }{f.file}, *bufPtr); err != nil {
+			return fmt.Errorf("failed to copy file content: %w", err)
+		}
+	}
+	if len(files) > 1 {
+		if err := writer.WriteField("merge", "true"); err != nil {
+			return fmt.Errorf("failed to write field merge: %w", err)
+		}
+	}
+	return nil
+}
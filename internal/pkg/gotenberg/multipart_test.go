@@ -0,0 +1,202 @@
+package gotenberg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_ConvertDocxFilesToPDF(t *testing.T) {
+	var names, contents []string
+	var merge string
+	var contentLength int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		reader, err := r.MultipartReader()
+		if err != nil {
+			t.Errorf("Failed to read form: %v", err)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("Failed to read part: %v", err)
+				return
+			}
+			content, _ := io.ReadAll(part)
+			if part.FormName() == "merge" {
+				merge = string(content)
+				continue
+			}
+			names = append(names, part.FileName())
+			contents = append(contents, string(content))
+		}
+		_, _ = w.Write([]byte("%PDF-merged"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	second := filepath.Join(dir, "a.docx")
+	if err := os.WriteFile(second, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(t.TempDir(), "b.docx")
+	if err := os.WriteFile(first, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Одинаковые имена в разных директориях не должны конфликтовать
+	third := filepath.Join(t.TempDir(), "a.docx")
+	if err := os.WriteFile(third, []byte("third"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewClient(srv.URL).ConvertDocxFilesToPDF(context.Background(), first, second, third)
+	if err != nil || string(result) != "%PDF-merged" {
+		t.Fatalf("Expected merged pdf, got %q, %v", result, err)
+	}
+	if strings.Join(names, ",") != "001_b.docx,002_a.docx,003_a.docx" {
+		t.Errorf("Expected numbered names in argument order, got %v", names)
+	}
+	if strings.Join(contents, ",") != "first,second,third" {
+		t.Errorf("Unexpected contents: %v", contents)
+	}
+	if merge != "true" {
+		t.Errorf("Expected merge=true, got %q", merge)
+	}
+	if contentLength != -1 {
+		t.Errorf("Expected streamed body without Content-Length, got %d", contentLength)
+	}
+}
+
+func TestClient_ConvertDocxToPDF_Streams(t *testing.T) {
+	const size = 3 << 20
+	var received int64
+	var fields int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+			return
+		}
+		fields = len(r.MultipartForm.Value)
+		for _, fh := range r.MultipartForm.File["files"] {
+			if fh.Filename != "test.docx" {
+				t.Errorf("Expected original file name, got %s", fh.Filename)
+			}
+			received = fh.Size
+		}
+		_, _ = w.Write([]byte("%PDF"))
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL).ConvertDocxToPDF(context.Background(), newTestDocx(t, size)); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+	if received != size {
+		t.Errorf("Expected %d bytes, got %d", size, received)
+	}
+	if fields != 0 {
+		t.Error("Expected no merge field for a single file")
+	}
+}
+
+func TestClient_ConvertDocxToPDF_MissingFile(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	existing := newTestDocx(t, 10)
+	_, err := NewClient(srv.URL).ConvertDocxFilesToPDF(context.Background(), existing, filepath.Join(t.TempDir(), "missing.docx"))
+	if err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+	if isBackendFailure(context.Background(), err) {
+		t.Error("Missing local file must not count as Gotenberg failure")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected no request to Gotenberg, got %d", calls.Load())
+	}
+
+	if _, err := NewClient(srv.URL).ConvertDocxFilesToPDF(context.Background()); err == nil {
+		t.Error("Expected error without files")
+	}
+}
+
+// bufferedDocxForm собирает форму в памяти, как это делал клиент до потоковой передачи (для сравнения в бенчмарках)
+func bufferedDocxForm(path string) (*bytes.Buffer, string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+	part, err := writer.CreateFormFile("files", filepath.Base(path))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.CopyBuffer(part, file, make([]byte, 64*1024)); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body, writer.FormDataContentType(), nil
+}
+
+// newDiscardServer сервер Gotenberg, который вычитывает тело запроса и возвращает короткий PDF
+func newDiscardServer(b *testing.B) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("%PDF"))
+	}))
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+// Сравнение памяти на конвертацию: B/op буферизованной формы растёт с размером документа, потоковой — нет.
+//
+//	go test ./internal/pkg/gotenberg -run '^$' -bench ConvertDocxToPDF -benchmem
+func BenchmarkConvertDocxToPDF(b *testing.B) {
+	for _, size := range []int{1 << 20, 10 << 20} {
+		path := newTestDocx(b, size)
+		client := NewClient(newDiscardServer(b).URL)
+		ctx := context.Background()
+
+		b.Run(fmt.Sprintf("buffered/%dMB", size>>20), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				body, contentType, err := bufferedDocxForm(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := client.postForm(ctx, "/forms/libreoffice/convert", body, contentType); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("streaming/%dMB", size>>20), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if _, err := client.ConvertDocxToPDF(ctx, path); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package gotenberg
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	probeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gotenberg_probe_duration_seconds",
+			Help:    "Latency of background Gotenberg /health probes",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+		},
+		[]string{"backend", "result"},
+	)
+
+	probeHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gotenberg_probe_healthy",
+			Help: "Cached Gotenberg health state from background probes (1: healthy)",
+		},
+		[]string{"backend"},
+	)
+
+	probeTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gotenberg_probe_transitions_total",
+			Help: "Total number of Gotenberg health state changes detected by background probes",
+		},
+		[]string{"backend", "to"},
+	)
+)
+
+// ProberConfig настройки фоновой проверки /health Gotenberg
+type ProberConfig struct {
+	// Interval период опроса (0 — проверка выключена, бэкенд всегда считается здоровым)
+	Interval time.Duration
+	// Timeout таймаут одной проверки
+	Timeout time.Duration
+	// HealthyThreshold успешных проверок подряд для перехода в здоровое состояние
+	HealthyThreshold int
+	// UnhealthyThreshold неудачных проверок подряд для перехода в нездоровое состояние
+	UnhealthyThreshold int
+}
+
+// ProberConfigFromEnv читает настройки фоновой проверки из окружения
+func ProberConfigFromEnv() ProberConfig {
+	return ProberConfig{
+		Interval:           getEnvDurationWithDefault("GOTENBERG_PROBE_INTERVAL", 5*time.Second),
+		Timeout:            getEnvDurationWithDefault("GOTENBERG_PROBE_TIMEOUT", 2*time.Second),
+		HealthyThreshold:   getEnvIntWithDefault("GOTENBERG_PROBE_HEALTHY_THRESHOLD", 2),
+		UnhealthyThreshold: getEnvIntWithDefault("GOTENBERG_PROBE_UNHEALTHY_THRESHOLD", 3),
+	}
+}
+
+// ProbeStatus последнее известное состояние бэкенда по фоновой проверке
+type ProbeStatus struct {
+	Healthy   bool          `json:"healthy"`
+	LastProbe time.Time     `json:"last_probe,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
+// Prober периодически опрашивает /health Gotenberg и хранит состояние с гистерезисом.
+// Конвертации не выполняют проверку здоровья сами, а читают закэшированное состояние.
+type Prober struct {
+	name   string
+	client *Client
+	cfg    ProberConfig
+	log    *zap.Logger
+
+	mu        sync.RWMutex
+	healthy   bool
+	successes int
+	failures  int
+	status    ProbeStatus
+
+	started   atomic.Bool
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewProber создаёт проверку для бэкенда; до первых результатов бэкенд считается здоровым
+func NewProber(name string, client *Client, cfg ProberConfig) *Prober {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.HealthyThreshold < 1 {
+		cfg.HealthyThreshold = 1
+	}
+	if cfg.UnhealthyThreshold < 1 {
+		cfg.UnhealthyThreshold = 1
+	}
+	log := logger.Log
+	if log == nil {
+		log = zap.NewNop()
+	}
+	probeHealthy.WithLabelValues(name).Set(1)
+	return &Prober{
+		name:    name,
+		client:  client,
+		cfg:     cfg,
+		log:     log,
+		healthy: true,
+		status:  ProbeStatus{Healthy: true},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start запускает фоновый опрос; повторный вызов ничего не делает
+func (p *Prober) Start() {
+	if p.cfg.Interval <= 0 {
+		return
+	}
+	p.startOnce.Do(func() {
+		p.started.Store(true)
+		go p.run()
+	})
+}
+
+// Stop останавливает фоновый опрос и дожидается его завершения
+func (p *Prober) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	if p.started.Load() {
+		<-p.done
+	}
+}
+
+func (p *Prober) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	p.Probe(context.Background())
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.Probe(context.Background())
+		}
+	}
+}
+
+// Probe выполняет одну проверку и обновляет закэшированное состояние
+func (p *Prober) Probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.client.HealthCheck(ctx, true)
+	latency := time.Since(start)
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	probeDuration.WithLabelValues(p.name, result).Observe(latency.Seconds())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status.LastProbe = start
+	p.status.Latency = latency
+	if err != nil {
+		p.status.LastError = err.Error()
+		p.successes = 0
+		p.failures++
+		if p.healthy && p.failures >= p.cfg.UnhealthyThreshold {
+			p.setHealthyLocked(false)
+			p.log.Warn("Gotenberg backend marked unhealthy by health probe",
+				zap.String("backend", p.name),
+				zap.Int("failures", p.failures),
+				zap.Error(err))
+		}
+		return
+	}
+
+	p.status.LastError = ""
+	p.failures = 0
+	p.successes++
+	if !p.healthy && p.successes >= p.cfg.HealthyThreshold {
+		p.setHealthyLocked(true)
+		p.log.Info("Gotenberg backend marked healthy by health probe",
+			zap.String("backend", p.name))
+	}
+}
+
+func (p *Prober) setHealthyLocked(healthy bool) {
+	p.healthy = healthy
+	p.status.Healthy = healthy
+	to := "unhealthy"
+	value := 0.0
+	if healthy {
+		to = "healthy"
+		value = 1
+	}
+	probeHealthy.WithLabelValues(p.name).Set(value)
+	probeTransitions.WithLabelValues(p.name, to).Inc()
+}
+
+// Healthy возвращает закэшированное состояние бэкенда; nil Prober считается здоровым
+func (p *Prober) Healthy() bool {
+	if p == nil {
+		return true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.healthy
+}
+
+// Status возвращает подробности последней проверки
+func (p *Prober) Status() ProbeStatus {
+	if p == nil {
+		return ProbeStatus{Healthy: true}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.status
+}
+
+// Check возвращает *circuitbreaker.OpenError до следующей проверки, если бэкенд нездоров.
+// Сбои проверки не учитываются Circuit Breaker конвертаций.
+func (p *Prober) Check() error {
+	if p.Healthy() {
+		return nil
+	}
+	retryAfter := p.cfg.Interval
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	return &circuitbreaker.OpenError{Name: "gotenberg", RetryAfter: retryAfter}
+}
@@ -0,0 +1,137 @@
+package gotenberg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+)
+
+// newHealthServer возвращает сервер, у которого /health отвечает кодом из health, а конвертации считаются
+func newHealthServer(t *testing.T, health *atomic.Int64) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var conversions atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(int(health.Load()))
+			return
+		}
+		conversions.Add(1)
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &conversions
+}
+
+func TestProber_Hysteresis(t *testing.T) {
+	var health atomic.Int64
+	health.Store(http.StatusServiceUnavailable)
+	srv, _ := newHealthServer(t, &health)
+	p := NewProber("test", NewClient(srv.URL), ProberConfig{Timeout: time.Second, HealthyThreshold: 2, UnhealthyThreshold: 3})
+
+	for i := 0; i < 2; i++ {
+		p.Probe(context.Background())
+		if !p.Healthy() {
+			t.Fatalf("Expected backend to stay healthy after %d failures", i+1)
+		}
+	}
+	p.Probe(context.Background())
+	if p.Healthy() {
+		t.Fatal("Expected backend to become unhealthy after 3 failures")
+	}
+	var openErr *circuitbreaker.OpenError
+	if err := p.Check(); !errors.As(err, &openErr) {
+		t.Errorf("Expected OpenError from Check, got %v", err)
+	}
+	if p.Status().LastError == "" {
+		t.Error("Expected last probe error to be recorded")
+	}
+
+	health.Store(http.StatusOK)
+	p.Probe(context.Background())
+	if p.Healthy() {
+		t.Fatal("Expected backend to stay unhealthy after a single success")
+	}
+	p.Probe(context.Background())
+	if !p.Healthy() || p.Check() != nil {
+		t.Error("Expected backend to recover after 2 successes")
+	}
+}
+
+func TestProber_StartStop(t *testing.T) {
+	var health atomic.Int64
+	health.Store(http.StatusServiceUnavailable)
+	srv, _ := newHealthServer(t, &health)
+	p := NewProber("test", NewClient(srv.URL), ProberConfig{Interval: 10 * time.Millisecond, Timeout: time.Second, UnhealthyThreshold: 1})
+
+	p.Start()
+	defer p.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Healthy() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.Healthy() {
+		t.Fatal("Expected background probe to mark backend unhealthy")
+	}
+}
+
+func TestClientWithCircuitBreaker_UsesCachedHealth(t *testing.T) {
+	var health atomic.Int64
+	health.Store(http.StatusServiceUnavailable)
+	srv, conversions := newHealthServer(t, &health)
+	client := NewClientWithCircuitBreaker(srv.URL)
+	client.prober = NewProber("test", client.client, ProberConfig{Timeout: time.Second, HealthyThreshold: 1, UnhealthyThreshold: 1})
+
+	if _, err := client.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); err != nil {
+		t.Fatalf("Conversion must not depend on /health: %v", err)
+	}
+
+	client.prober.Probe(context.Background())
+	if client.Ready() {
+		t.Fatal("Expected client not to be ready")
+	}
+	if _, err := client.ConvertDocxToPDF(context.Background(), newTestDocx(t, 16)); !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Errorf("Expected fast failure while unhealthy, got %v", err)
+	}
+	if conversions.Load() != 1 {
+		t.Errorf("Expected no conversion to reach unhealthy Gotenberg, got %d", conversions.Load())
+	}
+	if state := client.State(); state != circuitbreaker.StateClosed {
+		t.Errorf("Health probe failures must not open the breaker, got %v", state)
+	}
+}
+
+func TestBalancer_SkipsUnhealthyBackend(t *testing.T) {
+	var healthA, healthB atomic.Int64
+	healthA.Store(http.StatusServiceUnavailable)
+	healthB.Store(http.StatusOK)
+	srvA, convA := newHealthServer(t, &healthA)
+	srvB, convB := newHealthServer(t, &healthB)
+	cfg := testBalancerConfig(BackendConfig{srvA.URL, 1}, BackendConfig{srvB.URL, 1})
+	cfg.Probe = ProberConfig{Timeout: time.Second, HealthyThreshold: 1, UnhealthyThreshold: 1}
+	b, err := NewBalancer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, be := range b.backends {
+		be.prober.Probe(context.Background())
+	}
+
+	docx := newTestDocx(t, 16)
+	for i := 0; i < 4; i++ {
+		if _, err := b.ConvertDocxToPDF(context.Background(), docx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if convA.Load() != 0 || convB.Load() != 4 {
+		t.Errorf("Expected all conversions on healthy backend, got a=%d b=%d", convA.Load(), convB.Load())
+	}
+	if !b.Ready() {
+		t.Error("Expected balancer to be ready with one healthy backend")
+	}
+}
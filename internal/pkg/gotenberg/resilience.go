@@ -0,0 +1,37 @@
+package gotenberg
+
+import (
+	"time"
+
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/resilience"
+	"pdf-service-go/internal/pkg/retry"
+)
+
+// backendSettings настройки конвейера конвертаций одного бэкенда по умолчанию.
+// Повторы выключены: их включает GOTENBERG_PIPELINE_ORDER со звеном retry.
+func backendSettings() resilience.Settings {
+	return resilience.Settings{
+		Order: []resilience.Stage{resilience.StageTimeout, resilience.StageCircuitBreaker, resilience.StageAttemptTimeout},
+		Retry: retry.Config{MaxAttempts: 1},
+		Breaker: circuitbreaker.Config{
+			FailureThreshold: 5,
+			ResetTimeout:     10 * time.Second,
+			HalfOpenMaxCalls: 2,
+			SuccessThreshold: 2,
+		},
+	}
+}
+
+// pipelineSettings дополняет настройки по умолчанию клиента переменными GOTENBERG_PIPELINE_*, GOTENBERG_RETRY_*
+// и CIRCUIT_BREAKER_*. Какие ошибки считаются сбоем Gotenberg, решает isBackendFailure.
+func pipelineSettings(defaults resilience.Settings) resilience.Settings {
+	defaults.Name = "gotenberg"
+	defaults.Breaker.IsFailure = isBackendFailure
+	return resilience.SettingsFromEnv(defaults, resilience.EnvPrefixes{Pipeline: "GOTENBERG_", Retry: "GOTENBERG_"})
+}
+
+// newPipeline собирает конвейер устойчивости конвертаций из настроек по умолчанию клиента и окружения
+func newPipeline(defaults resilience.Settings) *resilience.Pipeline[[]byte] {
+	return resilience.Build[[]byte](pipelineSettings(defaults), nil)
+}
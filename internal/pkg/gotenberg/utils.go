@@ -24,4 +24,31 @@ func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Dura
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+// getEnvFloatWithDefault возвращает дробное значение переменной окружения или значение по умолчанию
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBoolWithDefault возвращает булево значение переменной окружения или значение по умолчанию
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// getEnvWithDefault возвращает значение переменной окружения или значение по умолчанию
+func getEnvWithDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -53,4 +53,22 @@ var (
 		},
 		[]string{"operation"},
 	)
+
+	// RetryBudgetTokens доступные токены бюджета повторов
+	RetryBudgetTokens = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "retry_budget_tokens",
+			Help: "Retry tokens currently available in the operation retry budget",
+		},
+		[]string{"operation"},
+	)
+
+	// RetryBudgetExhausted количество повторов, отменённых из-за исчерпания бюджета
+	RetryBudgetExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_budget_exhausted_total",
+			Help: "Total number of retries skipped because the retry budget was exhausted",
+		},
+		[]string{"operation"},
+	)
 )
@@ -0,0 +1,332 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Причины отказа
+const (
+	ReasonRate         = "rate"
+	ReasonQuotaDaily   = "quota_daily"
+	ReasonQuotaMonthly = "quota_monthly"
+)
+
+// Атрибуты клиента (API ключа или JWT), переопределяющие лимиты по умолчанию
+const (
+	AttrRPS          = "rate_limit_rps"
+	AttrBurst        = "rate_limit_burst"
+	AttrQuotaDaily   = "quota_daily"
+	AttrQuotaMonthly = "quota_monthly"
+)
+
+// Limits лимиты клиента. Нулевые значения означают отсутствие ограничения
+type Limits struct {
+	// RPS скорость пополнения токенов в секунду
+	RPS float64
+	// Burst ёмкость ведра токенов
+	Burst int
+	// Daily максимум документов за сутки (UTC)
+	Daily int64
+	// Monthly максимум документов за календарный месяц (UTC)
+	Monthly int64
+}
+
+// Config настройки ограничителя
+type Config struct {
+	Enabled bool
+	Default Limits
+	// IdleTTL время, после которого неиспользуемое ведро клиента удаляется из памяти
+	IdleTTL time.Duration
+}
+
+// ConfigFromEnv читает настройки из окружения
+func ConfigFromEnv() Config {
+	return Config{
+		Enabled: getEnvBoolWithDefault("RATE_LIMIT_ENABLED", false),
+		Default: Limits{
+			RPS:     getEnvFloatWithDefault("RATE_LIMIT_RPS", 5),
+			Burst:   getEnvIntWithDefault("RATE_LIMIT_BURST", 20),
+			Daily:   int64(getEnvIntWithDefault("QUOTA_DAILY", 0)),
+			Monthly: int64(getEnvIntWithDefault("QUOTA_MONTHLY", 0)),
+		},
+		IdleTTL: getEnvDurationWithDefault("RATE_LIMIT_IDLE_TTL", 10*time.Minute),
+	}
+}
+
+// Client идентифицирует клиента для учёта лимитов
+type Client struct {
+	// ID ключ учёта: идентификатор API ключа, subject JWT или IP адрес
+	ID string
+	// Type тип клиента для метрик: api_key, jwt, bootstrap или ip
+	Type string
+	// Attributes атрибуты клиента с индивидуальными лимитами
+	Attributes map[string]string
+}
+
+// Decision результат проверки лимитов
+type Decision struct {
+	Allowed bool
+	// Reason причина отказа (rate, quota_daily, quota_monthly)
+	Reason string
+	// RetryAfter через сколько имеет смысл повторить запрос
+	RetryAfter time.Duration
+	Limits     Limits
+	// RateRemaining оставшиеся токены в ведре
+	RateRemaining int
+	DailyUsed     int64
+	DailyReset    time.Time
+	MonthlyUsed   int64
+	MonthlyReset  time.Time
+
+	// dailyStart и monthlyStart периоды списанных квот; нулевое значение — квота не списывалась
+	dailyStart   time.Time
+	monthlyStart time.Time
+}
+
+// Limiter реализует token bucket на клиента и суточные/месячные квоты документов
+type Limiter struct {
+	cfg      Config
+	quotas   QuotaStore
+	fallback *MemoryQuotaStore
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// New создаёт ограничитель. Если quotas == nil, квоты считаются в памяти пода
+func New(cfg Config, quotas QuotaStore) *Limiter {
+	fallback := NewMemoryQuotaStore()
+	if quotas == nil {
+		quotas = fallback
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = 10 * time.Minute
+	}
+	return &Limiter{
+		cfg:      cfg,
+		quotas:   quotas,
+		fallback: fallback,
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// Enabled возвращает true, если ограничения включены
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.cfg.Enabled
+}
+
+// LimitsFor возвращает лимиты клиента с учётом переопределений из атрибутов
+func (l *Limiter) LimitsFor(attrs map[string]string) Limits {
+	limits := l.cfg.Default
+	if v, err := strconv.ParseFloat(attrs[AttrRPS], 64); err == nil && v >= 0 {
+		limits.RPS = v
+	}
+	if v, err := strconv.Atoi(attrs[AttrBurst]); err == nil && v >= 0 {
+		limits.Burst = v
+	}
+	if v, err := strconv.ParseInt(attrs[AttrQuotaDaily], 10, 64); err == nil && v >= 0 {
+		limits.Daily = v
+	}
+	if v, err := strconv.ParseInt(attrs[AttrQuotaMonthly], 10, 64); err == nil && v >= 0 {
+		limits.Monthly = v
+	}
+	return limits
+}
+
+// Allow проверяет лимиты и, если запрос разрешён, списывает токен и единицу квот
+func (l *Limiter) Allow(ctx context.Context, client Client) Decision {
+	now := l.now()
+	limits := l.LimitsFor(client.Attributes)
+	d := Decision{Allowed: true, Limits: limits}
+
+	if !l.takeToken(client.ID, limits, now, &d) {
+		d.Allowed = false
+		d.Reason = ReasonRate
+		throttledTotal.WithLabelValues(ReasonRate, client.Type).Inc()
+		return d
+	}
+
+	dayStart := startOfDay(now)
+	monthStart := startOfMonth(now)
+	d.DailyReset = dayStart.AddDate(0, 0, 1)
+	d.MonthlyReset = monthStart.AddDate(0, 1, 0)
+
+	if limits.Daily > 0 {
+		used, ok := l.consume(ctx, client.ID, PeriodDaily, dayStart, limits.Daily)
+		d.DailyUsed = used
+		if !ok {
+			d.Allowed = false
+			d.Reason = ReasonQuotaDaily
+			d.RetryAfter = d.DailyReset.Sub(now)
+			throttledTotal.WithLabelValues(ReasonQuotaDaily, client.Type).Inc()
+			return d
+		}
+		d.dailyStart = dayStart
+	}
+	if limits.Monthly > 0 {
+		used, ok := l.consume(ctx, client.ID, PeriodMonthly, monthStart, limits.Monthly)
+		d.MonthlyUsed = used
+		if !ok {
+			// Суточная квота уже списана — возвращаем её, так как документ не будет обработан
+			if limits.Daily > 0 {
+				l.release(ctx, client.ID, PeriodDaily, dayStart)
+				d.DailyUsed--
+			}
+			d.Allowed = false
+			d.Reason = ReasonQuotaMonthly
+			d.RetryAfter = d.MonthlyReset.Sub(now)
+			throttledTotal.WithLabelValues(ReasonQuotaMonthly, client.Type).Inc()
+			return d
+		}
+		d.monthlyStart = monthStart
+	}
+	allowedTotal.WithLabelValues(client.Type).Inc()
+	return d
+}
+
+// Refund возвращает квоты, списанные разрешённым запросом, если документ не был выдан клиенту.
+// Токен ведра не возвращается: он ограничивает частоту запросов, а не число документов.
+func (l *Limiter) Refund(ctx context.Context, client Client, d Decision) {
+	if !d.Allowed {
+		return
+	}
+	if !d.dailyStart.IsZero() {
+		l.release(ctx, client.ID, PeriodDaily, d.dailyStart)
+	}
+	if !d.monthlyStart.IsZero() {
+		l.release(ctx, client.ID, PeriodMonthly, d.monthlyStart)
+	}
+	if !d.dailyStart.IsZero() || !d.monthlyStart.IsZero() {
+		refundedTotal.WithLabelValues(client.Type).Inc()
+	}
+}
+
+// takeToken списывает токен из ведра клиента
+func (l *Limiter) takeToken(clientID string, limits Limits, now time.Time, d *Decision) bool {
+	if limits.RPS <= 0 {
+		d.RateRemaining = -1
+		return true
+	}
+	burst := float64(limits.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(now)
+
+	b, ok := l.buckets[clientID]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[clientID] = b
+		activeBuckets.Set(float64(len(l.buckets)))
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*limits.RPS)
+	b.updated = now
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		d.RateRemaining = 0
+		d.RetryAfter = time.Duration((1 - b.tokens) / limits.RPS * float64(time.Second))
+		return false
+	}
+	b.tokens--
+	d.RateRemaining = int(b.tokens)
+	return true
+}
+
+// sweepLocked удаляет вёдра неактивных клиентов
+func (l *Limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < l.cfg.IdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for id, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.cfg.IdleTTL {
+			delete(l.buckets, id)
+		}
+	}
+	activeBuckets.Set(float64(len(l.buckets)))
+}
+
+// consume списывает квоту. При недоступности хранилища квоты считаются в памяти пода
+func (l *Limiter) consume(ctx context.Context, clientID, period string, start time.Time, limit int64) (int64, bool) {
+	used, ok, err := l.quotas.Consume(ctx, clientID, period, start, limit)
+	if err != nil {
+		quotaStoreErrors.Inc()
+		used, ok, _ = l.fallback.Consume(ctx, clientID, period, start, limit)
+	}
+	return used, ok
+}
+
+func (l *Limiter) release(ctx context.Context, clientID, period string, start time.Time) {
+	if err := l.quotas.Release(ctx, clientID, period, start); err != nil {
+		quotaStoreErrors.Inc()
+		_ = l.fallback.Release(ctx, clientID, period, start)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// getEnvIntWithDefault возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloatWithDefault возвращает дробное значение переменной окружения или значение по умолчанию
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBoolWithDefault возвращает булево значение переменной окружения или значение по умолчанию
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
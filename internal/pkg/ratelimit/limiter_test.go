@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(limits Limits, quotas QuotaStore, now *time.Time) *Limiter {
+	l := New(Config{Enabled: true, Default: limits, IdleTTL: time.Hour}, quotas)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(Limits{RPS: 2, Burst: 3}, nil, &now)
+	client := Client{ID: "ip:1.2.3.4", Type: "ip"}
+
+	for i := 0; i < 3; i++ {
+		if d := l.Allow(context.Background(), client); !d.Allowed {
+			t.Fatalf("Request %d should be allowed within burst", i)
+		}
+	}
+	d := l.Allow(context.Background(), client)
+	if d.Allowed || d.Reason != ReasonRate {
+		t.Fatalf("Expected rate limit, got %+v", d)
+	}
+	if d.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Expected RetryAfter 500ms, got %v", d.RetryAfter)
+	}
+
+	// Другой клиент не затронут
+	if d := l.Allow(context.Background(), Client{ID: "ip:5.6.7.8", Type: "ip"}); !d.Allowed {
+		t.Error("Expected another client to be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if d := l.Allow(context.Background(), client); !d.Allowed {
+		t.Error("Expected token to be refilled")
+	}
+}
+
+func TestLimiter_DailyQuota(t *testing.T) {
+	now := time.Date(2024, 5, 10, 23, 0, 0, 0, time.UTC)
+	l := newTestLimiter(Limits{Daily: 2}, nil, &now)
+	client := Client{ID: "key:1", Type: "api_key"}
+
+	for i := 0; i < 2; i++ {
+		if d := l.Allow(context.Background(), client); !d.Allowed {
+			t.Fatalf("Request %d should be allowed", i)
+		}
+	}
+	d := l.Allow(context.Background(), client)
+	if d.Allowed || d.Reason != ReasonQuotaDaily {
+		t.Fatalf("Expected daily quota, got %+v", d)
+	}
+	if d.RetryAfter != time.Hour {
+		t.Errorf("Expected RetryAfter until midnight (1h), got %v", d.RetryAfter)
+	}
+
+	now = now.Add(time.Hour)
+	if d := l.Allow(context.Background(), client); !d.Allowed || d.DailyUsed != 1 {
+		t.Errorf("Expected quota reset on the next day, got %+v", d)
+	}
+}
+
+func TestLimiter_MonthlyQuotaReleasesDaily(t *testing.T) {
+	now := time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryQuotaStore()
+	l := newTestLimiter(Limits{Daily: 10, Monthly: 1}, store, &now)
+	client := Client{ID: "key:1", Type: "api_key"}
+
+	if d := l.Allow(context.Background(), client); !d.Allowed {
+		t.Fatal("First request should be allowed")
+	}
+	d := l.Allow(context.Background(), client)
+	if d.Allowed || d.Reason != ReasonQuotaMonthly {
+		t.Fatalf("Expected monthly quota, got %+v", d)
+	}
+	if d.DailyUsed != 1 {
+		t.Errorf("Expected daily usage to be rolled back to 1, got %d", d.DailyUsed)
+	}
+	if d.RetryAfter != 14*time.Hour {
+		t.Errorf("Expected RetryAfter until next month (14h), got %v", d.RetryAfter)
+	}
+}
+
+func TestLimiter_Refund(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(Limits{Daily: 1, Monthly: 1}, NewMemoryQuotaStore(), &now)
+	client := Client{ID: "key:1", Type: "api_key"}
+
+	d := l.Allow(context.Background(), client)
+	if !d.Allowed {
+		t.Fatal("First request should be allowed")
+	}
+	if d := l.Allow(context.Background(), client); d.Allowed {
+		t.Fatal("Expected quota to be exhausted")
+	}
+
+	// Отказ не списывал квоту — его возврат ничего не меняет
+	l.Refund(context.Background(), client, Decision{Reason: ReasonQuotaDaily})
+	l.Refund(context.Background(), client, d)
+	d = l.Allow(context.Background(), client)
+	if !d.Allowed || d.DailyUsed != 1 || d.MonthlyUsed != 1 {
+		t.Fatalf("Expected refunded quota to be available again, got %+v", d)
+	}
+	if d := l.Allow(context.Background(), client); d.Allowed {
+		t.Error("Expected a single refund to restore a single document")
+	}
+}
+
+func TestLimiter_AttributeOverrides(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Limits{RPS: 1, Burst: 1, Daily: 1}, nil, &now)
+	limits := l.LimitsFor(map[string]string{AttrRPS: "10", AttrBurst: "50", AttrQuotaDaily: "0", AttrQuotaMonthly: "bad"})
+	if limits.RPS != 10 || limits.Burst != 50 || limits.Daily != 0 || limits.Monthly != 0 {
+		t.Errorf("Unexpected limits %+v", limits)
+	}
+}
+
+type failingQuotaStore struct{}
+
+func (failingQuotaStore) Consume(context.Context, string, string, time.Time, int64) (int64, bool, error) {
+	return 0, false, errors.New("db down")
+}
+
+func (failingQuotaStore) Release(context.Context, string, string, time.Time) error {
+	return errors.New("db down")
+}
+
+func TestLimiter_FallbackOnStoreError(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(Limits{Daily: 1}, failingQuotaStore{}, &now)
+	client := Client{ID: "key:1", Type: "api_key"}
+
+	if d := l.Allow(context.Background(), client); !d.Allowed {
+		t.Fatal("Expected request to be allowed via in-memory fallback")
+	}
+	if d := l.Allow(context.Background(), client); d.Allowed {
+		t.Error("Expected in-memory fallback to enforce quota")
+	}
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// throttledTotal количество отклонённых запросов по причинам
+	throttledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_throttled_total",
+			Help: "Total number of requests rejected by rate limits or quotas",
+		},
+		[]string{"reason", "client_type"},
+	)
+
+	// allowedTotal количество запросов, прошедших проверку лимитов
+	allowedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_allowed_total",
+			Help: "Total number of requests allowed by rate limits and quotas",
+		},
+		[]string{"client_type"},
+	)
+
+	// refundedTotal количество запросов, квоты которых возвращены из-за ошибки обработки
+	refundedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_quota_refunded_total",
+			Help: "Total number of requests whose quota was refunded after a failed response",
+		},
+		[]string{"client_type"},
+	)
+
+	// activeBuckets количество вёдер токенов в памяти
+	activeBuckets = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_limit_active_buckets",
+			Help: "Number of active per-client token buckets",
+		},
+	)
+
+	// quotaStoreErrors ошибки хранилища квот (учёт переключается на память пода)
+	quotaStoreErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_quota_store_errors_total",
+			Help: "Total number of quota store errors (quota accounting fell back to in-memory)",
+		},
+	)
+)
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Периоды квот
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// QuotaStore хранит счётчики квот. Реализация на PostgreSQL (statistics.QuotaStore)
+// делает квоты общими для всех подов.
+type QuotaStore interface {
+	// Consume увеличивает счётчик периода, если он меньше limit. Возвращает текущее значение и признак успеха
+	Consume(ctx context.Context, clientID, period string, start time.Time, limit int64) (int64, bool, error)
+	// Release уменьшает счётчик периода (откат списания)
+	Release(ctx context.Context, clientID, period string, start time.Time) error
+}
+
+type quotaKey struct {
+	client string
+	period string
+	start  time.Time
+}
+
+// MemoryQuotaStore хранит квоты в памяти пода
+type MemoryQuotaStore struct {
+	mu     sync.Mutex
+	counts map[quotaKey]int64
+}
+
+// NewMemoryQuotaStore создаёт хранилище квот в памяти
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counts: make(map[quotaKey]int64)}
+}
+
+// Consume увеличивает счётчик, если лимит не исчерпан
+func (m *MemoryQuotaStore) Consume(_ context.Context, clientID, period string, start time.Time, limit int64) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Удаляем счётчики прошедших периодов того же типа
+	for k := range m.counts {
+		if k.period == period && k.start.Before(start) {
+			delete(m.counts, k)
+		}
+	}
+
+	key := quotaKey{client: clientID, period: period, start: start}
+	used := m.counts[key]
+	if used >= limit {
+		return used, false, nil
+	}
+	used++
+	m.counts[key] = used
+	return used, true, nil
+}
+
+// Release откатывает списание
+func (m *MemoryQuotaStore) Release(_ context.Context, clientID, period string, start time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := quotaKey{client: clientID, period: period, start: start}
+	if m.counts[key] > 0 {
+		m.counts[key]--
+	}
+	return nil
+}
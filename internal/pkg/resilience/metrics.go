@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// callsTotal вызовы через конвейер по результату
+	callsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "resilience_pipeline_calls_total",
+			Help: "Total number of calls executed through the resilience pipeline",
+		},
+		[]string{"pipeline", "result"},
+	)
+
+	// callDuration длительность вызова с учётом всех политик
+	callDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "resilience_pipeline_call_duration_seconds",
+			Help:    "Duration of calls through the resilience pipeline including retries and waits",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+		},
+		[]string{"pipeline"},
+	)
+
+	// fallbacksTotal срабатывания запасного варианта
+	fallbacksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "resilience_pipeline_fallbacks_total",
+			Help: "Total number of failed calls handed to the pipeline fallback",
+		},
+		[]string{"pipeline"},
+	)
+)
+
+// observeCall записывает результат вызова через конвейер
+func observeCall(pipeline string, start time.Time, err error) {
+	callDuration.WithLabelValues(pipeline).Observe(time.Since(start).Seconds())
+	callsTotal.WithLabelValues(pipeline, callResult(err)).Inc()
+}
+
+// callResult возвращает результат вызова для метрик
+func callResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
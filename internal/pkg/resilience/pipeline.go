@@ -0,0 +1,162 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"pdf-service-go/internal/pkg/bulkhead"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/retry"
+)
+
+// Func вызов зависимости, защищаемый конвейером
+type Func[T any] func(ctx context.Context) (T, error)
+
+// Policy звено конвейера: оборачивает следующий вызов
+type Policy[T any] func(next Func[T]) Func[T]
+
+// Pipeline конвейер устойчивости: политики применяются в объявленном порядке,
+// первая политика — внешняя. Конвейер не хранит состояние вызова и безопасен для параллельного использования.
+type Pipeline[T any] struct {
+	name     string
+	policies []Policy[T]
+	breaker  *circuitbreaker.CircuitBreaker
+	bulkhead *bulkhead.Bulkhead
+}
+
+// New создаёт конвейер из политик; nil политики пропускаются
+func New[T any](name string, policies ...Policy[T]) *Pipeline[T] {
+	p := &Pipeline[T]{name: name}
+	for _, policy := range policies {
+		if policy != nil {
+			p.policies = append(p.policies, policy)
+		}
+	}
+	return p
+}
+
+// Name возвращает имя конвейера
+func (p *Pipeline[T]) Name() string {
+	return p.name
+}
+
+// Breaker возвращает Circuit Breaker конвейера (nil, если звена нет)
+func (p *Pipeline[T]) Breaker() *circuitbreaker.CircuitBreaker {
+	return p.breaker
+}
+
+// Bulkhead возвращает отсек конвейера (nil, если звена нет)
+func (p *Pipeline[T]) Bulkhead() *bulkhead.Bulkhead {
+	return p.bulkhead
+}
+
+// Execute выполняет fn через все политики конвейера
+func (p *Pipeline[T]) Execute(ctx context.Context, fn Func[T]) (T, error) {
+	for i := len(p.policies) - 1; i >= 0; i-- {
+		fn = p.policies[i](fn)
+	}
+	start := time.Now()
+	result, err := fn(ctx)
+	observeCall(p.name, start, err)
+	return result, err
+}
+
+// Do выполняет через конвейер вызов без результата
+func Do(ctx context.Context, p *Pipeline[struct{}], fn func(ctx context.Context) error) error {
+	_, err := p.Execute(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// Timeout ограничивает время вызова; d <= 0 — без ограничения
+func Timeout[T any](d time.Duration) Policy[T] {
+	if d <= 0 {
+		return nil
+	}
+	return func(next Func[T]) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx)
+		}
+	}
+}
+
+// Retry повторяет вызов по правилам retrier; nil — без повторов
+func Retry[T any](r *retry.Retrier) Policy[T] {
+	if r == nil {
+		return nil
+	}
+	return func(next Func[T]) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			var result T
+			err := r.Do(ctx, func(ctx context.Context) error {
+				value, err := next(ctx)
+				if err == nil {
+					result = value
+				}
+				return err
+			})
+			return result, err
+		}
+	}
+}
+
+// Breaker выполняет вызов через Circuit Breaker; nil — без Circuit Breaker
+func Breaker[T any](cb *circuitbreaker.CircuitBreaker) Policy[T] {
+	if cb == nil {
+		return nil
+	}
+	return func(next Func[T]) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			var result T
+			err := cb.Execute(ctx, func() error {
+				value, err := next(ctx)
+				if err == nil {
+					result = value
+				}
+				return err
+			})
+			return result, err
+		}
+	}
+}
+
+// Isolate выполняет вызов в отсеке; отсек без ограничения пропускается
+func Isolate[T any](b *bulkhead.Bulkhead) Policy[T] {
+	if !b.Enabled() {
+		return nil
+	}
+	return func(next Func[T]) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			release, err := b.Acquire(ctx)
+			if err != nil {
+				var zero T
+				return zero, err
+			}
+			defer release()
+			return next(ctx)
+		}
+	}
+}
+
+// FallbackFunc возвращает запасной результат по ошибке вызова
+type FallbackFunc[T any] func(ctx context.Context, err error) (T, error)
+
+// Fallback подменяет ошибку результатом fn; nil — без запасного варианта
+func Fallback[T any](name string, fn FallbackFunc[T]) Policy[T] {
+	if fn == nil {
+		return nil
+	}
+	return func(next Func[T]) Func[T] {
+		return func(ctx context.Context) (T, error) {
+			result, err := next(ctx)
+			if err == nil {
+				return result, nil
+			}
+			fallbacksTotal.WithLabelValues(name).Inc()
+			return fn(ctx, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pdf-service-go/internal/pkg/bulkhead"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/retry"
+)
+
+var errBackend = errors.New("backend failure")
+
+// trace политика, записывающая порядок входа в звенья
+func trace(name string, calls *[]string) Policy[int] {
+	return func(next Func[int]) Func[int] {
+		return func(ctx context.Context) (int, error) {
+			*calls = append(*calls, name)
+			return next(ctx)
+		}
+	}
+}
+
+func TestPipeline_AppliesPoliciesInDeclaredOrder(t *testing.T) {
+	var calls []string
+	p := New("test-order", trace("outer", &calls), nil, trace("inner", &calls))
+
+	result, err := p.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		calls = append(calls, "call")
+		return 42, nil
+	})
+	if err != nil || result != 42 {
+		t.Fatalf("Expected 42, got %d, %v", result, err)
+	}
+	if expected := []string{"outer", "inner", "call"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected order %v, got %v", expected, calls)
+	}
+}
+
+func TestBuild_RetryOutsideBreakerCountsEveryAttempt(t *testing.T) {
+	p := Build[int](Settings{
+		Name:  "test-build",
+		Order: []Stage{StageFallback, StageRetry, StageCircuitBreaker, StageAttemptTimeout},
+		Retry: retry.Config{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1},
+		Breaker: circuitbreaker.Config{
+			FailureThreshold: 3,
+			ResetTimeout:     time.Minute,
+			HalfOpenMaxCalls: 1,
+			SuccessThreshold: 1,
+		},
+		AttemptTimeout: 10 * time.Millisecond,
+	}, func(ctx context.Context, err error) (int, error) {
+		return -1, nil
+	})
+
+	attempts := 0
+	result, err := p.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		attempts++
+		<-ctx.Done() // каждая попытка упирается в таймаут попытки
+		return 0, ctx.Err()
+	})
+	if err != nil || result != -1 {
+		t.Fatalf("Expected fallback result, got %d, %v", result, err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if state := p.Breaker().State(); state != circuitbreaker.StateOpen {
+		t.Errorf("Attempt timeouts must be counted by the breaker, got %v", state)
+	}
+	if p.Breaker().Name() != "test-build" {
+		t.Errorf("Expected breaker to default to pipeline name, got %s", p.Breaker().Name())
+	}
+
+	// Открытый Circuit Breaker отказывает без вызова
+	attempts = 0
+	_, err = New("test-open", Breaker[int](p.Breaker())).Execute(context.Background(), func(ctx context.Context) (int, error) {
+		attempts++
+		return 1, nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) || attempts != 0 {
+		t.Errorf("Expected fast fail with ErrCircuitOpen, got %v after %d calls", err, attempts)
+	}
+}
+
+func TestRetry_OpenBreakerIsNotRetried(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker(circuitbreaker.Config{
+		Name:             "test-open-retry",
+		FailureThreshold: 1,
+		ResetTimeout:     time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	})
+	_ = cb.Execute(context.Background(), func() error { return errBackend })
+	if cb.State() != circuitbreaker.StateOpen {
+		t.Fatalf("Expected breaker to be open, got %v", cb.State())
+	}
+
+	budget := retry.NewBudget("test-open-retry", retry.BudgetConfig{Ratio: 0.2, MinRetriesPerSecond: 2, MaxTokens: 5})
+	tokens := budget.Tokens()
+	p := New("test-open-retry",
+		Retry[int](retry.NewWithConfig("test-open-retry", log(), retry.Config{
+			MaxAttempts:   3,
+			InitialDelay:  time.Second,
+			MaxDelay:      time.Second,
+			BackoffFactor: 1,
+			Budget:        budget,
+		})),
+		Breaker[int](cb),
+	)
+
+	start := time.Now()
+	_, err := p.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		t.Error("Call must not pass an open breaker")
+		return 0, nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempt != 1 {
+		t.Errorf("Expected a single attempt, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected fast failure without backoff, took %v", elapsed)
+	}
+	if budget.Tokens() < tokens {
+		t.Errorf("Open breaker must not spend retry budget: %v -> %v", tokens, budget.Tokens())
+	}
+}
+
+func TestBuild_SkipsDisabledStages(t *testing.T) {
+	p := Build[int](Settings{
+		Name:  "test-disabled",
+		Order: []Stage{StageFallback, StageBulkhead, StageTimeout, StageRetry, StageAttemptTimeout},
+		Retry: retry.Config{MaxAttempts: 1},
+	}, nil)
+	if len(p.policies) != 0 || p.Breaker() != nil || p.Bulkhead() != nil {
+		t.Fatalf("Expected empty pipeline, got %d policies", len(p.policies))
+	}
+
+	calls := 0
+	_, err := p.Execute(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errBackend
+	})
+	if !errors.Is(err, errBackend) || calls != 1 {
+		t.Errorf("Expected single call with original error, got %v after %d calls", err, calls)
+	}
+}
+
+func TestBuild_BulkheadRejects(t *testing.T) {
+	p := Build[int](Settings{
+		Name:     "test-bulkhead",
+		Order:    []Stage{StageBulkhead},
+		Bulkhead: bulkhead.Config{MaxConcurrent: 1, QueueSize: 0},
+	}, nil)
+	release, err := p.Bulkhead().Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer release()
+
+	_, err = p.Execute(context.Background(), func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, bulkhead.ErrRejected) {
+		t.Errorf("Expected bulkhead rejection, got %v", err)
+	}
+}
+
+func TestSettingsFromEnv(t *testing.T) {
+	t.Setenv("TEST_PIPELINE_ORDER", "timeout, retry ,circuit_breaker")
+	t.Setenv("TEST_PIPELINE_TIMEOUT", "2s")
+	t.Setenv("TEST_RETRY_MAX_ATTEMPTS", "4")
+	t.Setenv("TEST_RETRY_BACKOFF_FACTOR", "1.5")
+	t.Setenv("LEGACY_CIRCUIT_BREAKER_FAILURE_THRESHOLD", "7")
+
+	s := SettingsFromEnv(Settings{
+		Name:    "test-env",
+		Retry:   retry.Config{MaxAttempts: 2},
+		Breaker: circuitbreaker.Config{FailureThreshold: 3, ResetTimeout: time.Second},
+	}, EnvPrefixes{Pipeline: "TEST_", Retry: "TEST_", Breaker: "LEGACY_"})
+
+	if expected := []Stage{StageTimeout, StageRetry, StageCircuitBreaker}; !reflect.DeepEqual(s.Order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, s.Order)
+	}
+	if s.Timeout != 2*time.Second || s.Retry.MaxAttempts != 4 || s.Retry.BackoffFactor != 1.5 {
+		t.Errorf("Unexpected settings: %+v", s)
+	}
+	if s.Breaker.FailureThreshold != 7 || s.Breaker.ResetTimeout != time.Second {
+		t.Errorf("Expected breaker threshold from env and reset timeout from defaults, got %+v", s.Breaker)
+	}
+
+	if _, err := ParseOrder("retry,unknown"); err == nil {
+		t.Error("Expected error for unknown stage")
+	}
+	if _, err := ParseOrder("retry,retry"); err == nil {
+		t.Error("Expected error for duplicate stage")
+	}
+}
@@ -0,0 +1,210 @@
+package resilience
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdf-service-go/internal/pkg/bulkhead"
+	"pdf-service-go/internal/pkg/circuitbreaker"
+	"pdf-service-go/internal/pkg/logger"
+	"pdf-service-go/internal/pkg/retry"
+
+	"go.uber.org/zap"
+)
+
+// Stage звено конвейера
+type Stage string
+
+const (
+	StageFallback       Stage = "fallback"        // Запасной вариант при ошибке
+	StageBulkhead       Stage = "bulkhead"        // Ограничение одновременных вызовов
+	StageTimeout        Stage = "timeout"         // Таймаут всего вызова вместе с повторами
+	StageRetry          Stage = "retry"           // Повторные попытки
+	StageCircuitBreaker Stage = "circuit_breaker" // Circuit Breaker, учитывает каждую попытку
+	StageAttemptTimeout Stage = "attempt_timeout" // Таймаут одной попытки
+)
+
+// DefaultOrder порядок звеньев по умолчанию, от внешнего к внутреннему
+var DefaultOrder = []Stage{StageFallback, StageBulkhead, StageTimeout, StageRetry, StageCircuitBreaker, StageAttemptTimeout}
+
+// ParseOrder разбирает порядок звеньев из списка через запятую
+func ParseOrder(value string) ([]Stage, error) {
+	var order []Stage
+	seen := make(map[Stage]bool)
+	for _, part := range strings.Split(value, ",") {
+		stage := Stage(strings.ToLower(strings.TrimSpace(part)))
+		if stage == "" {
+			continue
+		}
+		switch stage {
+		case StageFallback, StageBulkhead, StageTimeout, StageRetry, StageCircuitBreaker, StageAttemptTimeout:
+		default:
+			return nil, fmt.Errorf("unknown resilience stage %q", stage)
+		}
+		if seen[stage] {
+			return nil, fmt.Errorf("duplicate resilience stage %q", stage)
+		}
+		seen[stage] = true
+		order = append(order, stage)
+	}
+	return order, nil
+}
+
+// Settings настройки конвейера одной зависимости — единое место конфигурации всех звеньев
+type Settings struct {
+	Name string
+	// Order звенья конвейера от внешнего к внутреннему; звенья вне списка не используются
+	Order []Stage
+	// Timeout таймаут всего вызова (0 — без таймаута)
+	Timeout time.Duration
+	// AttemptTimeout таймаут одной попытки (0 — без таймаута)
+	AttemptTimeout time.Duration
+	// Retry настройки повторов; MaxAttempts <= 1 — без повторов
+	Retry retry.Config
+	// Breaker настройки Circuit Breaker; имя по умолчанию — Name
+	Breaker circuitbreaker.Config
+	// Bulkhead настройки отсека; MaxConcurrent 0 — без ограничения
+	Bulkhead bulkhead.Config
+}
+
+// EnvPrefixes префиксы переменных окружения звеньев; у исторических переменных они различаются
+type EnvPrefixes struct {
+	// Pipeline префикс <prefix>PIPELINE_ORDER, <prefix>PIPELINE_TIMEOUT и <prefix>PIPELINE_ATTEMPT_TIMEOUT
+	Pipeline string
+	// Retry префикс <prefix>RETRY_*
+	Retry string
+	// Breaker префикс <prefix>CIRCUIT_BREAKER_*
+	Breaker string
+}
+
+// SettingsFromEnv дополняет настройки по умолчанию значениями из переменных окружения
+func SettingsFromEnv(defaults Settings, env EnvPrefixes) Settings {
+	s := defaults
+	if s.Order == nil {
+		s.Order = DefaultOrder
+	}
+	if value := os.Getenv(env.Pipeline + "PIPELINE_ORDER"); value != "" {
+		if order, err := ParseOrder(value); err == nil {
+			s.Order = order
+		} else {
+			log().Warn("Invalid resilience pipeline order, using default",
+				zap.String("pipeline", s.Name),
+				zap.String("order", value),
+				zap.Error(err))
+		}
+	}
+	s.Timeout = getEnvDurationWithDefault(env.Pipeline+"PIPELINE_TIMEOUT", s.Timeout)
+	s.AttemptTimeout = getEnvDurationWithDefault(env.Pipeline+"PIPELINE_ATTEMPT_TIMEOUT", s.AttemptTimeout)
+
+	retryPrefix := env.Retry + "RETRY_"
+	s.Retry.MaxAttempts = getEnvIntWithDefault(retryPrefix+"MAX_ATTEMPTS", s.Retry.MaxAttempts)
+	s.Retry.InitialDelay = getEnvDurationWithDefault(retryPrefix+"INITIAL_DELAY", s.Retry.InitialDelay)
+	s.Retry.MaxDelay = getEnvDurationWithDefault(retryPrefix+"MAX_DELAY", s.Retry.MaxDelay)
+	s.Retry.BackoffFactor = getEnvFloatWithDefault(retryPrefix+"BACKOFF_FACTOR", s.Retry.BackoffFactor)
+	if value := os.Getenv(retryPrefix + "JITTER"); value != "" {
+		s.Retry.Jitter = retry.ParseJitter(value)
+	}
+	if s.Retry.Budget == nil {
+		s.Retry.Budget = retry.SharedBudget(s.Name, retry.BudgetConfigFromEnv(env.Retry))
+	}
+
+	breakerPrefix := env.Breaker + "CIRCUIT_BREAKER_"
+	s.Breaker.FailureThreshold = getEnvIntWithDefault(breakerPrefix+"FAILURE_THRESHOLD", s.Breaker.FailureThreshold)
+	s.Breaker.ResetTimeout = getEnvDurationWithDefault(breakerPrefix+"RESET_TIMEOUT", s.Breaker.ResetTimeout)
+	s.Breaker.HalfOpenMaxCalls = getEnvIntWithDefault(breakerPrefix+"HALF_OPEN_MAX_CALLS", s.Breaker.HalfOpenMaxCalls)
+	s.Breaker.SuccessThreshold = getEnvIntWithDefault(breakerPrefix+"SUCCESS_THRESHOLD", s.Breaker.SuccessThreshold)
+	s.Breaker.Window = circuitbreaker.WindowConfigFromEnv(breakerPrefix)
+	if s.Breaker.PodName == "" {
+		s.Breaker.PodName = os.Getenv("POD_NAME")
+	}
+	if s.Breaker.Namespace == "" {
+		s.Breaker.Namespace = os.Getenv("POD_NAMESPACE")
+	}
+
+	// Отсек читает BULKHEAD_<NAME>_*; дефисы в имени недопустимы в переменных окружения
+	if s.Bulkhead.Name == "" {
+		s.Bulkhead.Name = strings.ReplaceAll(s.Name, "-", "_")
+	}
+	s.Bulkhead = bulkhead.ConfigFromEnv(s.Bulkhead.Name, s.Bulkhead)
+	return s
+}
+
+// Build собирает конвейер из настроек в объявленном порядке.
+// Звенья с нулевыми настройками (без повторов, таймаутов и ограничений) не добавляются.
+func Build[T any](s Settings, fallback FallbackFunc[T]) *Pipeline[T] {
+	p := &Pipeline[T]{name: s.Name}
+	for _, stage := range s.Order {
+		var policy Policy[T]
+		switch stage {
+		case StageFallback:
+			policy = Fallback(s.Name, fallback)
+		case StageBulkhead:
+			if s.Bulkhead.Name == "" {
+				s.Bulkhead.Name = strings.ReplaceAll(s.Name, "-", "_")
+			}
+			if s.Bulkhead.MaxConcurrent > 0 {
+				p.bulkhead = bulkhead.New(s.Bulkhead)
+				policy = Isolate[T](p.bulkhead)
+			}
+		case StageTimeout:
+			policy = Timeout[T](s.Timeout)
+		case StageRetry:
+			if s.Retry.MaxAttempts > 1 {
+				policy = Retry[T](retry.NewWithConfig(s.Name, log(), s.Retry))
+			}
+		case StageCircuitBreaker:
+			if s.Breaker.Name == "" {
+				s.Breaker.Name = s.Name
+			}
+			p.breaker = circuitbreaker.NewCircuitBreaker(s.Breaker)
+			policy = Breaker[T](p.breaker)
+		case StageAttemptTimeout:
+			policy = Timeout[T](s.AttemptTimeout)
+		}
+		if policy != nil {
+			p.policies = append(p.policies, policy)
+		}
+	}
+	return p
+}
+
+// log возвращает логгер сервиса или пустой логгер, если он не инициализирован (тесты)
+func log() *zap.Logger {
+	if logger.Log == nil {
+		return zap.NewNop()
+	}
+	return logger.Log
+}
+
+// getEnvIntWithDefault возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getEnvIntWithDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloatWithDefault возвращает дробное значение переменной окружения или значение по умолчанию
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"pdf-service-go/internal/pkg/metrics"
+)
+
+// BudgetConfig настройки бюджета повторов
+type BudgetConfig struct {
+	// Ratio доля повторов от успешных вызовов (0.2 — один повтор на пять успешных вызовов; 0 — бюджет выключен)
+	Ratio float64
+	// MinRetriesPerSecond запас повторов в секунду, доступный даже без успешных вызовов
+	MinRetriesPerSecond float64
+	// MaxTokens максимум накопленных повторов
+	MaxTokens float64
+}
+
+// BudgetConfigFromEnv читает настройки бюджета из <prefix>RETRY_BUDGET_RATIO, _MIN_PER_SECOND и _MAX_TOKENS
+func BudgetConfigFromEnv(prefix string) BudgetConfig {
+	return BudgetConfig{
+		Ratio:               getEnvFloatWithDefault(prefix+"RETRY_BUDGET_RATIO", 0),
+		MinRetriesPerSecond: getEnvFloatWithDefault(prefix+"RETRY_BUDGET_MIN_PER_SECOND", 1),
+		MaxTokens:           getEnvFloatWithDefault(prefix+"RETRY_BUDGET_MAX_TOKENS", 10),
+	}
+}
+
+// Budget ограничивает повторы операции долей недавних успешных вызовов (token bucket).
+// Каждый успешный вызов добавляет Ratio токенов, каждый повтор забирает один токен,
+// а запас MinRetriesPerSecond пополняется со временем. Во время сбоя успешных вызовов нет,
+// поэтому повторы быстро исчерпывают бюджет и не умножают нагрузку на зависимость.
+type Budget struct {
+	operation string
+	cfg       BudgetConfig
+	now       func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBudget создаёт бюджет; начальный запас — MinRetriesPerSecond токенов
+func NewBudget(operation string, cfg BudgetConfig) *Budget {
+	if cfg.MaxTokens < 1 {
+		cfg.MaxTokens = 1
+	}
+	b := &Budget{operation: operation, cfg: cfg, now: time.Now}
+	b.tokens = min(cfg.MinRetriesPerSecond, cfg.MaxTokens)
+	b.last = b.now()
+	metrics.RetryBudgetTokens.WithLabelValues(operation).Set(b.tokens)
+	return b
+}
+
+var (
+	budgetsMu sync.Mutex
+	budgets   = make(map[string]*Budget)
+)
+
+// SharedBudget возвращает общий для операции бюджет, создавая его при первом обращении.
+// При Ratio <= 0 бюджет выключен и возвращается nil.
+func SharedBudget(operation string, cfg BudgetConfig) *Budget {
+	if cfg.Ratio <= 0 {
+		return nil
+	}
+	budgetsMu.Lock()
+	defer budgetsMu.Unlock()
+	if b, ok := budgets[operation]; ok {
+		return b
+	}
+	b := NewBudget(operation, cfg)
+	budgets[operation] = b
+	return b
+}
+
+// Deposit учитывает успешный вызов
+func (b *Budget) Deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	b.tokens = min(b.tokens+b.cfg.Ratio, b.cfg.MaxTokens)
+	metrics.RetryBudgetTokens.WithLabelValues(b.operation).Set(b.tokens)
+}
+
+// TryWithdraw забирает токен на повтор; false — бюджет исчерпан
+func (b *Budget) TryWithdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	if b.tokens < 1 {
+		metrics.RetryBudgetExhausted.WithLabelValues(b.operation).Inc()
+		return false
+	}
+	b.tokens--
+	metrics.RetryBudgetTokens.WithLabelValues(b.operation).Set(b.tokens)
+	return true
+}
+
+// Tokens возвращает доступные токены
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	return b.tokens
+}
+
+// refillLocked пополняет запас MinRetriesPerSecond за прошедшее время
+func (b *Budget) refillLocked() {
+	now := b.now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed > 0 && b.cfg.MinRetriesPerSecond > 0 {
+		b.tokens = min(b.tokens+elapsed*b.cfg.MinRetriesPerSecond, b.cfg.MaxTokens)
+	}
+}
+
+// getEnvFloatWithDefault возвращает дробное значение переменной окружения или значение по умолчанию
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
@@ -1,6 +1,9 @@
 package retry
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // RetryConfig содержит настройки для retry механизма
 type RetryConfig struct {
@@ -21,7 +24,11 @@ const (
 	ErrorTypeConnection ErrorType = "connection_error"
 	ErrorTypeTimeout    ErrorType = "timeout"
 	ErrorTypeValidation ErrorType = "validation"
-	ErrorTypeUnknown    ErrorType = "unknown"
+	// ErrorTypeRateLimited зависимость перегружена (429, 503) и может указать Retry-After
+	ErrorTypeRateLimited ErrorType = "rate_limited"
+	// ErrorTypeServer ответ зависимости 5xx
+	ErrorTypeServer  ErrorType = "server_error"
+	ErrorTypeUnknown ErrorType = "unknown"
 )
 
 // RetryConfigs содержит настройки retry для разных типов ошибок
@@ -44,6 +51,18 @@ var RetryConfigs = map[ErrorType]RetryConfig{
 		MaxDelay:      0,
 		BackoffFactor: 1,
 	},
+	ErrorTypeRateLimited: {
+		MaxAttempts:   3,
+		InitialDelay:  100 * time.Millisecond,
+		MaxDelay:      time.Second,
+		BackoffFactor: 2,
+	},
+	ErrorTypeServer: {
+		MaxAttempts:   2,
+		InitialDelay:  50 * time.Millisecond,
+		MaxDelay:      500 * time.Millisecond,
+		BackoffFactor: 2,
+	},
 	ErrorTypeUnknown: {
 		MaxAttempts:   2,
 		InitialDelay:  20 * time.Millisecond,
@@ -72,6 +91,57 @@ type Config struct {
 	BackoffFactor float64
 	// RetryableErrors список ошибок, для которых нужно выполнять retry
 	RetryableErrors []error
+	// Jitter способ рандомизации задержки, чтобы клиенты не повторяли запросы синхронно
+	Jitter Jitter
+	// Budget общий для операции бюджет повторов (nil — без ограничения)
+	Budget *Budget
+	// MaxRetryAfter максимальная задержка из Retry-After, которую retrier готов ждать; при большей повтор не выполняется
+	MaxRetryAfter time.Duration
+	// Policies политики повторов по типу ошибки; политика выбирается по ошибке каждой попытки,
+	// для типов без политики используются MaxAttempts, InitialDelay, MaxDelay и BackoffFactor
+	Policies map[ErrorType]RetryConfig
+}
+
+// policyFor возвращает политику для типа ошибки
+func (c *Config) policyFor(errorType ErrorType) RetryConfig {
+	if policy, ok := c.Policies[errorType]; ok {
+		return policy
+	}
+	return c.basePolicy()
+}
+
+// basePolicy возвращает политику по умолчанию из основных настроек
+func (c *Config) basePolicy() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:   c.MaxAttempts,
+		InitialDelay:  c.InitialDelay,
+		MaxDelay:      c.MaxDelay,
+		BackoffFactor: c.BackoffFactor,
+	}
+}
+
+// Jitter способ рандомизации задержки между попытками
+type Jitter string
+
+const (
+	// JitterNone задержка строго экспоненциальная (по умолчанию)
+	JitterNone Jitter = "none"
+	// JitterFull случайная задержка от 0 до экспоненциальной
+	JitterFull Jitter = "full"
+	// JitterEqual половина экспоненциальной задержки плюс случайная добавка до второй половины
+	JitterEqual Jitter = "equal"
+	// JitterDecorrelated случайная задержка от InitialDelay до утроенной предыдущей
+	JitterDecorrelated Jitter = "decorrelated"
+)
+
+// ParseJitter разбирает название стратегии; неизвестные значения дают JitterNone
+func ParseJitter(value string) Jitter {
+	switch j := Jitter(strings.ToLower(strings.TrimSpace(value))); j {
+	case JitterFull, JitterEqual, JitterDecorrelated:
+		return j
+	default:
+		return JitterNone
+	}
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
@@ -81,6 +151,7 @@ func DefaultConfig() *Config {
 		InitialDelay:  50 * time.Millisecond,
 		MaxDelay:      200 * time.Millisecond,
 		BackoffFactor: 1.5,
+		MaxRetryAfter: 10 * time.Second,
 	}
 }
 
@@ -115,6 +186,38 @@ func WithBackoffFactor(factor float64) Option {
 	}
 }
 
+// WithJitter устанавливает стратегию рандомизации задержки
+func WithJitter(jitter Jitter) Option {
+	return func(c *Config) {
+		c.Jitter = jitter
+	}
+}
+
+// WithMaxRetryAfter устанавливает максимальную задержку из Retry-After
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *Config) {
+		c.MaxRetryAfter = d
+	}
+}
+
+// WithPolicies устанавливает политики повторов по типу ошибки.
+// Карта копируется, поэтому её последующие изменения не влияют на retrier.
+func WithPolicies(policies map[ErrorType]RetryConfig) Option {
+	return func(c *Config) {
+		c.Policies = make(map[ErrorType]RetryConfig, len(policies))
+		for errorType, policy := range policies {
+			c.Policies[errorType] = policy
+		}
+	}
+}
+
+// WithBudget ограничивает повторы общим бюджетом операции
+func WithBudget(budget *Budget) Option {
+	return func(c *Config) {
+		c.Budget = budget
+	}
+}
+
 // WithRetryableErrors устанавливает список ошибок для retry
 func WithRetryableErrors(errors []error) Option {
 	return func(c *Config) {
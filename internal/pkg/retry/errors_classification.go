@@ -1,10 +1,13 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"net"
+	"net/http"
 	"os"
 	"syscall"
+	"time"
 )
 
 // TimeoutError интерфейс для ошибок таймаута
@@ -22,6 +25,65 @@ type ValidationError interface {
 	Validation() bool
 }
 
+// HTTPStatusError интерфейс для ошибок с HTTP статусом ответа зависимости
+type HTTPStatusError interface {
+	HTTPStatus() int
+}
+
+// RetryAfterError интерфейс для ошибок с рекомендуемой задержкой повтора (заголовок Retry-After)
+type RetryAfterError interface {
+	RetryAfterDelay() time.Duration
+}
+
+// HTTPStatus возвращает HTTP статус из цепочки ошибок; 0 — статуса нет
+func HTTPStatus(err error) int {
+	var statusErr HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatus()
+	}
+	return 0
+}
+
+// RetryAfter возвращает задержку, запрошенную зависимостью в Retry-After; 0 — не запрошена
+func RetryAfter(err error) time.Duration {
+	var retryAfterErr RetryAfterError
+	if errors.As(err, &retryAfterErr) {
+		return retryAfterErr.RetryAfterDelay()
+	}
+	return 0
+}
+
+// Classify определяет тип ошибки для выбора стратегии retry
+func Classify(err error) ErrorType {
+	switch {
+	case err == nil:
+		return ErrorTypeUnknown
+	case IsRateLimited(err):
+		return ErrorTypeRateLimited
+	case IsValidationError(err):
+		return ErrorTypeValidation
+	case IsTimeout(err):
+		return ErrorTypeTimeout
+	case IsConnectionError(err):
+		return ErrorTypeConnection
+	case IsServerError(err):
+		return ErrorTypeServer
+	default:
+		return ErrorTypeUnknown
+	}
+}
+
+// IsRateLimited проверяет, что зависимость перегружена и просит повторить позже (429 или 503)
+func IsRateLimited(err error) bool {
+	status := HTTPStatus(err)
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// IsServerError проверяет, что зависимость ответила 5xx
+func IsServerError(err error) bool {
+	return HTTPStatus(err) >= http.StatusInternalServerError
+}
+
 // IsTimeout проверяет, является ли ошибка таймаутом
 func IsTimeout(err error) bool {
 	var timeoutErr TimeoutError
@@ -29,6 +91,15 @@ func IsTimeout(err error) bool {
 		return timeoutErr.Timeout()
 	}
 
+	// Проверяем HTTP статусы таймаута
+	if status := HTTPStatus(err); status != 0 {
+		return status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
 	// Проверяем стандартные ошибки таймаута
 	if os.IsTimeout(err) {
 		return true
@@ -56,10 +127,9 @@ func IsConnectionError(err error) bool {
 		return connErr.Connection()
 	}
 
-	// Проверяем сетевые ошибки
-	var netErr net.Error
-	if errors.As(err, &netErr) {
-		return true
+	// Ответ получен — соединение в порядке
+	if HTTPStatus(err) != 0 {
+		return false
 	}
 
 	// Проверяем системные ошибки соединения
@@ -70,11 +140,23 @@ func IsConnectionError(err error) bool {
 			syscall.ECONNRESET,
 			syscall.ECONNABORTED,
 			syscall.ENETUNREACH,
-			syscall.ENETDOWN:
+			syscall.ENETDOWN,
+			syscall.EHOSTUNREACH,
+			syscall.EPIPE:
 			return true
 		}
 	}
 
+	// Не удалось разрешить имя или установить соединение (*url.Error оборачивает *net.OpError)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return !opErr.Timeout()
+	}
+
 	return false
 }
 
@@ -85,8 +167,10 @@ func IsValidationError(err error) bool {
 		return validErr.Validation()
 	}
 
-	// Здесь можно добавить дополнительные проверки для конкретных типов ошибок валидации
-	return false
+	// Ответы 4xx, кроме таймаута и перегрузки, означают некорректный запрос
+	status := HTTPStatus(err)
+	return status >= http.StatusBadRequest && status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
 }
 
 // IsTransientError проверяет, является ли ошибка временной
@@ -105,8 +189,8 @@ func ShouldRetry(err error) bool {
 		return false
 	}
 
-	// Повторяем для временных ошибок
-	if IsTransientError(err) {
+	// Повторяем для временных ошибок и ответов 5xx, 408 и 429
+	if IsTransientError(err) || IsRateLimited(err) || IsServerError(err) {
 		return true
 	}
 
@@ -2,9 +2,12 @@ package retry
 
 import (
 	"context"
+	"errors"
+	"math/rand"
 	"strconv"
 	"time"
 
+	"pdf-service-go/internal/pkg/circuitbreaker"
 	"pdf-service-go/internal/pkg/metrics"
 
 	"go.uber.org/zap"
@@ -13,7 +16,8 @@ import (
 // Operation представляет операцию, которую нужно повторить
 type Operation func(ctx context.Context) error
 
-// Retrier выполняет повторные попытки операции
+// Retrier выполняет повторные попытки операции.
+// Конфигурация не меняется после создания, поэтому один Retrier безопасно использовать из нескольких горутин.
 type Retrier struct {
 	config    *Config
 	logger    *zap.Logger
@@ -34,6 +38,22 @@ func New(operation string, logger *zap.Logger, opts ...Option) *Retrier {
 	}
 }
 
+// NewWithConfig создает Retrier из готовой конфигурации; карта политик копируется,
+// нулевой MaxRetryAfter заменяется значением по умолчанию
+func NewWithConfig(operation string, logger *zap.Logger, config Config) *Retrier {
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = DefaultConfig().MaxRetryAfter
+	}
+	if config.Policies != nil {
+		WithPolicies(config.Policies)(&config)
+	}
+	return &Retrier{
+		config:    &config,
+		logger:    logger,
+		operation: operation,
+	}
+}
+
 // Do выполняет операцию с повторными попытками
 func (r *Retrier) Do(ctx context.Context, op Operation) error {
 	start := time.Now()
@@ -47,8 +67,14 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 
 	successfulAttempts := 0
 	totalAttempts := 0
+	var prevDelay time.Duration
+
+	if r.config.MaxAttempts < 1 {
+		return ErrMaxAttemptsReached
+	}
 
-	for attempt := 1; attempt <= r.config.MaxAttempts; attempt++ {
+	attempt := 1
+	for ; ; attempt++ {
 		attemptStr := strconv.Itoa(attempt)
 		attemptStart := time.Now()
 		totalAttempts++
@@ -88,6 +114,9 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 			// Записываем распределение попыток
 			metrics.RetryAttemptsDistribution.WithLabelValues(r.operation).Observe(float64(attempt))
 
+			// Успешный вызов пополняет бюджет повторов
+			r.config.Budget.Deposit()
+
 			return nil
 		}
 
@@ -119,12 +148,24 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 
 		// Если контекст отменен, прекращаем попытки
 		if ctx.Err() != nil {
-			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "cancelled").Inc()
-			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, "cancelled").Observe(time.Since(start).Seconds())
+			status := contextStatus(ctx)
+			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, status).Inc()
+			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, status).Observe(time.Since(start).Seconds())
 			metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
 			return ctx.Err()
 		}
 
+		// Circuit Breaker открыт: повтор до ResetTimeout тоже будет отклонён, ждать и тратить бюджет незачем
+		if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "circuit_open").Inc()
+			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, "circuit_open").Observe(time.Since(start).Seconds())
+			metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
+			return &RetryError{
+				Attempt:       attempt,
+				OriginalError: err,
+			}
+		}
+
 		// Если ошибка не подлежит retry, прекращаем попытки
 		if !IsRetryable(err, r.config.RetryableErrors) {
 			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "non_retryable").Inc()
@@ -136,13 +177,54 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 			}
 		}
 
-		// Если это последняя попытка, не нужно ждать
-		if attempt == r.config.MaxAttempts {
+		// Политика выбирается по ошибке этой попытки и не влияет на другие вызовы
+		policy := r.config.policyFor(Classify(err))
+
+		// Если это последняя попытка по политике, не нужно ждать
+		if attempt >= policy.MaxAttempts {
 			break
 		}
 
 		// Вычисляем задержку для следующей попытки
-		delay := r.calculateDelay(attempt)
+		delay := r.nextDelay(policy, attempt, prevDelay)
+		prevDelay = delay
+
+		// Зависимость сама указала, когда повторять (Retry-After в ответе 429/503)
+		if retryAfter := RetryAfter(err); retryAfter > 0 {
+			if retryAfter > r.config.MaxRetryAfter || !fitsDeadline(ctx, retryAfter) {
+				r.logger.Warn("retry-after exceeds the allowed wait, giving up",
+					zap.Int("attempt", attempt),
+					zap.Duration("retry_after", retryAfter),
+					zap.Error(err),
+				)
+				metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "retry_after_too_long").Inc()
+				metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, "retry_after_too_long").Observe(time.Since(start).Seconds())
+				metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
+				return &RetryError{
+					Attempt:       attempt,
+					OriginalError: err,
+				}
+			}
+			if retryAfter > delay {
+				delay = retryAfter
+			}
+		}
+
+		// Повтор возможен только при наличии токена в бюджете операции; токен списывается,
+		// только когда остальные проверки пройдены и повтор точно будет выполнен
+		if !r.config.Budget.TryWithdraw() {
+			r.logger.Warn("retry budget exhausted, giving up",
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+			)
+			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "budget_exhausted").Inc()
+			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, "budget_exhausted").Observe(time.Since(start).Seconds())
+			metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
+			return &RetryError{
+				Attempt:       attempt,
+				OriginalError: err,
+			}
+		}
 
 		// Записываем длительность задержки
 		metrics.RetryBackoffDuration.WithLabelValues(
@@ -151,13 +233,16 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 		).Observe(delay.Seconds())
 
 		// Ждем с учетом контекста
+		timer := time.NewTimer(delay)
 		select {
 		case <-ctx.Done():
-			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, "cancelled").Inc()
-			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, "cancelled").Observe(time.Since(start).Seconds())
+			timer.Stop()
+			status := contextStatus(ctx)
+			metrics.RetryAttemptsTotal.WithLabelValues(r.operation, attemptStr, status).Inc()
+			metrics.RetryOperationDuration.WithLabelValues(r.operation, attemptStr, status).Observe(time.Since(start).Seconds())
 			metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
 			return ctx.Err()
-		case <-time.After(delay):
+		case <-timer.C:
 		}
 	}
 
@@ -169,23 +254,19 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 	metrics.RetryTotalDuration.WithLabelValues(r.operation, "false").Observe(time.Since(start).Seconds())
 
 	// Записываем распределение попыток
-	metrics.RetryAttemptsDistribution.WithLabelValues(r.operation).Observe(float64(r.config.MaxAttempts))
+	metrics.RetryAttemptsDistribution.WithLabelValues(r.operation).Observe(float64(attempt))
 
-	if lastErr != nil {
-		metrics.RetryAttemptsTotal.WithLabelValues(r.operation, strconv.Itoa(r.config.MaxAttempts), "max_attempts").Inc()
-		return &RetryError{
-			Attempt:       r.config.MaxAttempts,
-			OriginalError: lastErr,
-		}
+	metrics.RetryAttemptsTotal.WithLabelValues(r.operation, strconv.Itoa(attempt), "max_attempts").Inc()
+	return &RetryError{
+		Attempt:       attempt,
+		OriginalError: lastErr,
 	}
-
-	return ErrMaxAttemptsReached
 }
 
 // determineRetryReason определяет причину retry
 func determineRetryReason(err error, ctx context.Context) string {
 	if ctx.Err() != nil {
-		return "context_cancelled"
+		return contextReason(ctx)
 	}
 	if IsTimeout(err) {
 		return "timeout"
@@ -196,34 +277,88 @@ func determineRetryReason(err error, ctx context.Context) string {
 	if IsValidationError(err) {
 		return "validation_error"
 	}
+	if IsRateLimited(err) {
+		return "rate_limited"
+	}
+	if IsServerError(err) {
+		return "server_error"
+	}
 	return "unknown"
 }
 
-// calculateDelay вычисляет задержку для следующей попытки
-func (r *Retrier) calculateDelay(attempt int) time.Duration {
-	delay := float64(r.config.InitialDelay)
+// fitsDeadline проверяет, что после ожидания d у вызывающего ещё останется время на попытку
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// backoff вычисляет экспоненциальную задержку попытки по политике
+func backoff(policy RetryConfig, attempt int) time.Duration {
+	delay := float64(policy.InitialDelay)
 	for i := 1; i < attempt; i++ {
-		delay *= r.config.BackoffFactor
+		delay *= policy.BackoffFactor
 	}
 
-	if delay > float64(r.config.MaxDelay) {
-		delay = float64(r.config.MaxDelay)
+	if delay > float64(policy.MaxDelay) {
+		delay = float64(policy.MaxDelay)
 	}
 
 	return time.Duration(delay)
 }
 
+// nextDelay вычисляет задержку по политике с учётом стратегии jitter; prev — предыдущая задержка
+func (r *Retrier) nextDelay(policy RetryConfig, attempt int, prev time.Duration) time.Duration {
+	base := backoff(policy, attempt)
+	switch r.config.Jitter {
+	case JitterFull:
+		return time.Duration(rand.Int63n(int64(base) + 1))
+	case JitterEqual:
+		half := base / 2
+		return half + time.Duration(rand.Int63n(int64(base-half)+1))
+	case JitterDecorrelated:
+		if prev < policy.InitialDelay {
+			prev = policy.InitialDelay
+		}
+		upper := 3 * prev
+		if upper > policy.MaxDelay {
+			upper = policy.MaxDelay
+		}
+		if upper <= policy.InitialDelay {
+			return upper
+		}
+		return policy.InitialDelay + time.Duration(rand.Int63n(int64(upper-policy.InitialDelay)+1))
+	default:
+		return base
+	}
+}
+
 // errorToStatus преобразует ошибку в статус для метрик
 func errorToStatus(err error, ctx context.Context) string {
 	if err == nil {
 		return "success"
 	}
 	if ctx.Err() != nil {
-		return "cancelled"
+		return contextStatus(ctx)
 	}
 	return "error"
 }
 
+// contextStatus различает отмену вызывающим и истечение его дедлайна
+func contextStatus(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "deadline_exceeded"
+	}
+	return "cancelled"
+}
+
+// contextReason возвращает причину остановки retry по контексту
+func contextReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "context_deadline"
+	}
+	return "context_cancelled"
+}
+
 // classifyError классифицирует ошибку для метрик
 func classifyError(err error, ctx context.Context) string {
 	if err == nil {
@@ -232,22 +367,18 @@ func classifyError(err error, ctx context.Context) string {
 
 	switch {
 	case ctx.Err() != nil:
-		return "context_cancelled"
+		return contextReason(ctx)
 	case IsTimeout(err):
 		return "timeout"
 	case IsConnectionError(err):
 		return "connection"
 	case IsValidationError(err):
 		return "validation"
+	case IsRateLimited(err):
+		return "rate_limited"
+	case IsServerError(err):
+		return "server_error"
 	default:
 		return "unknown"
 	}
 }
-
-// UpdateConfig обновляет конфигурацию retry
-func (r *Retrier) UpdateConfig(config RetryConfig) {
-	r.config.MaxAttempts = config.MaxAttempts
-	r.config.InitialDelay = config.InitialDelay
-	r.config.MaxDelay = config.MaxDelay
-	r.config.BackoffFactor = config.BackoffFactor
-}
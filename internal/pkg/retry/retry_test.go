@@ -3,6 +3,11 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -174,10 +179,12 @@ func TestRetrier_Delay(t *testing.T) {
 				WithInitialDelay(tt.initialDelay),
 				WithMaxDelay(tt.maxDelay),
 				WithBackoffFactor(tt.backoffFactor),
+				WithJitter(JitterNone),
 			)
 
-			delay := r.calculateDelay(tt.attempt)
-			assert.Equal(t, tt.expected, delay)
+			policy := r.config.basePolicy()
+			assert.Equal(t, tt.expected, backoff(policy, tt.attempt))
+			assert.Equal(t, tt.expected, r.nextDelay(policy, tt.attempt, 0))
 		})
 	}
 }
@@ -222,3 +229,283 @@ func TestIsRetryable(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrier_StopsBackoffOnDeadline(t *testing.T) {
+	r := New("test", logger,
+		WithMaxAttempts(5),
+		WithInitialDelay(time.Second),
+		WithMaxDelay(time.Second),
+		WithRetryableErrors([]error{errTest}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := r.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return errTest
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, calls, "backoff must be interrupted by the deadline")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "deadline_exceeded", contextStatus(ctx))
+}
+
+func TestRetrier_JitterBounds(t *testing.T) {
+	initial := 100 * time.Millisecond
+	maxDelay := time.Second
+	tests := []struct {
+		jitter   Jitter
+		attempt  int
+		prev     time.Duration
+		min, max time.Duration
+	}{
+		{jitter: JitterNone, attempt: 2, min: 200 * time.Millisecond, max: 200 * time.Millisecond},
+		{jitter: JitterFull, attempt: 2, min: 0, max: 200 * time.Millisecond},
+		{jitter: JitterEqual, attempt: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{jitter: JitterDecorrelated, attempt: 2, prev: 200 * time.Millisecond, min: initial, max: 600 * time.Millisecond},
+		{jitter: JitterDecorrelated, attempt: 5, prev: 900 * time.Millisecond, min: initial, max: maxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.jitter), func(t *testing.T) {
+			r := New("test", logger,
+				WithInitialDelay(initial),
+				WithMaxDelay(maxDelay),
+				WithBackoffFactor(2),
+				WithJitter(tt.jitter),
+			)
+			for i := 0; i < 1000; i++ {
+				delay := r.nextDelay(r.config.basePolicy(), tt.attempt, tt.prev)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, tt.max)
+			}
+		})
+	}
+
+	assert.Equal(t, JitterFull, ParseJitter(" Full "))
+	assert.Equal(t, JitterNone, ParseJitter("unknown"))
+}
+
+func TestRetrier_BudgetLimitsRetries(t *testing.T) {
+	now := time.Unix(0, 0)
+	budget := NewBudget("test-budget", BudgetConfig{Ratio: 0.5, MinRetriesPerSecond: 1, MaxTokens: 2})
+	budget.now = func() time.Time { return now }
+	budget.last = now
+
+	r := New("test-budget", logger,
+		WithMaxAttempts(3),
+		WithInitialDelay(time.Millisecond),
+		WithMaxDelay(time.Millisecond),
+		WithBudget(budget),
+	)
+
+	failing := func(calls *int) Operation {
+		return func(ctx context.Context) error {
+			*calls++
+			return errTest
+		}
+	}
+
+	// Начального запаса хватает на один повтор
+	calls := 0
+	err := r.Do(context.Background(), failing(&calls))
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls, "only one retry fits into the initial reserve")
+
+	// Бюджет исчерпан — повторов нет
+	calls = 0
+	err = r.Do(context.Background(), failing(&calls))
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 1, calls, "exhausted budget must stop retries")
+
+	// Два успешных вызова возвращают один токен
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, r.Do(context.Background(), func(ctx context.Context) error { return nil }))
+	}
+	calls = 0
+	_ = r.Do(context.Background(), failing(&calls))
+	assert.Equal(t, 2, calls, "successful calls must refill the budget")
+
+	// Запас пополняется со временем, но не выше MaxTokens
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2.0, budget.Tokens())
+
+	assert.Nil(t, SharedBudget("test-disabled", BudgetConfig{Ratio: 0}))
+	shared := SharedBudget("test-shared", BudgetConfig{Ratio: 0.1, MaxTokens: 1})
+	assert.Same(t, shared, SharedBudget("test-shared", BudgetConfig{Ratio: 0.1, MaxTokens: 1}))
+}
+
+// statusError ответ зависимости с HTTP статусом и Retry-After
+type statusError struct {
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string                  { return "status error" }
+func (e *statusError) HTTPStatus() int                { return e.status }
+func (e *statusError) RetryAfterDelay() time.Duration { return e.retryAfter }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorType
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, expected: ErrorTypeConnection},
+		{name: "dns", err: &url.Error{Op: "Post", URL: "http://x", Err: &net.DNSError{Err: "no such host", Name: "x"}}, expected: ErrorTypeConnection},
+		{name: "net timeout", err: &url.Error{Op: "Post", URL: "http://x", Err: &net.DNSError{IsTimeout: true}}, expected: ErrorTypeTimeout},
+		{name: "deadline", err: fmt.Errorf("wrapped: %w", context.DeadlineExceeded), expected: ErrorTypeTimeout},
+		{name: "bad request", err: &statusError{status: 400}, expected: ErrorTypeValidation},
+		{name: "gateway timeout", err: &statusError{status: 504}, expected: ErrorTypeTimeout},
+		{name: "too many requests", err: &statusError{status: 429}, expected: ErrorTypeRateLimited},
+		{name: "unavailable", err: &statusError{status: 503}, expected: ErrorTypeRateLimited},
+		{name: "internal", err: &statusError{status: 500}, expected: ErrorTypeServer},
+		// Текст ошибки больше не влияет на классификацию
+		{name: "message mentions 400 and timeout", err: errors.New("document has 400 pages, timeout field invalid"), expected: ErrorTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Classify(tt.err))
+		})
+	}
+	assert.False(t, ShouldRetry(&statusError{status: 422}))
+	assert.True(t, ShouldRetry(&statusError{status: 502}))
+}
+
+func TestRetrier_HonoursRetryAfter(t *testing.T) {
+	r := New("test", logger,
+		WithMaxAttempts(2),
+		WithInitialDelay(time.Millisecond),
+		WithMaxDelay(time.Millisecond),
+		WithMaxRetryAfter(time.Second),
+	)
+
+	calls := 0
+	start := time.Now()
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return &statusError{status: 503, retryAfter: 100 * time.Millisecond}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "retrier must wait for Retry-After")
+
+	// Слишком долгий Retry-After — повтор бессмысленен
+	calls = 0
+	err = r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return &statusError{status: 429, retryAfter: time.Minute}
+	})
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 1, calls)
+
+	// Retry-After не укладывается в дедлайн вызывающего
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	calls = 0
+	_ = r.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return &statusError{status: 503, retryAfter: 500 * time.Millisecond}
+	})
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetrier_PerCallPoliciesAreConcurrencySafe(t *testing.T) {
+	policies := map[ErrorType]RetryConfig{
+		ErrorTypeValidation:  {MaxAttempts: 1},
+		ErrorTypeServer:      {MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1},
+		ErrorTypeRateLimited: {MaxAttempts: 4, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1},
+	}
+	r := New("test-policies", logger,
+		WithMaxAttempts(2),
+		WithInitialDelay(time.Millisecond),
+		WithMaxDelay(time.Millisecond),
+		WithPolicies(policies),
+	)
+	// Изменение исходной карты не должно влиять на retrier
+	policies[ErrorTypeValidation] = RetryConfig{MaxAttempts: 10}
+
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{err: &statusError{status: 400}, expected: 1},
+		{err: &statusError{status: 500}, expected: 3},
+		{err: &statusError{status: 429}, expected: 4},
+		{err: errTest, expected: 2}, // тип без политики — основные настройки
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, tt := range tests {
+			wg.Add(1)
+			go func(err error, expected int) {
+				defer wg.Done()
+				calls := 0
+				_ = r.Do(context.Background(), func(ctx context.Context) error {
+					calls++
+					return err
+				})
+				if calls != expected {
+					t.Errorf("%v: expected %d attempts, got %d", err, expected, calls)
+				}
+			}(tt.err, tt.expected)
+		}
+	}
+	wg.Wait()
+
+	// Политика выбирается по ошибке каждой попытки: после 400 на второй попытке повторы прекращаются
+	calls := 0
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return &statusError{status: 500}
+		}
+		return &statusError{status: 400}
+	})
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, retryErr.Attempt)
+}
+
+func TestRetrier_RetryAfterTooLongKeepsBudget(t *testing.T) {
+	now := time.Unix(0, 0)
+	budget := NewBudget("test-retry-after-budget", BudgetConfig{Ratio: 0.5, MinRetriesPerSecond: 1, MaxTokens: 2})
+	budget.now = func() time.Time { return now }
+	budget.last = now
+	tokens := budget.Tokens()
+
+	r := New("test-retry-after-budget", logger,
+		WithMaxAttempts(2),
+		WithInitialDelay(time.Millisecond),
+		WithMaxDelay(time.Millisecond),
+		WithMaxRetryAfter(time.Second),
+		WithBudget(budget),
+	)
+
+	// Повтор отклонён из-за Retry-After — токен бюджета не списывается
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		return &statusError{status: 429, retryAfter: time.Minute}
+	})
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, tokens, budget.Tokens())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = r.Do(ctx, func(ctx context.Context) error {
+		return &statusError{status: 503, retryAfter: 500 * time.Millisecond}
+	})
+	assert.Equal(t, tokens, budget.Tokens())
+}
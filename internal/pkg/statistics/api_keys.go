@@ -0,0 +1,143 @@
+package statistics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"pdf-service-go/internal/pkg/auth"
+
+	"github.com/lib/pq"
+)
+
+// ErrDBNotReady возвращается, когда БД статистики ещё не инициализирована
+var ErrDBNotReady = errors.New("statistics DB is not ready")
+
+// APIKeyStore реализует auth.KeyStore поверх PostgreSQL.
+// Экземпляр БД берётся динамически, так как статистика инициализируется в фоне.
+type APIKeyStore struct{}
+
+// NewAPIKeyStore создаёт хранилище API ключей
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{}
+}
+
+func (s *APIKeyStore) db() (*sql.DB, error) {
+	pg := GetPostgresDB()
+	if pg == nil {
+		return nil, ErrDBNotReady
+	}
+	return pg.db, nil
+}
+
+// CreateAPIKey сохраняет новый ключ (только хеш)
+func (s *APIKeyStore) CreateAPIKey(ctx context.Context, key *auth.APIKey, hash string) error {
+	db, err := s.db()
+	if err != nil {
+		return err
+	}
+	attributes, err := json.Marshal(key.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	_, err = db.ExecContext(ctx, `
+        INSERT INTO api_keys (id, name, key_hash, prefix, scopes, attributes, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `, key.ID, key.Name, hash, key.Prefix, pq.Array(key.Scopes), attributes, key.CreatedAt, key.ExpiresAt)
+	return err
+}
+
+// GetAPIKeyByHash ищет ключ по хешу
+func (s *APIKeyStore) GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error) {
+	db, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRowContext(ctx, `
+        SELECT id, name, prefix, scopes, attributes, created_at, expires_at, revoked_at, last_used_at
+        FROM api_keys
+        WHERE key_hash = $1
+    `, hash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrKeyNotFound
+	}
+	return key, err
+}
+
+// ListAPIKeys возвращает все ключи, новые первыми
+func (s *APIKeyStore) ListAPIKeys(ctx context.Context) ([]auth.APIKey, error) {
+	db, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `
+        SELECT id, name, prefix, scopes, attributes, created_at, expires_at, revoked_at, last_used_at
+        FROM api_keys
+        ORDER BY created_at DESC
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []auth.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ
+func (s *APIKeyStore) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+	db, err := s.db()
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`, at, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return auth.ErrKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (s *APIKeyStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	db, err := s.db()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*auth.APIKey, error) {
+	var (
+		key        auth.APIKey
+		attributes []byte
+	)
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &attributes,
+		&key.CreatedAt, &key.ExpiresAt, &key.RevokedAt, &key.LastUsedAt); err != nil {
+		return nil, err
+	}
+	if len(attributes) > 0 {
+		if err := json.Unmarshal(attributes, &key.Attributes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attributes: %w", err)
+		}
+	}
+	return &key, nil
+}
@@ -27,6 +27,8 @@ func getLikelyCause(path string, duration time.Duration) string {
 		return "Timeout - downstream service hanging or processing large data"
 	} else if path == "/api/v1/docx" {
 		return "DOCX generation error or Python script failure"
+	} else if path == "/api/v1/html" {
+		return "HTML template rendering or Chromium conversion error in Gotenberg"
 	} else if path == "/generate-pdf" {
 		return "PDF conversion error in Gotenberg"
 	} else {
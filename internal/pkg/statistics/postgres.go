@@ -97,6 +97,27 @@ func (p *PostgresDB) InitSchema() error {
 			severity TEXT NOT NULL
 		);
 
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL,
+			scopes TEXT[] NOT NULL,
+			attributes JSONB,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			last_used_at TIMESTAMP WITH TIME ZONE
+		);
+
+		CREATE TABLE IF NOT EXISTS client_quotas (
+			client_id TEXT NOT NULL,
+			period TEXT NOT NULL,
+			period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+			used BIGINT NOT NULL,
+			PRIMARY KEY (client_id, period, period_start)
+		);
+
 		CREATE INDEX IF NOT EXISTS idx_request_logs_timestamp ON request_logs(timestamp);
 		CREATE INDEX IF NOT EXISTS idx_docx_logs_timestamp ON docx_logs(timestamp);
 		CREATE INDEX IF NOT EXISTS idx_gotenberg_logs_timestamp ON gotenberg_logs(timestamp);
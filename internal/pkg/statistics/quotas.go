@@ -0,0 +1,86 @@
+package statistics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// quotaRetention срок хранения счётчиков закончившихся периодов
+const quotaRetention = 62 * 24 * time.Hour
+
+// QuotaStore реализует ratelimit.QuotaStore поверх PostgreSQL, чтобы квоты были общими для всех подов
+type QuotaStore struct {
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewQuotaStore создаёт хранилище квот
+func NewQuotaStore() *QuotaStore {
+	return &QuotaStore{}
+}
+
+// Consume атомарно увеличивает счётчик периода, если он меньше limit
+func (s *QuotaStore) Consume(ctx context.Context, clientID, period string, start time.Time, limit int64) (int64, bool, error) {
+	pg := GetPostgresDB()
+	if pg == nil {
+		return 0, false, ErrDBNotReady
+	}
+	s.cleanupIfDue(pg)
+
+	var used int64
+	err := pg.db.QueryRowContext(ctx, `
+        INSERT INTO client_quotas (client_id, period, period_start, used)
+        VALUES ($1, $2, $3, 1)
+        ON CONFLICT (client_id, period, period_start) DO UPDATE
+            SET used = client_quotas.used + 1
+            WHERE client_quotas.used < $4
+        RETURNING used
+    `, clientID, period, start, limit).Scan(&used)
+	if err == nil {
+		return used, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	// Квота исчерпана — строка не обновлена; читаем текущее значение для заголовков ответа
+	if err := pg.db.QueryRowContext(ctx, `
+        SELECT used FROM client_quotas WHERE client_id = $1 AND period = $2 AND period_start = $3
+    `, clientID, period, start).Scan(&used); err != nil {
+		return 0, false, err
+	}
+	return used, false, nil
+}
+
+// Release откатывает списание квоты
+func (s *QuotaStore) Release(ctx context.Context, clientID, period string, start time.Time) error {
+	pg := GetPostgresDB()
+	if pg == nil {
+		return ErrDBNotReady
+	}
+	_, err := pg.db.ExecContext(ctx, `
+        UPDATE client_quotas SET used = used - 1
+        WHERE client_id = $1 AND period = $2 AND period_start = $3 AND used > 0
+    `, clientID, period, start)
+	return err
+}
+
+// cleanupIfDue не чаще раза в час удаляет счётчики закончившихся периодов
+func (s *QuotaStore) cleanupIfDue(pg *PostgresDB) {
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < time.Hour {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, _ = pg.db.ExecContext(ctx, `DELETE FROM client_quotas WHERE period_start < $1`, time.Now().Add(-quotaRetention))
+	}()
+}
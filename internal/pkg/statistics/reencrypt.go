@@ -0,0 +1,77 @@
+package statistics
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/encryption"
+	"pdf-service-go/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// artifactReencrypter реализуется хранилищами, умеющими перешифровывать артефакты
+type artifactReencrypter interface {
+	Reencrypt(ctx context.Context, prefix string) (int, error)
+}
+
+// StartArchiveReencryption запускает фоновое перешифрование архива активным ключом:
+// тела запросов в request_details и артефакты в хранилище. Проход выполняется сразу
+// и затем с интервалом ARCHIVE_REENCRYPT_INTERVAL (по умолчанию 1h).
+func StartArchiveReencryption(ctx context.Context) {
+	keyring := encryption.Default()
+	if !keyring.Enabled() {
+		return
+	}
+	interval := getEnvDurationWithDefault("ARCHIVE_REENCRYPT_INTERVAL", time.Hour)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			reencryptArchive(ctx, keyring)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// reencryptArchive выполняет один проход перешифрования
+func reencryptArchive(ctx context.Context, keyring *encryption.Keyring) {
+	if db := GetPostgresDB(); db != nil {
+		n, err := db.ReencryptRequestBodies(ctx, keyring, 100)
+		if err != nil {
+			logger.Error("Failed to reencrypt request bodies", zap.Error(err))
+		} else if n > 0 {
+			logger.Info("Request bodies reencrypted",
+				zap.Int("count", n),
+				zap.String("key_id", keyring.ActiveKeyID()))
+		}
+	}
+
+	if store, ok := artifacts.Default().(artifactReencrypter); ok {
+		n, err := store.Reencrypt(ctx, "")
+		if err != nil {
+			logger.Error("Failed to reencrypt artifacts", zap.Int("reencrypted", n), zap.Error(err))
+		} else if n > 0 {
+			logger.Info("Artifacts reencrypted",
+				zap.Int("count", n),
+				zap.String("key_id", keyring.ActiveKeyID()))
+		}
+	}
+}
+
+// getEnvDurationWithDefault возвращает значение длительности из переменной окружения или значение по умолчанию
+func getEnvDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
+
+	"pdf-service-go/internal/pkg/annex"
+	"pdf-service-go/internal/pkg/artifacts"
+	"pdf-service-go/internal/pkg/encryption"
 )
 
 // === МЕТОДЫ ДЛЯ РАБОТЫ С ДЕТАЛЬНЫМИ ЗАПРОСАМИ ===
@@ -17,6 +20,12 @@ func (p *PostgresDB) SaveRequestDetail(detail *RequestDetail) error {
 		return fmt.Errorf("failed to marshal headers: %w", err)
 	}
 
+	// Тело запроса может содержать персональные данные — шифруем его, если настроен keyring
+	bodyText, err := encryption.Default().EncryptString(detail.BodyText)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt body: %w", err)
+	}
+
 	query := `
         INSERT INTO request_details (
             request_id, timestamp, method, path, client_ip, user_agent,
@@ -41,7 +50,7 @@ func (p *PostgresDB) SaveRequestDetail(detail *RequestDetail) error {
 
 	_, err = p.db.Exec(query,
 		detail.RequestID, detail.Timestamp, detail.Method, detail.Path,
-		detail.ClientIP, detail.UserAgent, headersJSON, detail.BodyText,
+		detail.ClientIP, detail.UserAgent, headersJSON, bodyText,
 		detail.BodySizeBytes, detail.Success, detail.HTTPStatus, detail.DurationNs,
 		detail.ContentType, detail.HasSensitiveData, detail.ErrorCategory,
 		detail.RequestLogID, detail.DocxLogID, detail.GotenbergLogID,
@@ -112,6 +121,12 @@ func (p *PostgresDB) GetRequestDetail(requestID string) (*RequestDetail, error)
 		}
 	}
 
+	bodyText, err := encryption.Default().DecryptString(detail.BodyText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt body: %w", err)
+	}
+	detail.BodyText = bodyText
+
 	return &detail, nil
 }
 
@@ -223,7 +238,7 @@ func (p *PostgresDB) GetRecentRequestsCtx(ctx context.Context, limit int) ([]Req
             body_size_bytes, success, http_status, duration_ns,
             request_file_path, result_file_path, result_size_bytes
         FROM request_details
-        WHERE path IN ('/api/v1/docx', '/generate-pdf')
+        WHERE path IN ('/api/v1/docx', '/api/v1/html', '/generate-pdf')
         ORDER BY timestamp DESC
         LIMIT $1
     `
@@ -272,7 +287,7 @@ func (p *PostgresDB) GetRecentRequestsWithPaginationCtx(ctx context.Context, lim
 			body_size_bytes, success, http_status, duration_ns,
 			request_file_path, result_file_path, result_size_bytes
 		FROM request_details
-		WHERE path IN ('/api/v1/docx', '/generate-pdf')
+		WHERE path IN ('/api/v1/docx', '/api/v1/html', '/generate-pdf')
 		ORDER BY timestamp DESC
         LIMIT $1 OFFSET $2
 	`
@@ -323,7 +338,7 @@ func (p *PostgresDB) CleanupOldRequestArtifactsKeepLast(keep int) error {
 	}
 
 	// Получаем пути файлов для удаления
-	rows, err := p.db.Query(`SELECT request_file_path, result_file_path FROM request_details WHERE timestamp < $1`, cutoff)
+	rows, err := p.db.Query(`SELECT request_id, request_file_path, result_file_path FROM request_details WHERE timestamp < $1`, cutoff)
 	if err != nil {
 		return err
 	}
@@ -332,8 +347,9 @@ func (p *PostgresDB) CleanupOldRequestArtifactsKeepLast(keep int) error {
 	var reqPaths []string
 	var resPaths []string
 	for rows.Next() {
+		var requestID string
 		var reqPath, resPath *string
-		if err := rows.Scan(&reqPath, &resPath); err != nil {
+		if err := rows.Scan(&requestID, &reqPath, &resPath); err != nil {
 			return err
 		}
 		if reqPath != nil && *reqPath != "" {
@@ -342,14 +358,91 @@ func (p *PostgresDB) CleanupOldRequestArtifactsKeepLast(keep int) error {
 		if resPath != nil && *resPath != "" {
 			resPaths = append(resPaths, *resPath)
 		}
+		// Ключи приложений не хранятся в БД: они однозначно выводятся из request_id
+		for _, format := range annex.Formats {
+			resPaths = append(resPaths, artifacts.AnnexKey(requestID, format))
+		}
 	}
 
-	// Удаляем файлы на диске (игнорируем ошибки удаления)
+	// Удаляем артефакты из хранилища (игнорируем ошибки удаления)
+	store := artifacts.Default()
 	for _, pth := range append(reqPaths, resPaths...) {
-		_ = os.Remove(pth)
+		_ = store.Delete(context.Background(), pth)
 	}
 
 	// Удаляем старые записи
 	_, err = p.db.Exec(`DELETE FROM request_details WHERE timestamp < $1`, cutoff)
 	return err
 }
+
+// ReencryptRequestBodies перешифровывает активным ключом тела запросов, зашифрованные старыми ключами
+// или сохранённые открытым текстом. Обрабатывает записи пачками по batchSize, возвращает число обновлённых.
+func (p *PostgresDB) ReencryptRequestBodies(ctx context.Context, keyring *encryption.Keyring, batchSize int) (int, error) {
+	if !keyring.Enabled() {
+		return 0, nil
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	activePrefix := keyring.ActiveStringPrefix() + "%"
+
+	var (
+		updated int
+		lastID  int64
+	)
+	for {
+		rows, err := p.db.QueryContext(ctx, `
+            SELECT id, body_text FROM request_details
+            WHERE id > $1 AND body_text IS NOT NULL AND body_text <> '' AND body_text NOT LIKE $2
+            ORDER BY id
+            LIMIT $3
+        `, lastID, activePrefix, batchSize)
+		if err != nil {
+			return updated, err
+		}
+
+		type row struct {
+			id   int64
+			body string
+		}
+		var batch []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.body); err != nil {
+				rows.Close()
+				return updated, err
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, err
+		}
+		if len(batch) == 0 {
+			return updated, nil
+		}
+
+		for _, r := range batch {
+			lastID = r.id
+			plaintext, err := keyring.DecryptString(r.body)
+			if err != nil {
+				// Ключ отсутствует в keyring — запись пропускаем, чтобы не блокировать остальные
+				continue
+			}
+			ciphertext, err := keyring.EncryptString(plaintext)
+			if err != nil {
+				return updated, err
+			}
+			// Условие по старому значению защищает от гонки с параллельной записью
+			res, err := p.db.ExecContext(ctx,
+				`UPDATE request_details SET body_text = $1 WHERE id = $2 AND body_text = $3`,
+				ciphertext, r.id, r.body)
+			if err != nil {
+				return updated, err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				updated++
+			}
+		}
+	}
+}
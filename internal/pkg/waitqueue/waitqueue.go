@@ -0,0 +1,113 @@
+// Package waitqueue содержит общую для отсеков и контроллера допуска очередь ожидания слота:
+// FIFO очередь, выдачу слота ожидающему и оценку Retry-After по времени удержания слота.
+package waitqueue
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout истекло время ожидания в очереди
+var ErrTimeout = errors.New("queue wait timeout")
+
+// Waiter ожидающий слота
+type Waiter struct {
+	ready chan struct{}
+	// granted слот уже выдан ожидающему
+	granted bool
+	elem    *list.Element
+}
+
+// Wait ждёт выдачи слота не дольше timeout. Возвращает nil, если слот выдан, ErrTimeout или ошибку контекста.
+// Ожидающего без слота владелец очереди убирает через Queue.Remove под своей блокировкой.
+func (w *Waiter) Wait(ctx context.Context, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-timer.C:
+		return ErrTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Queue FIFO очередь ожидающих. Не потокобезопасна: методы вызываются под блокировкой владельца
+type Queue struct {
+	waiters *list.List
+}
+
+// New создаёт пустую очередь
+func New() *Queue {
+	return &Queue{waiters: list.New()}
+}
+
+// Len возвращает число ожидающих
+func (q *Queue) Len() int {
+	return q.waiters.Len()
+}
+
+// Push ставит нового ожидающего в конец очереди
+func (q *Queue) Push() *Waiter {
+	w := &Waiter{ready: make(chan struct{})}
+	w.elem = q.waiters.PushBack(w)
+	return w
+}
+
+// Grant выдаёт слот первому ожидающему. Возвращает false, если очередь пуста
+func (q *Queue) Grant() bool {
+	front := q.waiters.Front()
+	if front == nil {
+		return false
+	}
+	w := q.waiters.Remove(front).(*Waiter)
+	w.granted = true
+	close(w.ready)
+	return true
+}
+
+// Remove убирает ожидающего, не дождавшегося слота. Возвращает false, если слот выдан одновременно
+// с таймаутом или отменой: тогда владелец должен освободить этот слот
+func (q *Queue) Remove(w *Waiter) bool {
+	if w.granted {
+		return false
+	}
+	q.waiters.Remove(w.elem)
+	return true
+}
+
+// HoldEstimator скользящее среднее времени удержания слота для оценки Retry-After.
+// Не потокобезопасен, как и Queue
+type HoldEstimator struct {
+	avg time.Duration
+}
+
+// Observe учитывает время удержания освобождённого слота
+func (e *HoldEstimator) Observe(hold time.Duration) {
+	if e.avg == 0 {
+		e.avg = hold
+		return
+	}
+	e.avg = (e.avg*4 + hold) / 5
+}
+
+// RetryAfter оценивает, когда освободится место для queued ожидающих при capacity слотах (от секунды до минуты)
+func (e *HoldEstimator) RetryAfter(queued, capacity int) time.Duration {
+	hold := e.avg
+	if hold <= 0 {
+		hold = time.Second
+	}
+	waves := (queued + capacity) / capacity
+	retryAfter := hold * time.Duration(waves)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	if retryAfter > time.Minute {
+		retryAfter = time.Minute
+	}
+	return retryAfter
+}
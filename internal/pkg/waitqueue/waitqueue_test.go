@@ -0,0 +1,67 @@
+package waitqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueue_GrantsInOrder(t *testing.T) {
+	q := New()
+	first, second := q.Push(), q.Push()
+
+	if !q.Grant() {
+		t.Fatal("Expected slot to be granted")
+	}
+	if err := first.Wait(context.Background(), time.Second); err != nil {
+		t.Fatalf("Expected first waiter to get the slot, got %v", err)
+	}
+	if q.Len() != 1 {
+		t.Errorf("Expected 1 waiter left, got %d", q.Len())
+	}
+	if err := second.Wait(context.Background(), 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected second waiter to time out, got %v", err)
+	}
+	if !q.Remove(second) || q.Len() != 0 {
+		t.Errorf("Expected timed out waiter to leave the queue, got %d", q.Len())
+	}
+	if q.Grant() {
+		t.Error("Expected no grant from empty queue")
+	}
+}
+
+func TestQueue_RemoveAfterGrant(t *testing.T) {
+	q := New()
+	w := q.Push()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := w.Wait(ctx, time.Second); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context error, got %v", err)
+	}
+	// Слот выдан после отмены — владелец должен его освободить
+	q.Grant()
+	if q.Remove(w) {
+		t.Error("Expected Remove to report granted slot")
+	}
+}
+
+func TestHoldEstimator_RetryAfter(t *testing.T) {
+	var e HoldEstimator
+	if d := e.RetryAfter(0, 2); d != time.Second {
+		t.Errorf("Expected 1s without observations, got %v", d)
+	}
+
+	e.Observe(10 * time.Second)
+	e.Observe(5 * time.Second)
+	if d := e.RetryAfter(0, 2); d != 9*time.Second {
+		t.Errorf("Expected moving average 9s, got %v", d)
+	}
+	if d := e.RetryAfter(4, 2); d != 27*time.Second {
+		t.Errorf("Expected 3 waves of 9s, got %v", d)
+	}
+	if d := e.RetryAfter(100, 2); d != time.Minute {
+		t.Errorf("Expected Retry-After capped at 1m, got %v", d)
+	}
+}
@@ -3,6 +3,7 @@ import sys
 import json
 import os
 from docxtpl import DocxTemplate
+from jinja2 import TemplateError
 import logging
 from datetime import datetime
 from pathlib import Path
@@ -20,6 +21,26 @@ logger = logging.getLogger(__name__)
 # Глобальный шаблон
 TEMPLATE = None
 
+# Код выхода при некорректном шаблоне или данных; сервис не считает его сбоем генератора.
+# Ошибки ввода-вывода, ОС и непредвиденные исключения завершаются кодом 1 и учитываются Circuit Breaker
+EXIT_INVALID_INPUT = 2
+
+class InvalidInputError(Exception):
+    """Некорректные данные запроса или шаблон."""
+
+def validate_data(data):
+    """Проверяет структуру данных запроса."""
+    if not isinstance(data, dict):
+        raise InvalidInputError("data must be a JSON object")
+    items = data.get('registryItems')
+    if items is not None and (not isinstance(items, list) or not all(isinstance(item, dict) for item in items)):
+        raise InvalidInputError("registryItems must be a list of objects")
+    if not isinstance(data.get('id', ''), str):
+        raise InvalidInputError("id must be a string")
+    pages = data.get('pages', 0)
+    if isinstance(pages, bool) or not isinstance(pages, int):
+        raise InvalidInputError("pages must be an integer")
+
 def init_app(template_path):
     """Инициализация приложения."""
     global TEMPLATE
@@ -150,93 +171,91 @@ def process_template(data, output_path, timings=None):
         logger.error("Template not initialized")
         raise ValueError("Template not initialized")
         
-    try:
-        # Проверяем, является ли это черновиком для подсчета страниц
-        is_draft = data.get('isDraft', False)
-        if is_draft:
-            logger.info("Processing DRAFT document for page counting")
+    validate_data(data)
+
+    # Проверяем, является ли это черновиком для подсчета страниц
+    is_draft = data.get('isDraft', False)
+    if is_draft:
+        logger.info("Processing DRAFT document for page counting")
+    else:
+        logger.info("Processing FINAL document with page count")
+    
+    # Обрабатываем даты и генерируем информацию о заявителе
+    t_pd_start = time.time()
+    process_dates(data)
+    if timings is not None:
+        timings.append({"stage": "process_dates", "ms": round((time.time() - t_pd_start) * 1000, 2)})
+    t_ai_start = time.time()
+    data['applicant_info'] = generate_applicant_info(data)
+    if timings is not None:
+        timings.append({"stage": "generate_applicant_info", "ms": round((time.time() - t_ai_start) * 1000, 2)})
+    
+    # Создаем укороченный ID без текста ЕФГИ
+    if 'id' in data:
+        # Используем ID
+        full_id = data.get('id', '')
+        short_id = full_id
+        
+        # Если ID начинается с "ЕФГИ-", берем часть строки с 5-го символа
+        if full_id and full_id.startswith("ЕФГИ-"):
+            short_id = full_id[5:]  # Получаем строку начиная с 5-го символа (после "ЕФГИ-")
+            logger.info(f"Extracted short ID: '{short_id}' from full ID: '{full_id}'")
         else:
-            logger.info("Processing FINAL document with page count")
+            logger.info(f"ID '{full_id}' does not start with 'ЕФГИ-', keeping original")
         
-        # Обрабатываем даты и генерируем информацию о заявителе
-        t_pd_start = time.time()
-        process_dates(data)
-        if timings is not None:
-            timings.append({"stage": "process_dates", "ms": round((time.time() - t_pd_start) * 1000, 2)})
-        t_ai_start = time.time()
-        data['applicant_info'] = generate_applicant_info(data)
-        if timings is not None:
-            timings.append({"stage": "generate_applicant_info", "ms": round((time.time() - t_ai_start) * 1000, 2)})
+        data['short_id'] = short_id
+    
+    # Устанавливаем количество страниц для отображения
+    # Для черновика устанавливаем заглушку, для финала - используем счетчик
+    if is_draft:
+        # В черновике просто используем заглушку, т.к. этот документ только для подсчета
+        data['display_pages'] = "[Подсчет страниц...]"
+        logger.info(f"Using placeholder for page count in draft document")
+    else:
+        # В финальном документе используем реальное количество страниц
+        page_count = data.get('pages', 0)
         
-        # Создаем укороченный ID без текста ЕФГИ
-        if 'id' in data:
-            # Используем ID
-            full_id = data.get('id', '')
-            short_id = full_id
-            
-            # Если ID начинается с "ЕФГИ-", берем часть строки с 5-го символа
-            if full_id and full_id.startswith("ЕФГИ-"):
-                short_id = full_id[5:]  # Получаем строку начиная с 5-го символа (после "ЕФГИ-")
-                logger.info(f"Extracted short ID: '{short_id}' from full ID: '{full_id}'")
-            else:
-                logger.info(f"ID '{full_id}' does not start with 'ЕФГИ-', keeping original")
-            
-            data['short_id'] = short_id
+        # Вычитаем еще одну страницу для отображения (сопроводительная записка)
+        display_count = max(1, page_count - 1)
         
-        # Устанавливаем количество страниц для отображения
-        # Для черновика устанавливаем заглушку, для финала - используем счетчик
-        if is_draft:
-            # В черновике просто используем заглушку, т.к. этот документ только для подсчета
-            data['display_pages'] = "[Подсчет страниц...]"
-            logger.info(f"Using placeholder for page count in draft document")
+        # Логика отображения: 
+        # Если 1 страница - "на 1 листе"
+        # Для остальных случаев - "на X листах"
+        if display_count == 1:
+            data['display_pages'] = "на 1 листе"
         else:
-            # В финальном документе используем реальное количество страниц
-            page_count = data.get('pages', 0)
-            
-            # Вычитаем еще одну страницу для отображения (сопроводительная записка)
-            display_count = max(1, page_count - 1)
-            
-            # Логика отображения: 
-            # Если 1 страница - "на 1 листе"
-            # Для остальных случаев - "на X листах"
-            if display_count == 1:
-                data['display_pages'] = "на 1 листе"
-            else:
-                data['display_pages'] = f"на {display_count} листах"
-            
-            logger.info(f"Setting display pages to: '{data['display_pages']}', actual page count: {page_count}, display count: {display_count}")
+            data['display_pages'] = f"на {display_count} листах"
         
-        # Логируем все переменные для отладки
-        logger.info("Template variables:")
-        logger.info(f"id: {data.get('id', 'NOT FOUND')}")
-        logger.info(f"short_id: {data.get('short_id', 'NOT FOUND')}")
-        logger.info(f"creationDate: {data.get('creationDate', 'NOT FOUND')}")
-        logger.info(f"isDraft: {is_draft}")
-        logger.info(f"pages: {data.get('pages', 0)}")
-        logger.info(f"display_pages: {data.get('display_pages', 'NOT SET')}")
-        logger.info(f"status: {data.get('status', 'NOT SET')}")
-        
-        try:
-            # Рендерим документ
-            t_render_start = time.time()
-            TEMPLATE.render(data)
-            render_ms = round((time.time() - t_render_start) * 1000, 2)
-            if timings is not None:
-                timings.append({"stage": "render", "ms": render_ms})
+        logger.info(f"Setting display pages to: '{data['display_pages']}', actual page count: {page_count}, display count: {display_count}")
+    
+    # Логируем все переменные для отладки
+    logger.info("Template variables:")
+    logger.info(f"id: {data.get('id', 'NOT FOUND')}")
+    logger.info(f"short_id: {data.get('short_id', 'NOT FOUND')}")
+    logger.info(f"creationDate: {data.get('creationDate', 'NOT FOUND')}")
+    logger.info(f"isDraft: {is_draft}")
+    logger.info(f"pages: {data.get('pages', 0)}")
+    logger.info(f"display_pages: {data.get('display_pages', 'NOT SET')}")
+    logger.info(f"status: {data.get('status', 'NOT SET')}")
+    
+    # Рендерим документ: синтаксические ошибки шаблона и ошибки подстановки данных — некорректный ввод
+    t_render_start = time.time()
+    try:
+        TEMPLATE.render(data)
+    except TemplateError as e:
+        raise InvalidInputError(f"template rendering failed: {e}") from e
+    render_ms = round((time.time() - t_render_start) * 1000, 2)
+    if timings is not None:
+        timings.append({"stage": "render", "ms": render_ms})
 
-            t_save_start = time.time()
-            TEMPLATE.save(output_path)
-            save_ms = round((time.time() - t_save_start) * 1000, 2)
-            if timings is not None:
-                timings.append({"stage": "save", "ms": save_ms})
-            logger.info("Document generated successfully")
-            return True
-        except Exception as e:
-            logger.error(f"Error rendering template: {e}", exc_info=True)
-            return False
-    except Exception as e:
-        logger.error(f"Error processing template: {e}", exc_info=True)
-        return False
+    # Ошибки записи (нет места, нет прав) — сбой генератора
+    t_save_start = time.time()
+    TEMPLATE.save(output_path)
+    save_ms = round((time.time() - t_save_start) * 1000, 2)
+    if timings is not None:
+        timings.append({"stage": "save", "ms": save_ms})
+    logger.info("Document generated successfully")
+    return True
 
 def main():
     if len(sys.argv) != 4:
@@ -259,6 +278,7 @@ def main():
             data = json.load(f)
         read_ms = round((time.time() - t_read_start) * 1000, 2)
     except Exception as e:
+        # Шаблон и файл данных готовит сервис: их недоступность — сбой генератора, а не ошибка запроса
         logger.error("Error during initialization: %s", e)
         sys.exit(1)
 
@@ -267,7 +287,15 @@ def main():
     timings.append({"stage": "read_input", "ms": read_ms})
 
     t_proc_start = time.time()
-    ok = process_template(data, output_path, timings)
+    exit_code = 0
+    try:
+        process_template(data, output_path, timings)
+    except InvalidInputError as e:
+        logger.error("Invalid template or data: %s", e)
+        exit_code = EXIT_INVALID_INPUT
+    except Exception as e:
+        logger.error("Error processing template: %s", e, exc_info=True)
+        exit_code = 1
     total_ms = round((time.time() - t0) * 1000, 2)
 
     # Пишем тайминги в stdout и в файл рядом с выходным DOCX
@@ -284,8 +312,8 @@ def main():
     except Exception:
         traceback.print_exc()
 
-    if not ok:
-        sys.exit(1)
+    if exit_code:
+        sys.exit(exit_code)
 
 if __name__ == "__main__":
     main() 
\ No newline at end of file